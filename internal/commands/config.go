@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/snyk/parlay/internal/config"
+)
+
+func NewConfigCommand(logger *zerolog.Logger) *cobra.Command {
+	cmd := cobra.Command{
+		Use:                   "config",
+		Short:                 "Commands for inspecting the parlay configuration",
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := cmd.Help(); err != nil {
+				logger.Fatal().Err(err).Msg("Failed to run config command")
+			}
+		},
+	}
+
+	cmd.AddCommand(NewConfigShowCommand(logger))
+
+	return &cmd
+}
+
+func NewConfigShowCommand(logger *zerolog.Logger) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration, with secrets redacted",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if path := viper.ConfigFileUsed(); path != "" {
+				fmt.Fprintf(os.Stdout, "# Loaded from %s\n", path)
+			}
+
+			enc := yaml.NewEncoder(os.Stdout)
+			enc.SetIndent(2)
+			if err := enc.Encode(config.Redact(viper.AllSettings())); err != nil {
+				logger.Fatal().Err(err).Msg("Failed to encode configuration")
+			}
+		},
+	}
+
+	return &cmd
+}
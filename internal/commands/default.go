@@ -1,7 +1,10 @@
 package commands
 
 import (
+	"context"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
@@ -9,8 +12,15 @@ import (
 
 	"github.com/snyk/parlay/internal/commands/deps"
 	"github.com/snyk/parlay/internal/commands/ecosystems"
+	"github.com/snyk/parlay/internal/commands/exploit"
+	"github.com/snyk/parlay/internal/commands/osv"
 	"github.com/snyk/parlay/internal/commands/scorecard"
+	"github.com/snyk/parlay/internal/commands/slsa"
 	"github.com/snyk/parlay/internal/commands/snyk"
+	"github.com/snyk/parlay/internal/config"
+	"github.com/snyk/parlay/internal/httpclient"
+	"github.com/snyk/parlay/internal/utils"
+	ecosystemslib "github.com/snyk/parlay/lib/ecosystems"
 )
 
 // These values are set at build time
@@ -21,6 +31,7 @@ var (
 func NewDefaultCommand() *cobra.Command {
 	output := zerolog.ConsoleWriter{Out: os.Stderr}
 	logger := zerolog.New(output).With().Timestamp().Logger()
+	cancel := context.CancelFunc(func() {})
 
 	cmd := cobra.Command{
 		Use:                   "parlay",
@@ -34,17 +45,79 @@ func NewDefaultCommand() *cobra.Command {
 			}
 		},
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			path, err := config.Find(viper.GetString("config"))
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to find configuration")
+			}
+			if path != "" {
+				if err := config.Load(viper.GetViper(), path); err != nil {
+					logger.Fatal().Err(err).Msg("Failed to load configuration")
+				}
+			}
+			if err := config.ApplyFlags(viper.GetViper(), cmd, configSection(cmd)); err != nil {
+				logger.Fatal().Err(err).Msg("Invalid configuration")
+			}
+
 			if viper.GetBool("debug") {
 				zerolog.SetGlobalLevel(zerolog.DebugLevel)
 			} else {
 				zerolog.SetGlobalLevel(zerolog.InfoLevel)
 			}
+
+			if timeout := viper.GetDuration("timeout"); timeout > 0 {
+				var ctx context.Context
+				ctx, cancel = context.WithTimeout(cmd.Context(), timeout)
+				cmd.SetContext(ctx)
+			}
+
+			client, err := newHTTPClient(&logger)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Invalid HTTP client configuration")
+			}
+			httpclient.SetDefault(client)
+
+			endpoints, err := ecosystemsEndpoints()
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Invalid ecosyste.ms configuration")
+			}
+			ecosystemslib.SetEndpoints(endpoints)
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			cancel()
 		},
 	}
 	cmd.CompletionOptions.HiddenDefaultCmd = true
 
+	// Settings can also be given through PARLAY_ environment variables, e.g.
+	// PARLAY_ECOSYSTEMS_URL for ecosystems.url.
+	viper.SetEnvPrefix("parlay")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
+	viper.AutomaticEnv()
+
+	cmd.PersistentFlags().String("config", "", "Read configuration from this file instead of "+config.FileName)
+	viper.BindPFlag("config", cmd.PersistentFlags().Lookup("config")) //nolint:errcheck
 	cmd.PersistentFlags().Bool("debug", false, "")
 	viper.BindPFlag("debug", cmd.PersistentFlags().Lookup("debug")) //nolint:errcheck
+	cmd.PersistentFlags().Duration("timeout", 0, "Stop after this long, writing out partial results, e.g. 5m")
+	viper.BindPFlag("timeout", cmd.PersistentFlags().Lookup("timeout")) //nolint:errcheck
+	cmd.PersistentFlags().Duration("per-request-timeout", 0, "Give up on a single request after this long, e.g. 30s")
+	viper.BindPFlag("per-request-timeout", cmd.PersistentFlags().Lookup("per-request-timeout")) //nolint:errcheck
+	cmd.PersistentFlags().StringSlice("max-retries", nil, "Retry failed requests up to this many times, either as <n> or <host>=<n>")
+	viper.BindPFlag("max-retries", cmd.PersistentFlags().Lookup("max-retries")) //nolint:errcheck
+	cmd.PersistentFlags().StringSlice("rate-limit", nil, "Send at most this many requests per second to each host, either as <rps> or <host>=<rps>")
+	viper.BindPFlag("rate-limit", cmd.PersistentFlags().Lookup("rate-limit")) //nolint:errcheck
+	cmd.PersistentFlags().String("proxy", "", "Send requests through this proxy instead of the one set by HTTPS_PROXY")
+	viper.BindPFlag("proxy", cmd.PersistentFlags().Lookup("proxy")) //nolint:errcheck
+	cmd.PersistentFlags().String("ca-bundle", "", "Trust the certificate authorities in this PEM file")
+	viper.BindPFlag("ca-bundle", cmd.PersistentFlags().Lookup("ca-bundle")) //nolint:errcheck
+
+	endpoints := ecosystemslib.DefaultEndpoints()
+	cmd.PersistentFlags().String("ecosystems-url", endpoints.PackagesURL, "Base URL of the ecosyste.ms packages API")
+	viper.BindPFlag("ecosystems.url", cmd.PersistentFlags().Lookup("ecosystems-url")) //nolint:errcheck
+	cmd.PersistentFlags().String("ecosystems-repos-url", endpoints.ReposURL, "Base URL of the ecosyste.ms repositories API")
+	viper.BindPFlag("ecosystems.repos-url", cmd.PersistentFlags().Lookup("ecosystems-repos-url")) //nolint:errcheck
+	cmd.PersistentFlags().StringArray("ecosystems-header", nil, "Add this header to requests to ecosyste.ms, as \"<name>: <value>\"")
+	viper.BindPFlag("ecosystems.header", cmd.PersistentFlags().Lookup("ecosystems-header")) //nolint:errcheck
 
 	cmd.SetVersionTemplate(`{{.Version}}`)
 
@@ -52,10 +125,52 @@ func NewDefaultCommand() *cobra.Command {
 	cmd.AddCommand(snyk.NewSnykRootCommand(&logger))
 	cmd.AddCommand(deps.NewDepsRootCommand(&logger))
 	cmd.AddCommand(scorecard.NewRootCommand(&logger))
+	cmd.AddCommand(slsa.NewRootCommand(&logger))
+	cmd.AddCommand(osv.NewRootCommand(&logger))
+	cmd.AddCommand(exploit.NewRootCommand(&logger))
+	cmd.AddCommand(NewConfigCommand(&logger))
 
 	return &cmd
 }
 
+func newHTTPClient(logger *zerolog.Logger) (*http.Client, error) {
+	cfg := httpclient.DefaultConfig()
+	cfg.Proxy = viper.GetString("proxy")
+	cfg.CABundle = viper.GetString("ca-bundle")
+	for _, spec := range viper.GetStringSlice("max-retries") {
+		if err := cfg.SetRetryMax(spec); err != nil {
+			return nil, err
+		}
+	}
+	for _, spec := range viper.GetStringSlice("rate-limit") {
+		if err := cfg.SetRateLimit(spec); err != nil {
+			return nil, err
+		}
+	}
+	return httpclient.New(cfg, logger)
+}
+
+// configSection returns the section of the configuration file holding the
+// settings of cmd, e.g. "ecosystems" for "parlay ecosystems enrich".
+func configSection(cmd *cobra.Command) string {
+	if !cmd.HasParent() || cmd.Parent() == cmd.Root() {
+		return ""
+	}
+	return cmd.Parent().Name()
+}
+
+func ecosystemsEndpoints() (*ecosystemslib.Endpoints, error) {
+	header, err := utils.GetHeader("ecosystems.header")
+	if err != nil {
+		return nil, err
+	}
+	return &ecosystemslib.Endpoints{
+		PackagesURL: viper.GetString("ecosystems.url"),
+		ReposURL:    viper.GetString("ecosystems.repos-url"),
+		Header:      header,
+	}, nil
+}
+
 func GetVersion() string {
 	return version
 }
@@ -0,0 +1,64 @@
+package deps
+
+import (
+	"fmt"
+	"os"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/snyk/parlay/lib/deps"
+)
+
+func NewDependenciesCommand(logger *zerolog.Logger) *cobra.Command {
+	var format string
+
+	cmd := cobra.Command{
+		Use:   "dependencies <purl>",
+		Short: "Return the resolved dependency graph of a package version from deps.dev",
+		Long: "Return the dependency graph deps.dev resolved for a package version.\n" +
+			"With --format cyclonedx, the graph is written as a CycloneDX SBOM of the package version.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if format != "json" && format != "cyclonedx" {
+				logger.Fatal().Msgf("Unknown format %q, expected json or cyclonedx", format)
+			}
+			purl, err := packageurl.FromString(args[0])
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to parse PackageURL")
+			}
+			cfg := deps.DefaultConfig()
+			if err := configure(cfg); err != nil {
+				logger.Fatal().Err(err).Msg("Invalid deps.dev configuration")
+			}
+
+			resp, err := deps.GetDependenciesData(cmd.Context(), cfg, purl)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to get dependencies from deps.dev")
+			}
+
+			if format == "json" {
+				fmt.Print(string(resp.Body))
+				return
+			}
+
+			if resp.Data.Error != "" {
+				logger.Warn().Str("error", resp.Data.Error).Msg("deps.dev could only partially resolve the dependency graph")
+			}
+			bom, err := deps.DependencyGraphBOM(resp.Data, cmd.Root().Version)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to create SBOM")
+			}
+
+			if err := cdx.NewBOMEncoder(os.Stdout, cdx.BOMFileFormatJSON).Encode(bom); err != nil {
+				logger.Fatal().Err(err).Msg("Failed to encode SBOM")
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "json", "Output format, either json or cyclonedx")
+
+	return &cmd
+}
@@ -0,0 +1,63 @@
+package deps
+
+import (
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/deps"
+	"github.com/snyk/parlay/lib/report"
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+func NewEnrichCommand(logger *zerolog.Logger) *cobra.Command {
+	cfg := deps.DefaultConfig()
+	var reportPath string
+
+	cmd := cobra.Command{
+		Use:   "enrich <sbom>",
+		Short: "Enrich an SBOM with deps.dev data",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.Report = report.New()
+			if err := configure(cfg); err != nil {
+				logger.Fatal().Err(err).Msg("Invalid deps.dev configuration")
+			}
+
+			b, err := utils.GetUserInput(args[0], os.Stdin)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to read input")
+			}
+
+			doc, err := sbom.DecodeSBOMDocument(b)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to read SBOM input")
+			}
+
+			deps.EnrichSBOM(cmd.Context(), cfg, doc)
+			if err := cmd.Context().Err(); err != nil {
+				logger.Warn().Err(err).Msg("Enrichment was interrupted, writing partial results")
+			}
+			doc.RecordTool("parlay", cmd.Root().Version)
+
+			if err := doc.Encode(os.Stdout); err != nil {
+				logger.Fatal().Err(err).Msg("Failed to encode new SBOM")
+			}
+
+			cfg.Report.LogSummary(logger)
+			if reportPath != "" {
+				if err := cfg.Report.WriteFile(reportPath); err != nil {
+					logger.Fatal().Err(err).Msg("Failed to write report")
+				}
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&cfg.Concurrency, "concurrency", cfg.Concurrency, "Number of packages to enrich in parallel")
+
+	cmd.Flags().StringVar(&reportPath, "report", "", "Write a JSON report of the outcome for each component to this file")
+
+	return &cmd
+}
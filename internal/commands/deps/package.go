@@ -0,0 +1,37 @@
+package deps
+
+import (
+	"fmt"
+
+	"github.com/package-url/packageurl-go"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/snyk/parlay/lib/deps"
+)
+
+func NewPackageCommand(logger *zerolog.Logger) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "package <purl>",
+		Short: "Return package info from deps.dev",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			purl, err := packageurl.FromString(args[0])
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to parse PackageURL")
+			}
+			cfg := deps.DefaultConfig()
+			if err := configure(cfg); err != nil {
+				logger.Fatal().Err(err).Msg("Invalid deps.dev configuration")
+			}
+
+			resp, err := deps.GetPackageData(cmd.Context(), cfg, purl)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to get package data from deps.dev")
+			}
+
+			fmt.Print(string(resp.Body))
+		},
+	}
+	return &cmd
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
+	"github.com/snyk/parlay/internal/utils"
 	"github.com/snyk/parlay/lib/deps"
 )
 
@@ -16,7 +18,10 @@ func NewRepoCommand(logger *zerolog.Logger) *cobra.Command {
 		Short: "Return repo info from deps.dev",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			repo, err := deps.GetRepoData(args[0])
+			ctx, cancel := utils.WithRequestTimeout(cmd.Context(), viper.GetDuration("per-request-timeout"))
+			defer cancel()
+
+			repo, err := deps.GetRepoData(ctx, args[0])
 			if err != nil {
 				logger.Fatal().Err(err).Msg("Failed to retrieve data from deps.dev")
 			}
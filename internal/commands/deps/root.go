@@ -3,6 +3,10 @@ package deps
 import (
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/deps"
 )
 
 func NewDepsRootCommand(logger *zerolog.Logger) *cobra.Command {
@@ -19,7 +23,29 @@ func NewDepsRootCommand(logger *zerolog.Logger) *cobra.Command {
 		},
 	}
 
+	cmd.PersistentFlags().String("deps-url", deps.DefaultConfig().APIURL, "Base URL of the deps.dev API")
+	viper.BindPFlag("deps.url", cmd.PersistentFlags().Lookup("deps-url")) //nolint:errcheck
+	cmd.PersistentFlags().StringArray("deps-header", nil, "Add this header to requests to the deps.dev API, as \"<name>: <value>\"")
+	viper.BindPFlag("deps.header", cmd.PersistentFlags().Lookup("deps-header")) //nolint:errcheck
+
 	cmd.AddCommand(NewRepoCommand(logger))
+	cmd.AddCommand(NewPackageCommand(logger))
+	cmd.AddCommand(NewVersionCommand(logger))
+	cmd.AddCommand(NewDependenciesCommand(logger))
+	cmd.AddCommand(NewEnrichCommand(logger))
 
 	return &cmd
 }
+
+// configure sets up access to the deps.dev API from the flags, environment
+// and configuration file.
+func configure(cfg *deps.Config) error {
+	cfg.RequestTimeout = viper.GetDuration("per-request-timeout")
+	cfg.APIURL = viper.GetString("deps.url")
+	header, err := utils.GetHeader("deps.header")
+	if err != nil {
+		return err
+	}
+	cfg.Header = header
+	return nil
+}
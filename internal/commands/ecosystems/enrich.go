@@ -5,18 +5,38 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/snyk/parlay/internal/utils"
 	"github.com/snyk/parlay/lib/ecosystems"
+	"github.com/snyk/parlay/lib/report"
 	"github.com/snyk/parlay/lib/sbom"
 )
 
 func NewEnrichCommand(logger *zerolog.Logger) *cobra.Command {
+	cfg := ecosystems.DefaultConfig()
+	var merge, include, exclude []string
+	var reportPath string
+
 	cmd := cobra.Command{
 		Use:   "enrich <sbom>",
 		Short: "Enrich an SBOM with ecosyste.ms data",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
+			if len(include) > 0 || len(exclude) > 0 {
+				if err := cfg.SelectFields(include, exclude); err != nil {
+					logger.Fatal().Err(err).Msg("Invalid field selection")
+				}
+			}
+			for _, spec := range merge {
+				if err := cfg.SetMergePolicy(spec); err != nil {
+					logger.Fatal().Err(err).Msg("Invalid merge policy")
+				}
+			}
+
+			cfg.Report = report.New()
+			cfg.RequestTimeout = viper.GetDuration("per-request-timeout")
+
 			b, err := utils.GetUserInput(args[0], os.Stdin)
 			if err != nil {
 				logger.Fatal().Err(err).Msg("Failed to read input")
@@ -27,12 +47,39 @@ func NewEnrichCommand(logger *zerolog.Logger) *cobra.Command {
 				logger.Fatal().Err(err).Msg("Failed to read SBOM input")
 			}
 
-			ecosystems.EnrichSBOM(doc, logger)
+			ecosystems.EnrichSBOM(cmd.Context(), cfg, doc, logger)
+			if err := cmd.Context().Err(); err != nil {
+				logger.Warn().Err(err).Msg("Enrichment was interrupted, writing partial results")
+			}
+			doc.RecordTool("parlay", cmd.Root().Version)
 
 			if err := doc.Encode(os.Stdout); err != nil {
 				logger.Fatal().Err(err).Msg("Failed to encode new SBOM")
 			}
+
+			cfg.Report.LogSummary(logger)
+			if reportPath != "" {
+				if err := cfg.Report.WriteFile(reportPath); err != nil {
+					logger.Fatal().Err(err).Msg("Failed to write report")
+				}
+			}
 		},
 	}
+
+	cmd.Flags().IntVar(&cfg.MinDownloads, "min-downloads", 0, "Flag packages with fewer downloads than this as low-adoption")
+	cmd.Flags().IntVar(&cfg.MinDependentPackages, "min-dependent-packages", 0, "Flag packages with fewer dependent packages than this as low-adoption")
+	cmd.Flags().IntVar(&cfg.MinDependentRepos, "min-dependent-repos", 0, "Flag packages with fewer dependent repositories than this as low-adoption")
+	cmd.Flags().BoolVar(&cfg.RepositoryMetadata, "repository-metadata", false, "Add repository metadata from repos.ecosyste.ms")
+	cmd.Flags().BoolVar(&cfg.DependencyGraph, "dependency-graph", false, "Reconstruct the dependency graph between components from ecosyste.ms")
+	cmd.Flags().BoolVar(&cfg.InferPurls, "infer-purls", false, "Infer missing PackageURLs from CPEs, download locations and names")
+	cmd.Flags().StringVar(&cfg.DefaultEcosystem, "default-ecosystem", "", "PackageURL type to assume when inferring PackageURLs from names, e.g. npm")
+	cmd.Flags().IntVar(&cfg.Concurrency, "concurrency", cfg.Concurrency, "Number of packages to enrich in parallel")
+	cmd.Flags().StringSliceVar(&merge, "merge", nil, "How to merge enriched data with existing data: keep, overwrite or append, optionally per field as <field>=<policy>")
+	cmd.Flags().BoolVar(&cfg.Provenance, "provenance", false, "Record the source, endpoint and fetch time of each enriched field")
+	cmd.Flags().StringSliceVar(&include, "fields", nil, "Only enrich these fields, e.g. license,supplier,externalRefs")
+	cmd.Flags().StringSliceVar(&exclude, "exclude-fields", nil, "Don't enrich these fields")
+
+	cmd.Flags().StringVar(&reportPath, "report", "", "Write a JSON report of the outcome for each component to this file")
+
 	return &cmd
 }
@@ -6,7 +6,9 @@ import (
 	"github.com/package-url/packageurl-go"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
+	"github.com/snyk/parlay/internal/utils"
 	"github.com/snyk/parlay/lib/ecosystems"
 )
 
@@ -21,7 +23,10 @@ func NewPackageCommand(logger *zerolog.Logger) *cobra.Command {
 				logger.Fatal().Err(err).Msg("Failed to parse PackageURL")
 			}
 
-			resp, err := ecosystems.GetPackageData(purl)
+			ctx, cancel := utils.WithRequestTimeout(cmd.Context(), viper.GetDuration("per-request-timeout"))
+			defer cancel()
+
+			resp, err := ecosystems.GetPackageData(ctx, purl)
 			if err != nil {
 				logger.Fatal().Err(err).Msg("Failed to get package data from ecosyste.ms")
 			}
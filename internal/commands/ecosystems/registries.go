@@ -0,0 +1,33 @@
+package ecosystems
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/snyk/parlay/lib/ecosystems"
+)
+
+func NewRegistriesCommand(logger *zerolog.Logger) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "registries",
+		Short: "Return the registries supported by ecosyste.ms",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			registries, err := ecosystems.GetRegistries(cmd.Context())
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to get registries from ecosyste.ms")
+			}
+
+			b, err := json.Marshal(registries)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to encode registries")
+			}
+
+			fmt.Print(string(b))
+		},
+	}
+	return &cmd
+}
@@ -5,7 +5,9 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
+	"github.com/snyk/parlay/internal/utils"
 	"github.com/snyk/parlay/lib/ecosystems"
 )
 
@@ -15,7 +17,10 @@ func NewRepoCommand(logger *zerolog.Logger) *cobra.Command {
 		Short: "Return repo info from ecosyste.ms",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			resp, err := ecosystems.GetRepoData(args[0])
+			ctx, cancel := utils.WithRequestTimeout(cmd.Context(), viper.GetDuration("per-request-timeout"))
+			defer cancel()
+
+			resp, err := ecosystems.GetRepoData(ctx, args[0])
 			if err != nil {
 				logger.Fatal().Err(err).Msg("Failed to get repository data from ecosyste.ms")
 			}
@@ -21,6 +21,7 @@ func NewEcosystemsRootCommand(logger *zerolog.Logger) *cobra.Command {
 
 	cmd.AddCommand(NewPackageCommand(logger))
 	cmd.AddCommand(NewRepoCommand(logger))
+	cmd.AddCommand(NewRegistriesCommand(logger))
 	cmd.AddCommand(NewEnrichCommand(logger))
 
 	return &cmd
@@ -0,0 +1,86 @@
+package exploit
+
+import (
+	"errors"
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/exploit"
+	"github.com/snyk/parlay/lib/report"
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+func NewEnrichCommand(logger *zerolog.Logger) *cobra.Command {
+	cfg := exploit.DefaultConfig()
+	var epssLocation, kevLocation string
+	var reportPath string
+
+	cmd := cobra.Command{
+		Use:   "enrich <sbom>",
+		Short: "Enrich the vulnerabilities of an SBOM with EPSS scores and CISA KEV status",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.Report = report.New()
+			cfg.RequestTimeout = viper.GetDuration("per-request-timeout")
+			if epssLocation == "" && kevLocation == "" {
+				logger.Fatal().Err(errors.New("--epss and --kev are both empty")).Msg("Invalid exploitability configuration")
+			}
+
+			if epssLocation != "" {
+				epss, err := exploit.LoadEPSS(cmd.Context(), cfg, epssLocation)
+				if err != nil {
+					logger.Fatal().Err(err).Str("epss", epssLocation).Msg("Failed to load EPSS scores")
+				}
+				logger.Debug().Int("cves", epss.Len()).Str("score_date", epss.ScoreDate).Msg("Loaded EPSS scores")
+				cfg.EPSS = epss
+			}
+			if kevLocation != "" {
+				kev, err := exploit.LoadKEV(cmd.Context(), cfg, kevLocation)
+				if err != nil {
+					logger.Fatal().Err(err).Str("kev", kevLocation).Msg("Failed to load KEV catalog")
+				}
+				logger.Debug().Int("cves", kev.Len()).Str("catalog_version", kev.CatalogVersion).Msg("Loaded KEV catalog")
+				cfg.KEV = kev
+			}
+
+			b, err := utils.GetUserInput(args[0], os.Stdin)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to read input")
+			}
+
+			doc, err := sbom.DecodeSBOMDocument(b)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to read SBOM input")
+			}
+
+			exploit.EnrichSBOM(cmd.Context(), cfg, doc)
+			if err := cmd.Context().Err(); err != nil {
+				logger.Warn().Err(err).Msg("Enrichment was interrupted, writing partial results")
+			}
+			doc.RecordTool("parlay", cmd.Root().Version)
+
+			if err := doc.Encode(os.Stdout); err != nil {
+				logger.Fatal().Err(err).Msg("Failed to encode new SBOM")
+			}
+
+			cfg.Report.LogSummary(logger)
+			if reportPath != "" {
+				if err := cfg.Report.WriteFile(reportPath); err != nil {
+					logger.Fatal().Err(err).Msg("Failed to write report")
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&epssLocation, "epss", exploit.DefaultEPSSURL, "Read EPSS scores from this CSV file or URL, optionally gzipped, or skip them if empty")
+
+	cmd.Flags().StringVar(&kevLocation, "kev", exploit.DefaultKEVURL, "Read the CISA KEV catalog from this JSON file or URL, or skip it if empty")
+
+	cmd.Flags().StringVar(&reportPath, "report", "", "Write a JSON report of the outcome for each vulnerability to this file")
+
+	return &cmd
+}
@@ -0,0 +1,24 @@
+package exploit
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+func NewRootCommand(logger *zerolog.Logger) *cobra.Command {
+	cmd := cobra.Command{
+		Use:                   "exploit",
+		Short:                 "Commands for using parlay with EPSS scores and the CISA KEV catalog",
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := cmd.Help(); err != nil {
+				logger.Fatal().Err(err).Msg("Failed to run exploit command")
+			}
+		},
+	}
+
+	cmd.AddCommand(NewEnrichCommand(logger))
+
+	return &cmd
+}
@@ -0,0 +1,47 @@
+package osv
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/osv"
+)
+
+func NewRootCommand(logger *zerolog.Logger) *cobra.Command {
+	cmd := cobra.Command{
+		Use:                   "osv",
+		Short:                 "Commands for using parlay with OSV",
+		Aliases:               []string{"o"},
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := cmd.Help(); err != nil {
+				logger.Fatal().Err(err).Msg("Failed to run osv command")
+			}
+		},
+	}
+
+	cmd.PersistentFlags().String("osv-url", osv.DefaultConfig().APIURL, "Base URL of the OSV API")
+	viper.BindPFlag("osv.url", cmd.PersistentFlags().Lookup("osv-url")) //nolint:errcheck
+	cmd.PersistentFlags().StringArray("osv-header", nil, "Add this header to requests to the OSV API, as \"<name>: <value>\"")
+	viper.BindPFlag("osv.header", cmd.PersistentFlags().Lookup("osv-header")) //nolint:errcheck
+
+	cmd.AddCommand(NewEnrichCommand(logger))
+
+	return &cmd
+}
+
+// configure sets up access to the OSV API from the flags, environment and
+// configuration file.
+func configure(cfg *osv.Config) error {
+	cfg.RequestTimeout = viper.GetDuration("per-request-timeout")
+	cfg.APIURL = viper.GetString("osv.url")
+	header, err := utils.GetHeader("osv.header")
+	if err != nil {
+		return err
+	}
+	cfg.Header = header
+	return nil
+}
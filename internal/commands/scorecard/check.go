@@ -0,0 +1,93 @@
+package scorecard
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/sbom"
+	"github.com/snyk/parlay/lib/scorecard"
+)
+
+func NewCheckCommand(logger *zerolog.Logger) *cobra.Command {
+	var policyPath string
+	var reportPath string
+
+	cmd := cobra.Command{
+		Use:   "check <sbom>",
+		Short: "Check the OpenSSF Scorecard results in an SBOM against a policy",
+		Long: "Check the OpenSSF Scorecard results recorded by 'parlay scorecard enrich' against a policy.\n" +
+			"Exits with a non-zero status if any package fails the policy.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			// Not marked as required, so that the policy can be set in the
+			// configuration file.
+			if policyPath == "" {
+				logger.Fatal().Msg("A policy must be given with --policy")
+			}
+			policy, err := scorecard.LoadPolicy(policyPath)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to load policy")
+			}
+
+			b, err := utils.GetUserInput(args[0], os.Stdin)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to read input")
+			}
+
+			doc, err := sbom.DecodeSBOMDocument(b)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to read SBOM input")
+			}
+
+			result := policy.Check(doc)
+			for _, pkg := range result.Packages {
+				name := pkg.Purl
+				if name == "" {
+					name = pkg.ID
+				}
+				line := fmt.Sprintf("%-8s %s", strings.ToUpper(string(pkg.Status)), name)
+				if len(pkg.Violations) > 0 {
+					violations := make([]string, 0, len(pkg.Violations))
+					for _, v := range pkg.Violations {
+						violations = append(violations, v.String())
+					}
+					line += " (" + strings.Join(violations, ", ") + ")"
+				}
+				fmt.Fprintln(os.Stdout, line)
+			}
+
+			if reportPath != "" {
+				b, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					logger.Fatal().Err(err).Msg("Failed to encode report")
+				}
+				if err := os.WriteFile(reportPath, append(b, '\n'), 0o644); err != nil {
+					logger.Fatal().Err(err).Msg("Failed to write report")
+				}
+			}
+
+			logger.Info().
+				Int("passed", result.Count(scorecard.CheckPassed)).
+				Int("failed", result.Count(scorecard.CheckFailed)).
+				Int("allowed", result.Count(scorecard.CheckAllowed)).
+				Int("unscored", result.Count(scorecard.CheckUnscored)).
+				Msgf("Checked %d packages against the scorecard policy", len(result.Packages))
+			if !result.Passed {
+				logger.Error().Msg("Scorecard policy failed")
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&policyPath, "policy", "", "Policy file setting the minimum scores, e.g. policy.yaml")
+
+	cmd.Flags().StringVar(&reportPath, "report", "", "Write a JSON report of the outcome for each package to this file")
+
+	return &cmd
+}
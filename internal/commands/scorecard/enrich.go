@@ -7,16 +7,31 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/report"
 	"github.com/snyk/parlay/lib/sbom"
 	"github.com/snyk/parlay/lib/scorecard"
 )
 
 func NewEnrichCommand(logger *zerolog.Logger) *cobra.Command {
+	cfg := scorecard.DefaultConfig()
+	var include, exclude []string
+	var reportPath string
+
 	cmd := cobra.Command{
 		Use:   "enrich <sbom>",
 		Short: "Enrich an SBOM with OpenSSF Scorecard data",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
+			cfg.Report = report.New()
+			if err := configure(cfg); err != nil {
+				logger.Fatal().Err(err).Msg("Invalid OpenSSF Scorecard configuration")
+			}
+			if len(include) > 0 || len(exclude) > 0 {
+				if err := cfg.SelectFields(include, exclude); err != nil {
+					logger.Fatal().Err(err).Msg("Invalid field selection")
+				}
+			}
+
 			b, err := utils.GetUserInput(args[0], os.Stdin)
 			if err != nil {
 				logger.Fatal().Err(err).Msg("Failed to read input")
@@ -27,12 +42,30 @@ func NewEnrichCommand(logger *zerolog.Logger) *cobra.Command {
 				logger.Fatal().Err(err).Msg("Failed to read SBOM input")
 			}
 
-			scorecard.EnrichSBOM(doc)
+			scorecard.EnrichSBOM(cmd.Context(), cfg, doc)
+			if err := cmd.Context().Err(); err != nil {
+				logger.Warn().Err(err).Msg("Enrichment was interrupted, writing partial results")
+			}
+			doc.RecordTool("parlay", cmd.Root().Version)
 
 			if err := doc.Encode(os.Stdout); err != nil {
 				logger.Fatal().Err(err).Msg("Failed to encode new SBOM")
 			}
+
+			cfg.Report.LogSummary(logger)
+			if reportPath != "" {
+				if err := cfg.Report.WriteFile(reportPath); err != nil {
+					logger.Fatal().Err(err).Msg("Failed to write report")
+				}
+			}
 		},
 	}
+
+	cmd.Flags().IntVar(&cfg.Concurrency, "concurrency", cfg.Concurrency, "Number of packages to enrich in parallel")
+	cmd.Flags().StringSliceVar(&include, "fields", nil, "Only enrich these fields: scorecard, scorecard_results, externalRefs or properties")
+	cmd.Flags().StringSliceVar(&exclude, "exclude-fields", nil, "Don't enrich these fields")
+
+	cmd.Flags().StringVar(&reportPath, "report", "", "Write a JSON report of the outcome for each component to this file")
+
 	return &cmd
 }
@@ -0,0 +1,45 @@
+package scorecard
+
+import (
+	"github.com/package-url/packageurl-go"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/snyk/parlay/lib/scorecard"
+)
+
+func NewPackageCommand(logger *zerolog.Logger) *cobra.Command {
+	var format string
+
+	cmd := cobra.Command{
+		Use:   "package <purl>",
+		Short: "Return the OpenSSF Scorecard of a package's repository, found through ecosyste.ms",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := checkFormat(format); err != nil {
+				logger.Fatal().Err(err).Msg("Invalid output format")
+			}
+			purl, err := packageurl.FromString(args[0])
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to parse PackageURL")
+			}
+			cfg := scorecard.DefaultConfig()
+			if err := configure(cfg); err != nil {
+				logger.Fatal().Err(err).Msg("Invalid OpenSSF Scorecard configuration")
+			}
+
+			sc, err := scorecard.GetPackageScorecard(cmd.Context(), cfg, purl)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to get scorecard")
+			}
+
+			if err := printScorecard(sc, format); err != nil {
+				logger.Fatal().Err(err).Msg("Failed to print scorecard")
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "json", "Output format, either json or table")
+
+	return &cmd
+}
@@ -0,0 +1,62 @@
+package scorecard
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/snyk/parlay/lib/scorecard"
+)
+
+func NewRepoCommand(logger *zerolog.Logger) *cobra.Command {
+	var format string
+
+	cmd := cobra.Command{
+		Use:   "repo <repo-url>",
+		Short: "Return the OpenSSF Scorecard of a repository",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := checkFormat(format); err != nil {
+				logger.Fatal().Err(err).Msg("Invalid output format")
+			}
+			cfg := scorecard.DefaultConfig()
+			if err := configure(cfg); err != nil {
+				logger.Fatal().Err(err).Msg("Invalid OpenSSF Scorecard configuration")
+			}
+
+			sc, err := scorecard.GetRepoScorecard(cmd.Context(), cfg, args[0])
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to get scorecard")
+			}
+
+			if err := printScorecard(sc, format); err != nil {
+				logger.Fatal().Err(err).Msg("Failed to print scorecard")
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "json", "Output format, either json or table")
+
+	return &cmd
+}
+
+func checkFormat(format string) error {
+	switch format {
+	case "json", "table":
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q, expected json or table", format)
+	}
+}
+
+// printScorecard writes a scorecard to stdout, either as returned by the
+// Scorecard API or as a table of its checks.
+func printScorecard(sc *scorecard.Scorecard, format string) error {
+	if format == "table" {
+		return sc.Result.WriteTable(os.Stdout)
+	}
+	_, err := fmt.Print(string(sc.Body))
+	return err
+}
@@ -3,6 +3,10 @@ package scorecard
 import (
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/scorecard"
 )
 
 func NewRootCommand(logger *zerolog.Logger) *cobra.Command {
@@ -19,7 +23,28 @@ func NewRootCommand(logger *zerolog.Logger) *cobra.Command {
 		},
 	}
 
+	cmd.PersistentFlags().String("scorecard-url", scorecard.DefaultConfig().APIURL, "Base URL of the OpenSSF Scorecard API")
+	viper.BindPFlag("scorecard.url", cmd.PersistentFlags().Lookup("scorecard-url")) //nolint:errcheck
+	cmd.PersistentFlags().StringArray("scorecard-header", nil, "Add this header to requests to the OpenSSF Scorecard API, as \"<name>: <value>\"")
+	viper.BindPFlag("scorecard.header", cmd.PersistentFlags().Lookup("scorecard-header")) //nolint:errcheck
+
 	cmd.AddCommand(NewEnrichCommand(logger))
+	cmd.AddCommand(NewCheckCommand(logger))
+	cmd.AddCommand(NewRepoCommand(logger))
+	cmd.AddCommand(NewPackageCommand(logger))
 
 	return &cmd
 }
+
+// configure sets up access to the Scorecard API from the flags, environment
+// and configuration file.
+func configure(cfg *scorecard.Config) error {
+	cfg.RequestTimeout = viper.GetDuration("per-request-timeout")
+	cfg.APIURL = viper.GetString("scorecard.url")
+	header, err := utils.GetHeader("scorecard.header")
+	if err != nil {
+		return err
+	}
+	cfg.Header = header
+	return nil
+}
@@ -0,0 +1,72 @@
+package slsa
+
+import (
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/report"
+	"github.com/snyk/parlay/lib/sbom"
+	"github.com/snyk/parlay/lib/slsa"
+)
+
+func NewEnrichCommand(logger *zerolog.Logger) *cobra.Command {
+	cfg := slsa.DefaultConfig()
+	var reportPath string
+
+	cmd := cobra.Command{
+		Use:   "enrich <sbom>",
+		Short: "Enrich an SBOM with SLSA build provenance",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.Report = report.New()
+			if err := configure(cfg); err != nil {
+				logger.Fatal().Err(err).Msg("Invalid provenance configuration")
+			}
+
+			b, err := utils.GetUserInput(args[0], os.Stdin)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to read input")
+			}
+
+			doc, err := sbom.DecodeSBOMDocument(b)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to read SBOM input")
+			}
+
+			slsa.EnrichSBOM(cmd.Context(), cfg, doc)
+			if err := cmd.Context().Err(); err != nil {
+				logger.Warn().Err(err).Msg("Enrichment was interrupted, writing partial results")
+			}
+			doc.RecordTool("parlay", cmd.Root().Version)
+
+			if err := doc.Encode(os.Stdout); err != nil {
+				logger.Fatal().Err(err).Msg("Failed to encode new SBOM")
+			}
+
+			coverage := slsa.MeasureCoverage(doc)
+			logger.Info().
+				Int("components", coverage.Components).
+				Int("verified", coverage.Verified).
+				Int("unverified", coverage.Unverified).
+				Int("none", coverage.None).
+				Int("mismatched", coverage.Mismatched).
+				Msg("Provenance coverage")
+
+			cfg.Report.LogSummary(logger)
+			if reportPath != "" {
+				if err := cfg.Report.WriteFile(reportPath); err != nil {
+					logger.Fatal().Err(err).Msg("Failed to write report")
+				}
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&cfg.Concurrency, "concurrency", cfg.Concurrency, "Number of packages to enrich in parallel")
+
+	cmd.Flags().StringVar(&reportPath, "report", "", "Write a JSON report of the outcome for each component to this file")
+
+	return &cmd
+}
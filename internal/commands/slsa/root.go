@@ -0,0 +1,51 @@
+package slsa
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/slsa"
+)
+
+func NewRootCommand(logger *zerolog.Logger) *cobra.Command {
+	cmd := cobra.Command{
+		Use:                   "slsa",
+		Short:                 "Commands for using parlay with SLSA build provenance",
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := cmd.Help(); err != nil {
+				logger.Fatal().Err(err).Msg("Failed to run slsa command")
+			}
+		},
+	}
+
+	cmd.PersistentFlags().String("npm-registry-url", slsa.DefaultConfig().NPMRegistryURL, "Base URL of the npm registry")
+	viper.BindPFlag("slsa.npm-registry-url", cmd.PersistentFlags().Lookup("npm-registry-url")) //nolint:errcheck
+	cmd.PersistentFlags().String("pypi-url", slsa.DefaultConfig().PyPIURL, "Base URL of PyPI")
+	viper.BindPFlag("slsa.pypi-url", cmd.PersistentFlags().Lookup("pypi-url")) //nolint:errcheck
+
+	cmd.AddCommand(NewEnrichCommand(logger))
+
+	return &cmd
+}
+
+// configure sets up access to deps.dev and the package registries from the
+// flags, environment and configuration file. deps.dev is configured as for
+// the deps commands.
+func configure(cfg *slsa.Config) error {
+	cfg.RequestTimeout = viper.GetDuration("per-request-timeout")
+	cfg.NPMRegistryURL = viper.GetString("slsa.npm-registry-url")
+	cfg.PyPIURL = viper.GetString("slsa.pypi-url")
+
+	cfg.Deps.RequestTimeout = cfg.RequestTimeout
+	cfg.Deps.APIURL = viper.GetString("deps.url")
+	header, err := utils.GetHeader("deps.header")
+	if err != nil {
+		return err
+	}
+	cfg.Deps.Header = header
+	return nil
+}
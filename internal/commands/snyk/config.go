@@ -1,20 +1,26 @@
 package snyk
 
 import (
-	"os"
+	"github.com/spf13/viper"
 
 	"github.com/snyk/parlay/lib/snyk"
 )
 
-func config() *snyk.Config {
-	c := snyk.DefaultConfig()
+// bindConfig lets the Snyk settings be given through the environment
+// variables the Snyk CLI reads, as well as in the configuration file.
+func bindConfig() {
+	viper.BindEnv("snyk.token", "SNYK_TOKEN", "PARLAY_SNYK_TOKEN")   //nolint:errcheck
+	viper.BindEnv("snyk.api-url", "SNYK_API", "PARLAY_SNYK_API_URL") //nolint:errcheck
+	viper.SetDefault("snyk.api-url", snyk.DefaultConfig().SnykAPIURL)
+}
 
-	if t := os.Getenv("SNYK_TOKEN"); t != "" {
-		c.APIToken = t
+// configure applies the global and Snyk settings to cfg.
+func configure(cfg *snyk.Config) {
+	cfg.RequestTimeout = viper.GetDuration("per-request-timeout")
+	if t := viper.GetString("snyk.token"); t != "" {
+		cfg.APIToken = t
 	}
-	if u := os.Getenv("SNYK_API"); u != "" {
-		c.SnykAPIURL = u
+	if u := viper.GetString("snyk.api-url"); u != "" {
+		cfg.SnykAPIURL = u
 	}
-
-	return c
 }
@@ -16,7 +16,8 @@ func NewPackageCommand(logger *zerolog.Logger) *cobra.Command {
 		Short: "Return package vulnerabilities from Snyk",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			cfg := config()
+			cfg := snyk.DefaultConfig()
+			configure(cfg)
 			svc := snyk.NewService(cfg, logger)
 
 			purl, err := packageurl.FromString(args[0])
@@ -29,7 +30,7 @@ func NewPackageCommand(logger *zerolog.Logger) *cobra.Command {
 				Str("purl", args[0]).
 				Msg("Looking up package vulnerabilities from Snyk")
 
-			resp, err := svc.GetPackageVulnerabilities(&purl)
+			resp, err := svc.GetPackageVulnerabilities(cmd.Context(), &purl)
 			if err != nil {
 				logger.Fatal().Err(err).Msg("Failed to look up package vulnerabilities")
 			}
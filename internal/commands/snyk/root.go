@@ -6,6 +6,8 @@ import (
 )
 
 func NewSnykRootCommand(logger *zerolog.Logger) *cobra.Command {
+	bindConfig()
+
 	cmd := cobra.Command{
 		Use:                   "snyk",
 		Short:                 "Commands for using parlay with Snyk",
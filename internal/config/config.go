@@ -0,0 +1,269 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package config loads parlay's configuration file. Settings are looked up
+// through viper, so flags take precedence over PARLAY_ environment
+// variables, which take precedence over the file.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// FileName is the name of the configuration file looked for in the working
+// directory and in $XDG_CONFIG_HOME/parlay.
+const FileName = "parlay.yaml"
+
+// Find returns the path of the configuration file to use: path if set, the
+// file in the working directory if there is one, or else the one in
+// $XDG_CONFIG_HOME/parlay. It returns an empty path when there is no
+// configuration file.
+func Find(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+
+	candidates := []string{FileName}
+	if dir := configHome(); dir != "" {
+		candidates = append(candidates, filepath.Join(dir, "parlay", FileName))
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+func configHome() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config")
+	}
+	return ""
+}
+
+// Load reads the configuration file at path into v. References to
+// environment variables in its values, written as ${NAME}, are replaced by
+// their values so that secrets can be kept out of the file. Any other $ is
+// kept as it is.
+func Load(v *viper.Viper, path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration: %w", err)
+	}
+
+	file := viper.New()
+	file.SetConfigType("yaml")
+	if err := file.ReadConfig(bytes.NewReader(b)); err != nil {
+		return fmt.Errorf("failed to parse configuration %s: %w", path, err)
+	}
+
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.MergeConfigMap(expandSettings(file.AllSettings())); err != nil {
+		return fmt.Errorf("failed to parse configuration %s: %w", path, err)
+	}
+	return nil
+}
+
+// envReference matches references to environment variables, ${NAME}.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandSettings replaces the references to environment variables in the
+// string values of settings.
+func expandSettings(settings map[string]interface{}) map[string]interface{} {
+	expanded := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		expanded[key] = expandValue(value)
+	}
+	return expanded
+}
+
+func expandValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return envReference.ReplaceAllStringFunc(v, func(ref string) string {
+			return os.Getenv(envReference.FindStringSubmatch(ref)[1])
+		})
+	case map[string]interface{}:
+		return expandSettings(v)
+	case []interface{}:
+		expanded := make([]interface{}, len(v))
+		for i, item := range v {
+			expanded[i] = expandValue(item)
+		}
+		return expanded
+	}
+	return value
+}
+
+// ApplyFlags sets the flags of cmd which weren't given on the command line
+// from v. Each flag is looked up under section first, e.g.
+// "ecosystems.concurrency" for the --concurrency flag of "ecosystems enrich",
+// and then at the top level, e.g. "concurrency".
+func ApplyFlags(v *viper.Viper, cmd *cobra.Command, section string) error {
+	var errs []error
+	cmd.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed {
+			return
+		}
+		keys := []string{f.Name}
+		if section != "" {
+			keys = []string{section + "." + f.Name, f.Name}
+		}
+		for _, key := range keys {
+			if !v.IsSet(key) {
+				continue
+			}
+			if err := setFlag(cmd, f, v.Get(key)); err != nil {
+				errs = append(errs, fmt.Errorf("invalid value for %s: %w", key, err))
+			}
+			return
+		}
+	})
+	return errors.Join(errs...)
+}
+
+func setFlag(cmd *cobra.Command, f *pflag.Flag, value interface{}) error {
+	values, ok := value.([]interface{})
+	if !ok {
+		return cmd.Flags().Set(f.Name, fmt.Sprint(value))
+	}
+	for _, v := range values {
+		if err := cmd.Flags().Set(f.Name, fmt.Sprint(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Redacted is shown in place of secrets.
+const Redacted = "REDACTED"
+
+// Redact returns a copy of settings with secrets replaced: the values of
+// keys naming a token, password or secret, the values of headers, and the
+// credentials of URLs such as the proxy.
+func Redact(settings map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		redacted[key] = redactValue(key, value)
+	}
+	return redacted
+}
+
+func redactValue(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return Redact(v)
+	case nil:
+		return nil
+	}
+
+	name := strings.ToLower(key)
+	switch {
+	case strings.Contains(name, "token"), strings.Contains(name, "password"), strings.Contains(name, "secret"):
+		if fmt.Sprint(value) == "" {
+			return value
+		}
+		return Redacted
+	case strings.Contains(name, "header"):
+		return redactHeaders(value)
+	}
+
+	switch v := value.(type) {
+	case string:
+		return redactURL(v)
+	case []string:
+		redacted := make([]string, len(v))
+		for i, s := range v {
+			redacted[i] = redactURL(s)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, s := range v {
+			if str, ok := s.(string); ok {
+				redacted[i] = redactURL(str)
+			} else {
+				redacted[i] = s
+			}
+		}
+		return redacted
+	}
+	return value
+}
+
+// redactURL replaces the password of a URL, or its user name if it has no
+// password, as the user name may then be a token. Anything else is returned
+// as it is.
+func redactURL(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), Redacted)
+	} else {
+		u.User = url.User(Redacted)
+	}
+	return u.String()
+}
+
+// redactHeaders keeps the names of headers, given as "<name>: <value>", and
+// replaces their values.
+func redactHeaders(value interface{}) interface{} {
+	var specs []string
+	switch v := value.(type) {
+	case string:
+		specs = strings.Split(v, "\n")
+	case []string:
+		specs = v
+	case []interface{}:
+		for _, s := range v {
+			specs = append(specs, fmt.Sprint(s))
+		}
+	default:
+		return Redacted
+	}
+
+	headers := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(spec, ":")
+		headers = append(headers, strings.TrimSpace(name)+": "+Redacted)
+	}
+	return headers
+}
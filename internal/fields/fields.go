@@ -0,0 +1,113 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package fields selects which fields an enricher fills in.
+package fields
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Selection is the set of fields to enrich. A nil Selection selects every
+// field.
+type Selection struct {
+	selected map[string]bool
+}
+
+// NewSelection builds a selection of the known fields from the fields to
+// include and to exclude. An empty include list includes every known field.
+// Names may also refer to a group of fields through aliases, e.g.
+// "externalRefs".
+func NewSelection(known []string, aliases map[string][]string, include, exclude []string) (*Selection, error) {
+	expand := func(list []string) ([]string, error) {
+		var expanded []string
+		for _, name := range list {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if group, ok := aliases[name]; ok {
+				expanded = append(expanded, group...)
+				continue
+			}
+			if !contains(known, name) {
+				return nil, fmt.Errorf("unknown field %q, expected one of %s", name, strings.Join(allNames(known, aliases), ", "))
+			}
+			expanded = append(expanded, name)
+		}
+		return expanded, nil
+	}
+
+	included, err := expand(include)
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := expand(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(include) == 0 {
+		included = known
+	}
+
+	s := &Selection{selected: make(map[string]bool)}
+	for _, name := range included {
+		s.selected[name] = true
+	}
+	for _, name := range excluded {
+		delete(s.selected, name)
+	}
+	return s, nil
+}
+
+// Selected reports whether the named field should be enriched.
+func (s *Selection) Selected(name string) bool {
+	if s == nil {
+		return true
+	}
+	return s.selected[name]
+}
+
+// Any reports whether any of the named fields should be enriched.
+func (s *Selection) Any(names ...string) bool {
+	for _, name := range names {
+		if s.Selected(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func allNames(known []string, aliases map[string][]string) []string {
+	all := append([]string{}, known...)
+	for alias := range aliases {
+		all = append(all, alias)
+	}
+	sort.Strings(all)
+	return all
+}
@@ -0,0 +1,65 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fields
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	known   = []string{"description", "homepage", "license", "topics"}
+	aliases = map[string][]string{"externalRefs": {"homepage"}}
+)
+
+func TestNewSelection(t *testing.T) {
+	s, err := NewSelection(known, aliases, nil, nil)
+	require.NoError(t, err)
+	for _, name := range known {
+		assert.True(t, s.Selected(name))
+	}
+
+	s, err = NewSelection(known, aliases, []string{"license", "externalRefs"}, nil)
+	require.NoError(t, err)
+	assert.True(t, s.Selected("license"))
+	assert.True(t, s.Selected("homepage"))
+	assert.False(t, s.Selected("description"))
+	assert.True(t, s.Any("description", "license"))
+	assert.False(t, s.Any("description", "topics"))
+
+	s, err = NewSelection(known, aliases, nil, []string{"externalRefs", "topics"})
+	require.NoError(t, err)
+	assert.True(t, s.Selected("description"))
+	assert.True(t, s.Selected("license"))
+	assert.False(t, s.Selected("homepage"))
+	assert.False(t, s.Selected("topics"))
+}
+
+func TestNewSelection_UnknownField(t *testing.T) {
+	_, err := NewSelection(known, aliases, []string{"licence"}, nil)
+	assert.ErrorContains(t, err, `unknown field "licence"`)
+
+	_, err = NewSelection(known, aliases, nil, []string{"colour"})
+	assert.Error(t, err)
+}
+
+func TestSelection_Nil(t *testing.T) {
+	var s *Selection
+	assert.True(t, s.Selected("anything"))
+}
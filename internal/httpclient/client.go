@@ -0,0 +1,198 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
+
+	"github.com/snyk/parlay/internal/pool"
+)
+
+var (
+	defaultMu     sync.RWMutex
+	defaultClient *http.Client
+)
+
+// Default returns the client shared by all providers. Until SetDefault is
+// called, it is built from DefaultConfig.
+func Default() *http.Client {
+	defaultMu.RLock()
+	client := defaultClient
+	defaultMu.RUnlock()
+	if client != nil {
+		return client
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultClient == nil {
+		// Without a proxy or CA bundle to load, building the client can't
+		// fail.
+		defaultClient, _ = New(DefaultConfig(), nil)
+	}
+	return defaultClient
+}
+
+// SetDefault replaces the client shared by all providers. Passing nil restores
+// a client built from DefaultConfig.
+func SetDefault(client *http.Client) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultClient = client
+}
+
+// New returns a client configured by cfg, logging rate limiting and retries
+// to logger.
+func New(cfg *Config, logger *zerolog.Logger) (*http.Client, error) {
+	if logger == nil {
+		nop := zerolog.Nop()
+		logger = &nop
+	}
+
+	base, err := newBaseTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Transport: &transport{
+			cfg:      cfg,
+			base:     base,
+			logger:   logger,
+			limiters: make(map[string]*rate.Limiter),
+		},
+	}, nil
+}
+
+// newBaseTransport returns the transport requests are sent through. Unless a
+// proxy or CA bundle is configured, this is http.DefaultTransport, which nil
+// stands for.
+func newBaseTransport(cfg *Config) (http.RoundTripper, error) {
+	if cfg.Proxy == "" && cfg.CABundle == "" {
+		return nil, nil
+	}
+
+	t := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   pool.DefaultConcurrency,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	if cfg.Proxy != "" {
+		proxy, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		t.Proxy = http.ProxyURL(proxy)
+	}
+
+	if cfg.CABundle != "" {
+		pem, err := os.ReadFile(cfg.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", cfg.CABundle)
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	return t, nil
+}
+
+// SetRetryMax parses a retry limit given either as "<n>", which applies to all
+// hosts, or as "<host>=<n>" for a single host. A limit for all hosts replaces
+// the limits set for single hosts before, including the default one of the
+// Snyk API.
+func (cfg *Config) SetRetryMax(spec string) error {
+	host, value, perHost := cutHost(spec)
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid number of retries %q", value)
+	}
+	if !perHost {
+		cfg.RetryMax = n
+		for name, h := range cfg.Hosts {
+			h.RetryMax = nil
+			cfg.Hosts[name] = h
+		}
+		return nil
+	}
+	h := cfg.hostConfig(host)
+	h.RetryMax = &n
+	cfg.Hosts[host] = h
+	return nil
+}
+
+// SetRateLimit parses a rate limit in requests per second given either as
+// "<rate>", which applies to each host, or as "<host>=<rate>" for a single
+// host.
+func (cfg *Config) SetRateLimit(spec string) error {
+	host, value, perHost := cutHost(spec)
+	limit, err := strconv.ParseFloat(value, 64)
+	if err != nil || limit < 0 {
+		return fmt.Errorf("invalid rate limit %q", value)
+	}
+	if !perHost {
+		cfg.RateLimit = limit
+		return nil
+	}
+	h := cfg.hostConfig(host)
+	h.RateLimit = &limit
+	cfg.Hosts[host] = h
+	return nil
+}
+
+func cutHost(spec string) (string, string, bool) {
+	host, value, ok := strings.Cut(spec, "=")
+	if !ok {
+		return "", spec, false
+	}
+	return host, value, true
+}
+
+func (cfg *Config) hostConfig(host string) HostConfig {
+	if cfg.Hosts == nil {
+		cfg.Hosts = make(map[string]HostConfig)
+	}
+	return cfg.Hosts[host]
+}
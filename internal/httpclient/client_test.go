@@ -0,0 +1,254 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() *Config {
+	cfg := DefaultConfig()
+	cfg.RetryWaitMin = time.Millisecond
+	cfg.RetryWaitMax = 10 * time.Millisecond
+	return cfg
+}
+
+func TestClient_RetriesServerErrors(t *testing.T) {
+	var numRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if numRequests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client, err := New(testConfig(), nil)
+	require.NoError(t, err)
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), numRequests.Load())
+}
+
+func TestClient_DoesNotRetryClientErrors(t *testing.T) {
+	var numRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numRequests.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	client, err := New(testConfig(), nil)
+	require.NoError(t, err)
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, int32(1), numRequests.Load())
+}
+
+func TestClient_RetryMaxPerHost(t *testing.T) {
+	var numRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numRequests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	require.NoError(t, cfg.SetRetryMax("127.0.0.1=2"))
+
+	client, err := New(cfg, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(3), numRequests.Load(), "sends the request and retries it twice")
+}
+
+func TestClient_RetriesRequestBody(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make([]byte, r.ContentLength)
+		_, _ = r.Body.Read(body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client, err := New(testConfig(), nil)
+	require.NoError(t, err)
+
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("hello"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, []string{"hello", "hello"}, bodies)
+}
+
+func TestClient_HonoursRetryAfter(t *testing.T) {
+	var first time.Time
+	var waited time.Duration
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if first.IsZero() {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		waited = time.Since(first)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client, err := New(testConfig(), nil)
+	require.NoError(t, err)
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.GreaterOrEqual(t, waited, time.Second, "waits as long as asked to rather than backing off")
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	require.NoError(t, cfg.SetRateLimit("20"))
+
+	client, err := New(cfg, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// The first request is sent straight away, the other four 50ms apart.
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	wait, ok := parseRetryAfter("3")
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, wait)
+
+	wait, ok = parseRetryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), wait, "dates in the past don't wait")
+
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+
+	_, ok = parseRetryAfter("")
+	assert.False(t, ok)
+}
+
+func TestParseRateLimitReset(t *testing.T) {
+	wait, ok := parseRateLimitReset("10")
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Second, wait)
+
+	reset := time.Now().Add(time.Minute).Unix()
+	wait, ok = parseRateLimitReset(strconv.FormatInt(reset, 10))
+	assert.True(t, ok)
+	assert.InDelta(t, time.Minute, wait, float64(2*time.Second))
+
+	_, ok = parseRateLimitReset("-1")
+	assert.False(t, ok)
+}
+
+func TestConfig_DefaultRetryMax(t *testing.T) {
+	cfg := DefaultConfig()
+
+	assert.Equal(t, 5, cfg.host("packages.ecosyste.ms").retryMax)
+	assert.Equal(t, 20, cfg.host("api.snyk.io").retryMax, "Snyk rate limits heavily")
+
+	require.NoError(t, cfg.SetRetryMax("3"))
+	assert.Equal(t, 3, cfg.host("api.snyk.io").retryMax, "a limit for all hosts applies to Snyk too")
+}
+
+func TestConfig_SetRetryMax(t *testing.T) {
+	cfg := DefaultConfig()
+
+	require.NoError(t, cfg.SetRetryMax("2"))
+	require.NoError(t, cfg.SetRetryMax("api.snyk.io=10"))
+
+	assert.Equal(t, 2, cfg.host("packages.ecosyste.ms").retryMax)
+	assert.Equal(t, 10, cfg.host("api.snyk.io").retryMax)
+
+	assert.Error(t, cfg.SetRetryMax("lots"))
+	assert.Error(t, cfg.SetRetryMax("api.snyk.io=-1"))
+}
+
+func TestConfig_SetRateLimit(t *testing.T) {
+	cfg := DefaultConfig()
+
+	require.NoError(t, cfg.SetRateLimit("api.securityscorecards.dev=0.5"))
+
+	assert.Equal(t, 0.5, cfg.host("api.securityscorecards.dev").rateLimit)
+	assert.Equal(t, 0.0, cfg.host("api.snyk.io").rateLimit)
+	assert.Equal(t, 1, cfg.host("api.snyk.io").burst)
+
+	assert.Error(t, cfg.SetRateLimit("fast"))
+}
+
+func TestNew_InvalidCABundle(t *testing.T) {
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(bundle, []byte("not a certificate"), 0o600))
+
+	cfg := DefaultConfig()
+	cfg.CABundle = bundle
+
+	_, err := New(cfg, nil)
+	assert.ErrorContains(t, err, "no certificates found")
+
+	cfg.CABundle = filepath.Join(t.TempDir(), "missing.pem")
+	_, err = New(cfg, nil)
+	assert.ErrorContains(t, err, "failed to read CA bundle")
+}
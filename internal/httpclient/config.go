@@ -0,0 +1,108 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package httpclient provides the HTTP client shared by all providers. It
+// retries failed requests, honours the rate limits services report, limits
+// the rate of requests per host and supports proxies and custom CAs.
+package httpclient
+
+import "time"
+
+// Config controls the shared HTTP client.
+type Config struct {
+	// RetryMax is the number of times a request is retried after a
+	// connection error, a 429 or a 5xx response.
+	RetryMax int
+
+	// RetryWaitMin and RetryWaitMax bound the exponential backoff between
+	// retries. They don't apply when a response says how long to wait
+	// through a Retry-After or X-RateLimit-Reset header.
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+
+	// RateLimit is the number of requests per second sent to a single host,
+	// with bursts of up to Burst requests. A zero value doesn't limit the
+	// rate.
+	RateLimit float64
+	Burst     int
+
+	// Proxy is the URL of the proxy to send requests through. If empty, the
+	// proxy is taken from the HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+	// environment variables.
+	Proxy string
+
+	// CABundle is the path to a PEM file of certificate authorities which
+	// are trusted in addition to the system ones.
+	CABundle string
+
+	// Hosts overrides the retry and rate limit settings for individual
+	// hosts, keyed by host name.
+	Hosts map[string]HostConfig
+}
+
+// HostConfig overrides the settings of Config for a single host. Nil fields
+// keep the setting of Config.
+type HostConfig struct {
+	RetryMax  *int
+	RateLimit *float64
+	Burst     *int
+}
+
+// snykRetryMax is the number of retries for the Snyk API, which rate limits
+// clients heavily enough that they need a larger budget than other hosts.
+const snykRetryMax = 20
+
+func DefaultConfig() *Config {
+	retryMax := snykRetryMax
+	return &Config{
+		RetryMax:     5,
+		RetryWaitMin: 1 * time.Second,
+		RetryWaitMax: 30 * time.Second,
+		Hosts: map[string]HostConfig{
+			"api.snyk.io": {RetryMax: &retryMax},
+		},
+	}
+}
+
+// hostSettings are the settings in effect for a single host.
+type hostSettings struct {
+	retryMax  int
+	rateLimit float64
+	burst     int
+}
+
+func (cfg *Config) host(name string) hostSettings {
+	s := hostSettings{
+		retryMax:  cfg.RetryMax,
+		rateLimit: cfg.RateLimit,
+		burst:     cfg.Burst,
+	}
+	if h, ok := cfg.Hosts[name]; ok {
+		if h.RetryMax != nil {
+			s.retryMax = *h.RetryMax
+		}
+		if h.RateLimit != nil {
+			s.rateLimit = *h.RateLimit
+		}
+		if h.Burst != nil {
+			s.burst = *h.Burst
+		}
+	}
+	if s.burst < 1 {
+		s.burst = 1
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeaders(t *testing.T) {
+	header, err := ParseHeaders([]string{
+		"Authorization: Bearer token",
+		"x-mirror:internal",
+		"X-Mirror: eu, us",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Bearer token", header.Get("Authorization"))
+	assert.Equal(t, []string{"internal", "eu, us"}, header.Values("X-Mirror"))
+
+	_, err = ParseHeaders([]string{"Authorization"})
+	assert.Error(t, err)
+
+	_, err = ParseHeaders([]string{": value"})
+	assert.Error(t, err)
+}
+
+func TestSetHeaders(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer old")
+	req.Header.Set("User-Agent", "parlay")
+
+	SetHeaders(req, http.Header{"Authorization": {"Bearer new"}})
+
+	assert.Equal(t, []string{"Bearer new"}, req.Header.Values("Authorization"))
+	assert.Equal(t, "parlay", req.Header.Get("User-Agent"))
+}
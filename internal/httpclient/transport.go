@@ -0,0 +1,205 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpclient
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
+)
+
+// transport retries requests and limits their rate per host.
+type transport struct {
+	cfg    *Config
+	base   http.RoundTripper
+	logger *zerolog.Logger
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	settings := t.cfg.host(req.URL.Hostname())
+	limiter := t.limiter(req.URL.Hostname(), settings)
+
+	// Requests with a body can only be retried if the body can be read
+	// again.
+	retryMax := settings.retryMax
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		retryMax = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		r, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.roundTripper().RoundTrip(r)
+		if attempt >= retryMax || !shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+
+		wait, limited := t.backoff(attempt, resp)
+		if resp != nil {
+			// Drain the body so that the connection can be reused.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		if limited {
+			t.logger.Warn().
+				Dur("Retry-After", wait).
+				Str("host", req.URL.Host).
+				Msg("Getting rate-limited, waiting...")
+		} else {
+			t.logger.Debug().
+				Err(err).
+				Dur("wait", wait).
+				Str("url", req.URL.String()).
+				Msg("Retrying failed request")
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *transport) roundTripper() http.RoundTripper {
+	if t.base != nil {
+		return t.base
+	}
+	return http.DefaultTransport
+}
+
+func (t *transport) limiter(host string, s hostSettings) *rate.Limiter {
+	if s.rateLimit <= 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	l, ok := t.limiters[host]
+	if !ok {
+		l = rate.NewLimiter(rate.Limit(s.rateLimit), s.burst)
+		t.limiters[host] = l
+	}
+	return l
+}
+
+// backoff returns how long to wait before retrying, and whether the wait was
+// asked for by the service.
+func (t *transport) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait, true
+		}
+		if wait, ok := parseRateLimitReset(resp.Header.Get("X-RateLimit-Reset")); ok {
+			return wait, true
+		}
+	}
+
+	wait := t.cfg.RetryWaitMin << attempt
+	if wait <= 0 || wait > t.cfg.RetryWaitMax {
+		wait = t.cfg.RetryWaitMax
+	}
+	return wait, false
+}
+
+// rewind returns the request to send for an attempt, with a fresh body for
+// retries.
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 {
+		return req, nil
+	}
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// shouldRetry reports whether a request is worth retrying: after connection
+// errors, when rate-limited and when the service failed.
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		var unknownAuthority x509.UnknownAuthorityError
+		var invalidCert x509.CertificateInvalidError
+		return !errors.As(err, &unknownAuthority) && !errors.As(err, &invalidCert)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented)
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as
+// a date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if sec, err := strconv.ParseInt(v, 10, 64); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second, true
+	}
+	if date, err := http.ParseTime(v); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// parseRateLimitReset parses an X-RateLimit-Reset header. Services disagree on
+// its meaning: Snyk sends the seconds until the limit resets, GitHub the Unix
+// time at which it does. Values too large to be a wait are taken as the
+// latter.
+func parseRateLimitReset(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || sec < 0 {
+		return 0, false
+	}
+	if sec > 1_000_000_000 {
+		return max(time.Until(time.Unix(sec, 0)), 0), true
+	}
+	return time.Duration(sec) * time.Second, true
+}
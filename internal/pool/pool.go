@@ -0,0 +1,46 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package pool runs work over a bounded number of goroutines. It is shared by
+// the enrichers so that every data source fans out the same way.
+package pool
+
+import (
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// DefaultConcurrency is the number of items processed in parallel when no
+// concurrency is configured.
+const DefaultConcurrency = 20
+
+// Run calls fn for every item, with at most concurrency calls in flight at
+// any time, and returns once all calls have completed. A concurrency of zero
+// or less uses DefaultConcurrency.
+func Run[T any](concurrency int, items []T, fn func(T)) {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	wg := sizedwaitgroup.New(concurrency)
+	for i := range items {
+		wg.Add()
+		go func(item T) {
+			defer wg.Done()
+			fn(item)
+		}(items[i])
+	}
+	wg.Wait()
+}
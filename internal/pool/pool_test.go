@@ -0,0 +1,58 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	var mu sync.Mutex
+	var seen []int
+	var inFlight, maxInFlight int32
+
+	Run(3, items, func(i int) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if n > maxInFlight {
+			maxInFlight = n
+		}
+		seen = append(seen, i)
+	})
+
+	assert.ElementsMatch(t, items, seen)
+	assert.LessOrEqual(t, maxInFlight, int32(3))
+}
+
+func TestRun_DefaultConcurrency(t *testing.T) {
+	var count int32
+
+	Run(0, make([]struct{}, 50), func(struct{}) {
+		atomic.AddInt32(&count, 1)
+	})
+
+	assert.Equal(t, int32(50), count)
+}
@@ -0,0 +1,150 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package repourl normalizes the many ways source repositories are referred
+// to in SBOMs and package metadata, e.g. git+https://github.com/x/y.git,
+// git@github.com:x/y or https://github.com/x/y/tree/main, into one form.
+package repourl
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Hosts of the forges repositories are recognized on.
+const (
+	GitHub    = "github.com"
+	GitLab    = "gitlab.com"
+	Bitbucket = "bitbucket.org"
+)
+
+// Repository is a source repository on a known forge.
+type Repository struct {
+	// Host is the forge the repository is hosted on, e.g. "github.com".
+	Host string
+
+	// Path is the path of the repository on the forge, e.g. "snyk/parlay".
+	// GitLab repositories may be nested in subgroups, e.g.
+	// "gitlab-org/security-products/analyzers/semgrep".
+	Path string
+}
+
+// URL returns the web URL of the repository, e.g.
+// https://github.com/snyk/parlay.
+func (r *Repository) URL() string {
+	return "https://" + r.String()
+}
+
+// String returns the host and path of the repository, e.g.
+// github.com/snyk/parlay, which is how OpenSSF Scorecard and deps.dev name
+// projects.
+func (r *Repository) String() string {
+	return r.Host + "/" + r.Path
+}
+
+// Parse recognizes a repository on GitHub, GitLab or Bitbucket from a URL in
+// any of the forms used by package managers and SBOMs. It returns false for
+// URLs which don't identify such a repository.
+func Parse(raw string) (*Repository, bool) {
+	host, path, ok := split(strings.TrimSpace(raw))
+	if !ok {
+		return nil, false
+	}
+
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	// SPDX download locations may name a revision, e.g.
+	// git+https://github.com/snyk/parlay.git@v1.0.0
+	path, _, _ = strings.Cut(path, "@")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	var n int
+	switch host {
+	case GitHub, Bitbucket:
+		n = 2
+	case GitLab:
+		n = gitlabPathLength(segments)
+	default:
+		return nil, false
+	}
+	if n < 2 || len(segments) < n {
+		return nil, false
+	}
+
+	segments = segments[:n]
+	segments[n-1] = strings.TrimSuffix(segments[n-1], ".git")
+	for _, s := range segments {
+		if s == "" {
+			return nil, false
+		}
+	}
+	return &Repository{Host: host, Path: strings.Join(segments, "/")}, true
+}
+
+// Normalize returns the web URL of the repository raw refers to, or an empty
+// string if it doesn't identify a repository on a known forge.
+func Normalize(raw string) string {
+	if r, ok := Parse(raw); ok {
+		return r.URL()
+	}
+	return ""
+}
+
+// split returns the host and path of a repository URL.
+func split(raw string) (string, string, bool) {
+	raw = strings.TrimPrefix(raw, "git+")
+	if i := strings.IndexAny(raw, "?#"); i >= 0 {
+		raw = raw[:i]
+	}
+
+	// scp-like syntax, e.g. git@github.com:snyk/parlay.git
+	if !strings.Contains(raw, "://") {
+		if user, rest, ok := strings.Cut(raw, "@"); ok && !strings.Contains(user, "/") {
+			if host, path, ok := strings.Cut(rest, ":"); ok {
+				return host, path, true
+			}
+		}
+		// Scheme-less URLs, e.g. github.com/snyk/parlay
+		host, path, ok := strings.Cut(raw, "/")
+		return host, path, ok
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", false
+	}
+	switch u.Scheme {
+	case "http", "https", "git", "ssh":
+	default:
+		return "", "", false
+	}
+	return u.Hostname(), u.Path, true
+}
+
+// gitlabPathLength returns the number of segments which make up the path of
+// a GitLab repository. As projects can be nested in any number of subgroups,
+// the path ends where GitLab's own routes start.
+func gitlabPathLength(segments []string) int {
+	for i, s := range segments {
+		switch {
+		case s == "-":
+			return i
+		case strings.HasSuffix(s, ".git"):
+			return i + 1
+		case i >= 2 && (s == "tree" || s == "blob" || s == "commits"):
+			return i
+		}
+	}
+	return len(segments)
+}
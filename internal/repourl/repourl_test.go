@@ -0,0 +1,72 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package repourl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://github.com/snyk/parlay", "https://github.com/snyk/parlay"},
+		{"http://github.com/snyk/parlay", "https://github.com/snyk/parlay"},
+		{"https://www.github.com/snyk/parlay/", "https://github.com/snyk/parlay"},
+		{"https://GitHub.com/snyk/parlay", "https://github.com/snyk/parlay"},
+		{"git+https://github.com/snyk/parlay.git", "https://github.com/snyk/parlay"},
+		{"git+https://github.com/snyk/parlay.git@v1.0.0#cmd", "https://github.com/snyk/parlay"},
+		{"git@github.com:snyk/parlay.git@main", "https://github.com/snyk/parlay"},
+		{"git+ssh://git@github.com/snyk/parlay.git", "https://github.com/snyk/parlay"},
+		{"git://github.com/snyk/parlay.git", "https://github.com/snyk/parlay"},
+		{"git@github.com:snyk/parlay.git", "https://github.com/snyk/parlay"},
+		{"github.com/snyk/parlay", "https://github.com/snyk/parlay"},
+		{"https://github.com/snyk/parlay/tree/main/lib", "https://github.com/snyk/parlay"},
+		{"https://github.com/snyk/parlay#readme", "https://github.com/snyk/parlay"},
+		{"https://github.com/snyk/parlay.git?ref=v1", "https://github.com/snyk/parlay"},
+		{"https://gitlab.com/gitlab-org/gitlab-runner", "https://gitlab.com/gitlab-org/gitlab-runner"},
+		{"https://gitlab.com/gitlab-org/security-products/analyzers/semgrep", "https://gitlab.com/gitlab-org/security-products/analyzers/semgrep"},
+		{"https://gitlab.com/gitlab-org/security-products/analyzers/semgrep/-/tree/main", "https://gitlab.com/gitlab-org/security-products/analyzers/semgrep"},
+		{"git@gitlab.com:gitlab-org/cli.git", "https://gitlab.com/gitlab-org/cli"},
+		{"https://gitlab.com/gitlab-org/cli.git/", "https://gitlab.com/gitlab-org/cli"},
+		{"https://gitlab.com/gitlab-org/cli/tree/main", "https://gitlab.com/gitlab-org/cli"},
+		{"https://bitbucket.org/birkenfeld/pygments-main/src/default", "https://bitbucket.org/birkenfeld/pygments-main"},
+		{"https://github.com/snyk", ""},
+		{"https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz", ""},
+		{"https://git.example.com/snyk/parlay", ""},
+		{"ftp://github.com/snyk/parlay", ""},
+		{"NOASSERTION", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Normalize(tt.raw), tt.raw)
+	}
+}
+
+func TestParse(t *testing.T) {
+	repo, ok := Parse("git+https://gitlab.com/gitlab-org/security-products/analyzers/semgrep.git")
+	assert.True(t, ok)
+	assert.Equal(t, GitLab, repo.Host)
+	assert.Equal(t, "gitlab-org/security-products/analyzers/semgrep", repo.Path)
+	assert.Equal(t, "gitlab.com/gitlab-org/security-products/analyzers/semgrep", repo.String())
+
+	_, ok = Parse("https://example.com/snyk/parlay")
+	assert.False(t, ok)
+}
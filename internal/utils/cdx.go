@@ -27,3 +27,48 @@ func DiscoverCDXComponents(bom *cdx.BOM) []*cdx.Component {
 	}
 	return comps
 }
+
+// CDXComponentID identifies a component by its bom-ref, falling back to its
+// purl and then its name and version.
+func CDXComponentID(comp *cdx.Component) string {
+	switch {
+	case comp.BOMRef != "":
+		return comp.BOMRef
+	case comp.PackageURL != "":
+		return comp.PackageURL
+	case comp.Version != "":
+		return comp.Name + "@" + comp.Version
+	default:
+		return comp.Name
+	}
+}
+
+// AddCDXExternalReference appends ref to the component's external references,
+// unless a reference of the same type and URL is already present.
+func AddCDXExternalReference(comp *cdx.Component, ref cdx.ExternalReference) {
+	if comp.ExternalReferences == nil {
+		comp.ExternalReferences = &[]cdx.ExternalReference{ref}
+		return
+	}
+	for _, existing := range *comp.ExternalReferences {
+		if existing.Type == ref.Type && existing.URL == ref.URL {
+			return
+		}
+	}
+	*comp.ExternalReferences = append(*comp.ExternalReferences, ref)
+}
+
+// AddCDXProperty appends prop to the component's properties, unless a
+// property with the same name and value is already present.
+func AddCDXProperty(comp *cdx.Component, prop cdx.Property) {
+	if comp.Properties == nil {
+		comp.Properties = &[]cdx.Property{prop}
+		return
+	}
+	for _, existing := range *comp.Properties {
+		if existing == prop {
+			return
+		}
+	}
+	*comp.Properties = append(*comp.Properties, prop)
+}
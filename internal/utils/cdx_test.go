@@ -31,3 +31,29 @@ func TestDiscoverCDXComponents(t *testing.T) {
 
 	assert.Equal(len(result), 3)
 }
+
+func TestAddCDXExternalReference(t *testing.T) {
+	comp := &cdx.Component{}
+
+	utils.AddCDXExternalReference(comp, cdx.ExternalReference{URL: "https://example.com", Type: cdx.ERTypeWebsite})
+	utils.AddCDXExternalReference(comp, cdx.ExternalReference{URL: "https://example.com", Type: cdx.ERTypeWebsite, Comment: "again"})
+	utils.AddCDXExternalReference(comp, cdx.ExternalReference{URL: "https://example.com", Type: cdx.ERTypeVCS})
+
+	assert.Equal(t, &[]cdx.ExternalReference{
+		{URL: "https://example.com", Type: cdx.ERTypeWebsite},
+		{URL: "https://example.com", Type: cdx.ERTypeVCS},
+	}, comp.ExternalReferences)
+}
+
+func TestAddCDXProperty(t *testing.T) {
+	comp := &cdx.Component{}
+
+	utils.AddCDXProperty(comp, cdx.Property{Name: "ecosystems:topic", Value: "http"})
+	utils.AddCDXProperty(comp, cdx.Property{Name: "ecosystems:topic", Value: "http"})
+	utils.AddCDXProperty(comp, cdx.Property{Name: "ecosystems:topic", Value: "cookies"})
+
+	assert.Equal(t, &[]cdx.Property{
+		{Name: "ecosystems:topic", Value: "http"},
+		{Name: "ecosystems:topic", Value: "cookies"},
+	}, comp.Properties)
+}
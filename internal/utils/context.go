@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// WithRequestTimeout bounds a single request made with the returned context
+// by timeout. A timeout of zero leaves the request bound only by ctx.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
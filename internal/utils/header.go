@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/snyk/parlay/internal/httpclient"
+)
+
+// GetHeader returns the headers set for key. Flags are repeated for each
+// header, whereas an environment variable holds one header per line.
+func GetHeader(key string) (http.Header, error) {
+	var specs []string
+	if v, ok := viper.Get(key).(string); ok {
+		for _, line := range strings.Split(v, "\n") {
+			if strings.TrimSpace(line) != "" {
+				specs = append(specs, line)
+			}
+		}
+	} else {
+		specs = viper.GetStringSlice(key)
+	}
+	return httpclient.ParseHeaders(specs)
+}
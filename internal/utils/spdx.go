@@ -48,3 +48,15 @@ func GetLicenseExpressionFromEcosystemsLicense(pkgVersionData *packages.VersionW
 	}
 	return fmt.Sprintf("(%s)", strings.Join(licenses, " OR "))
 }
+
+// AddSPDXExternalReference appends ref to the package's external references,
+// unless a reference with the same category, type and locator is already
+// present.
+func AddSPDXExternalReference(pkg *spdx_2_3.Package, ref *spdx_2_3.PackageExternalReference) {
+	for _, existing := range pkg.PackageExternalReferences {
+		if existing.Category == ref.Category && existing.RefType == ref.RefType && existing.Locator == ref.Locator {
+			return
+		}
+	}
+	pkg.PackageExternalReferences = append(pkg.PackageExternalReferences, ref)
+}
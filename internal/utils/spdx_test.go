@@ -6,6 +6,8 @@ import (
 	"github.com/snyk/parlay/ecosystems/packages"
 	"github.com/snyk/parlay/internal/utils"
 
+	"github.com/spdx/tools-golang/spdx/v2/common"
+	spdx_2_3 "github.com/spdx/tools-golang/spdx/v2/v2_3"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -60,3 +62,21 @@ func TestGetSPDXLicenseExpressionFromEcosystemsLicense_EmptyLicenses(t *testing.
 	expression := utils.GetLicenseExpressionFromEcosystemsLicense(&pkgVersionData, &pkgData)
 	assert.Equal("", expression)
 }
+
+func TestAddSPDXExternalReference(t *testing.T) {
+	pkg := &spdx_2_3.Package{}
+	ref := &spdx_2_3.PackageExternalReference{
+		Category: common.CategoryOther,
+		RefType:  "vcs",
+		Locator:  "https://github.com/snyk/parlay",
+	}
+
+	utils.AddSPDXExternalReference(pkg, ref)
+	utils.AddSPDXExternalReference(pkg, &spdx_2_3.PackageExternalReference{
+		Category: common.CategoryOther,
+		RefType:  "vcs",
+		Locator:  "https://github.com/snyk/parlay",
+	})
+
+	assert.Equal(t, []*spdx_2_3.PackageExternalReference{ref}, pkg.PackageExternalReferences)
+}
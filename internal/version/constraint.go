@@ -0,0 +1,286 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	hyphenRangeRe = regexp.MustCompile(`^(\S+)\s+-\s+(\S+)$`)
+	operatorRe    = regexp.MustCompile(`^(~>|~=|===|==|!=|>=|<=|\^|~|>|<|=)?\s*(.*)$`)
+)
+
+// Satisfies reports whether version v satisfies requirement. It understands
+// the range syntaxes of npm and Cargo (^, ~, x-ranges, hyphen ranges, ||),
+// PEP 440 (~=, ==, !=, comma-separated clauses), RubyGems (~>) and Maven
+// ([1.0,2.0) interval notation). An error is returned when the requirement
+// cannot be parsed.
+func Satisfies(v, requirement string) (bool, error) {
+	requirement = strings.TrimSpace(requirement)
+	if isAny(requirement) {
+		return true, nil
+	}
+
+	for _, alt := range strings.Split(requirement, "||") {
+		ok, err := satisfiesAll(v, strings.TrimSpace(alt))
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func isAny(s string) bool {
+	switch strings.ToLower(s) {
+	case "", "*", "x", "latest", ">=0":
+		return true
+	}
+	return false
+}
+
+func satisfiesAll(v, expr string) (bool, error) {
+	if isAny(expr) {
+		return true, nil
+	}
+
+	if strings.HasPrefix(expr, "[") || strings.HasPrefix(expr, "(") {
+		return satisfiesIntervals(v, expr)
+	}
+
+	if m := hyphenRangeRe.FindStringSubmatch(expr); m != nil {
+		return Compare(v, m[1]) >= 0 && Compare(v, m[2]) <= 0, nil
+	}
+
+	for _, clause := range splitClauses(expr) {
+		ok, err := satisfiesClause(v, clause)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// splitClauses splits an expression such as ">= 1.2, < 2" into its clauses,
+// re-attaching operators which are separated from their version by spaces.
+func splitClauses(expr string) []string {
+	fields := strings.Fields(strings.ReplaceAll(expr, ",", " "))
+
+	var clauses []string
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if strings.Trim(f, "<>=!~^") == "" && i+1 < len(fields) {
+			f += fields[i+1]
+			i++
+		}
+		clauses = append(clauses, f)
+	}
+
+	return clauses
+}
+
+func satisfiesClause(v, clause string) (bool, error) {
+	m := operatorRe.FindStringSubmatch(clause)
+	op, ver := m[1], strings.TrimSpace(m[2])
+	if ver == "" {
+		return false, fmt.Errorf("invalid requirement %q", clause)
+	}
+
+	if isWildcard(ver) {
+		switch op {
+		case "", "=", "==":
+			return matchesWildcard(v, ver), nil
+		case "!=":
+			return !matchesWildcard(v, ver), nil
+		default:
+			ver = strings.Join(releaseParts(ver), ".")
+		}
+	}
+
+	c := Compare(v, ver)
+	switch op {
+	case "", "=", "==", "===":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case "^":
+		return c >= 0 && Compare(v, caretUpperBound(ver)) < 0, nil
+	case "~":
+		return c >= 0 && Compare(v, tildeUpperBound(ver)) < 0, nil
+	case "~>", "~=":
+		return c >= 0 && Compare(v, pessimisticUpperBound(ver)) < 0, nil
+	}
+
+	return false, fmt.Errorf("unsupported operator %q", op)
+}
+
+// satisfiesIntervals evaluates Maven style version ranges such as
+// "[1.0,2.0)" or "(,1.0],[1.2,)".
+func satisfiesIntervals(v, expr string) (bool, error) {
+	// Every interval is parsed, even once one matched, so that malformed
+	// ranges are always rejected.
+	matched := false
+	for len(expr) > 0 {
+		end := strings.IndexAny(expr, "])")
+		if end < 0 {
+			return false, fmt.Errorf("unterminated range %q", expr)
+		}
+		interval := expr[:end+1]
+		if len(interval) < 2 || (interval[0] != '[' && interval[0] != '(') {
+			return false, fmt.Errorf("invalid range %q", interval)
+		}
+
+		// Intervals are separated by a single comma, and the last one
+		// isn't followed by any.
+		rest := strings.TrimSpace(expr[end+1:])
+		if rest != "" {
+			var ok bool
+			if rest, ok = strings.CutPrefix(rest, ","); !ok {
+				return false, fmt.Errorf("invalid range %q", expr)
+			}
+			rest = strings.TrimSpace(rest)
+			if rest == "" {
+				return false, fmt.Errorf("invalid range %q", expr)
+			}
+		}
+		expr = rest
+
+		lowerInclusive := interval[0] == '['
+		upperInclusive := interval[len(interval)-1] == ']'
+		bounds := strings.Split(interval[1:len(interval)-1], ",")
+
+		switch len(bounds) {
+		case 1:
+			if Compare(v, strings.TrimSpace(bounds[0])) == 0 {
+				matched = true
+			}
+		case 2:
+			lower, upper := strings.TrimSpace(bounds[0]), strings.TrimSpace(bounds[1])
+			ok := true
+			if lower != "" {
+				c := Compare(v, lower)
+				ok = c > 0 || (lowerInclusive && c == 0)
+			}
+			if ok && upper != "" {
+				c := Compare(v, upper)
+				ok = c < 0 || (upperInclusive && c == 0)
+			}
+			if ok {
+				matched = true
+			}
+		default:
+			return false, fmt.Errorf("invalid range %q", interval)
+		}
+	}
+
+	return matched, nil
+}
+
+func isWildcard(v string) bool {
+	for _, p := range strings.Split(v, ".") {
+		switch p {
+		case "*", "x", "X":
+			return true
+		}
+	}
+	return false
+}
+
+func matchesWildcard(v, pattern string) bool {
+	prefix := releaseParts(pattern)
+	parts := releaseParts(v)
+	if len(parts) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if Compare(parts[i], prefix[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// releaseParts returns the leading numeric dot-separated parts of a version.
+func releaseParts(v string) []string {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	var parts []string
+	for _, p := range strings.Split(v, ".") {
+		if _, err := strconv.Atoi(p); err != nil {
+			break
+		}
+		parts = append(parts, p)
+	}
+	return parts
+}
+
+// bump returns the version made of the first n+1 release parts of v with the
+// last of them incremented, e.g. bump("1.2.3", 1) == "1.3".
+func bump(parts []string, n int) string {
+	out := make([]string, n+1)
+	for i := 0; i <= n; i++ {
+		if i < len(parts) {
+			out[i] = parts[i]
+		} else {
+			out[i] = "0"
+		}
+	}
+	last, _ := strconv.Atoi(out[n])
+	out[n] = strconv.Itoa(last + 1)
+	return strings.Join(out, ".")
+}
+
+func caretUpperBound(v string) string {
+	parts := releaseParts(v)
+	for i, p := range parts {
+		if p != "0" || i == len(parts)-1 {
+			return bump(parts, i)
+		}
+	}
+	return bump(parts, 0)
+}
+
+func tildeUpperBound(v string) string {
+	parts := releaseParts(v)
+	if len(parts) < 2 {
+		return bump(parts, 0)
+	}
+	return bump(parts, 1)
+}
+
+func pessimisticUpperBound(v string) string {
+	parts := releaseParts(v)
+	if len(parts) < 2 {
+		return bump(parts, 0)
+	}
+	return bump(parts, len(parts)-2)
+}
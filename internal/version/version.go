@@ -0,0 +1,176 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package version implements best-effort comparison of package versions and
+// evaluation of the requirement ranges used by common package ecosystems.
+package version
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// qualifiers ranks well-known pre- and post-release labels relative to a
+// release, which sits at 0.
+var qualifiers = map[string]int{
+	"dev":       -5,
+	"snapshot":  -5,
+	"alpha":     -4,
+	"a":         -4,
+	"beta":      -3,
+	"b":         -3,
+	"milestone": -2,
+	"m":         -2,
+	"pre":       -1,
+	"preview":   -1,
+	"rc":        -1,
+	"c":         -1,
+	"cr":        -1,
+	"final":     0,
+	"ga":        0,
+	"release":   0,
+	"post":      1,
+	"sp":        1,
+	"p":         1,
+}
+
+type segment struct {
+	num     int
+	str     string
+	numeric bool
+}
+
+// Compare compares versions a and b, returning -1 if a < b, 0 if they are
+// equal and 1 if a > b. Versions are split into numeric and alphabetic
+// segments; missing numeric segments count as zero and alphabetic segments
+// are treated as pre-release labels unless known to denote a post-release.
+func Compare(a, b string) int {
+	sa, sb := parse(a), parse(b)
+	for i := 0; i < len(sa) || i < len(sb); i++ {
+		var c int
+		switch {
+		case i >= len(sa):
+			c = -compareMissing(sb[i])
+		case i >= len(sb):
+			c = compareMissing(sa[i])
+		default:
+			c = compareSegments(sa[i], sb[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareMissing compares a segment with the absence of a segment.
+func compareMissing(s segment) int {
+	if s.numeric {
+		return sign(s.num)
+	}
+	return sign(qualifierRank(s.str))
+}
+
+func compareSegments(a, b segment) int {
+	switch {
+	case a.numeric && b.numeric:
+		return sign(a.num - b.num)
+	case a.numeric:
+		return 1
+	case b.numeric:
+		return -1
+	}
+
+	ra, rb := qualifierRank(a.str), qualifierRank(b.str)
+	if ra != rb {
+		return sign(ra - rb)
+	}
+	return strings.Compare(a.str, b.str)
+}
+
+func qualifierRank(s string) int {
+	if r, ok := qualifiers[s]; ok {
+		return r
+	}
+	return -1
+}
+
+func parse(v string) []segment {
+	v = strings.ToLower(strings.TrimSpace(v))
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	// Debian and RPM style epochs, e.g. 1:2.3-4
+	if i := strings.IndexByte(v, ':'); i >= 0 {
+		if _, err := strconv.Atoi(v[:i]); err == nil {
+			v = v[i+1:]
+		}
+	}
+
+	var segs []segment
+	var cur strings.Builder
+	var curNumeric bool
+
+	flush := func() {
+		if cur.Len() == 0 {
+			return
+		}
+		s := segment{str: cur.String(), numeric: curNumeric}
+		if curNumeric {
+			n, err := strconv.Atoi(s.str)
+			if err != nil {
+				s.numeric = false
+			}
+			s.num = n
+		}
+		segs = append(segs, s)
+		cur.Reset()
+	}
+
+	for _, r := range v {
+		switch {
+		case unicode.IsDigit(r):
+			if cur.Len() > 0 && !curNumeric {
+				flush()
+			}
+			curNumeric = true
+			cur.WriteRune(r)
+		case unicode.IsLetter(r):
+			if cur.Len() > 0 && curNumeric {
+				flush()
+			}
+			curNumeric = false
+			cur.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return segs
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
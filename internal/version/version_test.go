@@ -0,0 +1,121 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0", "1.0.0", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.10.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0-rc1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0a1", "1.0b1", -1},
+		{"1.0.post1", "1.0", 1},
+		{"1.0.0+build.5", "1.0.0", 0},
+		{"1:2.3-4", "2.3-4", 0},
+		{"2.3-10", "2.3-9", 1},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Compare(tt.a, tt.b), "Compare(%q, %q)", tt.a, tt.b)
+		assert.Equal(t, -tt.want, Compare(tt.b, tt.a), "Compare(%q, %q)", tt.b, tt.a)
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		version, requirement string
+		want                 bool
+	}{
+		{"1.2.3", "", true},
+		{"1.2.3", "*", true},
+		{"1.2.3", "1.2.3", true},
+		{"1.2.4", "1.2.3", false},
+		{"1.4.0", "^1.2.3", true},
+		{"2.0.0", "^1.2.3", false},
+		{"0.2.9", "^0.2.3", true},
+		{"0.3.0", "^0.2.3", false},
+		{"0.0.4", "^0.0.3", false},
+		{"1.2.9", "~1.2.3", true},
+		{"1.3.0", "~1.2.3", false},
+		{"1.2.5", "1.2.x", true},
+		{"1.3.0", "1.2.*", false},
+		{"1.5.0", ">=1.2.0 <2.0.0", true},
+		{"2.0.0", ">= 1.2.0, < 2.0.0", false},
+		{"1.5.0", "1.0.0 - 1.5.0", true},
+		{"3.1.0", "^1.0.0 || ^3.0.0", true},
+		{"2.2.5", "~=2.2", true},
+		{"3.0", "~=2.2", false},
+		{"1.4.9", "~=1.4.5", true},
+		{"1.5.0", "~=1.4.5", false},
+		{"2.28.0", ">=2.0,!=2.27.0", true},
+		{"2.27.0", ">=2.0,!=2.27.0", false},
+		{"1.9", "~> 1.2", true},
+		{"2.0", "~> 1.2", false},
+		{"1.5", "[1.0,2.0)", true},
+		{"2.0", "[1.0,2.0)", false},
+		{"1.0", "(1.0,2.0]", false},
+		{"0.5", "(,1.0],[1.2,)", true},
+		{"1.1", "(,1.0],[1.2,)", false},
+		{"1.2", "[1.2]", true},
+	}
+
+	for _, tt := range tests {
+		got, err := Satisfies(tt.version, tt.requirement)
+		require.NoError(t, err, "Satisfies(%q, %q)", tt.version, tt.requirement)
+		assert.Equal(t, tt.want, got, "Satisfies(%q, %q)", tt.version, tt.requirement)
+	}
+}
+
+func TestSatisfies_InvalidRequirement(t *testing.T) {
+	_, err := Satisfies("1.0", "[1.0,2.0")
+	assert.Error(t, err)
+
+	_, err = Satisfies("1.0", ">=")
+	assert.Error(t, err)
+}
+
+func TestSatisfiesIntervals_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"[1.0,2.0)]",
+		"]",
+		"(,",
+		"[1,2],,",
+		"[1,2] [3,4]",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := satisfiesIntervals("1.5", expr)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := Satisfies("1.5", "[1.0,2.0)]")
+	assert.ErrorContains(t, err, "invalid range")
+}
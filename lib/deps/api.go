@@ -0,0 +1,275 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package deps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/package-url/packageurl-go"
+
+	"github.com/snyk/parlay/internal/httpclient"
+	"github.com/snyk/parlay/internal/utils"
+)
+
+// systems maps purl types to the package management systems of deps.dev.
+var systems = map[string]string{
+	packageurl.TypeCargo:  "CARGO",
+	packageurl.TypeGem:    "RUBYGEMS",
+	packageurl.TypeGolang: "GO",
+	packageurl.TypeMaven:  "MAVEN",
+	packageurl.TypeNPM:    "NPM",
+	packageurl.TypeNuget:  "NUGET",
+	packageurl.TypePyPi:   "PYPI",
+}
+
+// VersionKey identifies a package version on deps.dev.
+type VersionKey struct {
+	System  string `json:"system"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Version is a package version, as returned by the deps.dev API.
+type Version struct {
+	VersionKey      VersionKey       `json:"versionKey"`
+	PublishedAt     string           `json:"publishedAt"`
+	IsDefault       bool             `json:"isDefault"`
+	Licenses        []string         `json:"licenses"`
+	AdvisoryKeys    []AdvisoryKey    `json:"advisoryKeys"`
+	Links           []Link           `json:"links"`
+	SLSAProvenances []SLSAProvenance `json:"slsaProvenances"`
+	Attestations    []Attestation    `json:"attestations"`
+	RelatedProjects []RelatedProject `json:"relatedProjects"`
+}
+
+type AdvisoryKey struct {
+	ID string `json:"id"`
+}
+
+// Link is a link from a package version's metadata, labelled with what it
+// points to, e.g. "SOURCE_REPO" or "HOMEPAGE".
+type Link struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// SLSAProvenance is a SLSA provenance statement published for a package
+// version, such as npm provenance. Verified reports whether deps.dev
+// verified its signature.
+type SLSAProvenance struct {
+	SourceRepository string `json:"sourceRepository"`
+	Commit           string `json:"commit"`
+	URL              string `json:"url"`
+	Verified         bool   `json:"verified"`
+}
+
+// Attestation is an attestation published for a package version, such as a
+// PyPI publish attestation. Verified reports whether deps.dev verified its
+// signature.
+type Attestation struct {
+	Type             string `json:"type"`
+	URL              string `json:"url"`
+	Verified         bool   `json:"verified"`
+	SourceRepository string `json:"sourceRepository"`
+	Commit           string `json:"commit"`
+}
+
+// RelatedProject is a source code project which a package version relates
+// to, e.g. with a relation type of "SOURCE_REPO".
+type RelatedProject struct {
+	ProjectKey struct {
+		ID string `json:"id"`
+	} `json:"projectKey"`
+	RelationProvenance string `json:"relationProvenance"`
+	RelationType       string `json:"relationType"`
+}
+
+// Project is a source code project, as returned by the deps.dev API.
+type Project struct {
+	ProjectKey struct {
+		ID string `json:"id"`
+	} `json:"projectKey"`
+	License     string            `json:"license"`
+	Description string            `json:"description"`
+	Homepage    string            `json:"homepage"`
+	Scorecard   *ProjectScorecard `json:"scorecard"`
+}
+
+// ProjectScorecard is the OpenSSF Scorecard of a project, as mirrored by
+// deps.dev.
+type ProjectScorecard struct {
+	Date       string `json:"date"`
+	Repository struct {
+		Name   string `json:"name"`
+		Commit string `json:"commit"`
+	} `json:"repository"`
+	OverallScore float64 `json:"overallScore"`
+	Checks       []struct {
+		Name   string `json:"name"`
+		Score  int    `json:"score"`
+		Reason string `json:"reason"`
+	} `json:"checks"`
+}
+
+// Package is a package and its versions, as returned by the deps.dev API.
+type Package struct {
+	PackageKey struct {
+		System string `json:"system"`
+		Name   string `json:"name"`
+	} `json:"packageKey"`
+	Versions []struct {
+		VersionKey  VersionKey `json:"versionKey"`
+		PublishedAt string     `json:"publishedAt"`
+		IsDefault   bool       `json:"isDefault"`
+	} `json:"versions"`
+}
+
+// Dependencies is the resolved dependency graph of a package version, as
+// returned by the deps.dev API. The first node is the version itself, and
+// edges refer to nodes by their index.
+type Dependencies struct {
+	Nodes []struct {
+		VersionKey VersionKey `json:"versionKey"`
+		Bundled    bool       `json:"bundled"`
+		Relation   string     `json:"relation"`
+		Errors     []string   `json:"errors"`
+	} `json:"nodes"`
+	Edges []struct {
+		FromNode    int    `json:"fromNode"`
+		ToNode      int    `json:"toNode"`
+		Requirement string `json:"requirement"`
+	} `json:"edges"`
+	Error string `json:"error"`
+}
+
+// Response is a successful response of the deps.dev API, holding both the
+// JSON document returned and the data decoded from it.
+type Response[T any] struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	Data         *T
+}
+
+// SupportsPurl reports whether deps.dev has packages of the purl's type.
+func SupportsPurl(purl packageurl.PackageURL) bool {
+	_, ok := systems[purl.Type]
+	return ok
+}
+
+// packagePath returns the deps.dev API path of the package a purl
+// identifies.
+func packagePath(purl packageurl.PackageURL) (string, error) {
+	system, ok := systems[purl.Type]
+	if !ok {
+		return "", fmt.Errorf("unsupported purl type %q", purl.Type)
+	}
+
+	name := purl.Name
+	switch {
+	case purl.Type == packageurl.TypeMaven && purl.Namespace != "":
+		name = purl.Namespace + ":" + purl.Name
+	case purl.Namespace != "":
+		name = purl.Namespace + "/" + purl.Name
+	}
+	return fmt.Sprintf("/systems/%s/packages/%s", system, url.PathEscape(name)), nil
+}
+
+// versionPath returns the deps.dev API path of the package version a purl
+// identifies.
+func versionPath(purl packageurl.PackageURL) (string, error) {
+	path, err := packagePath(purl)
+	if err != nil {
+		return "", err
+	}
+	if purl.Version == "" {
+		return "", fmt.Errorf("no version on PackageURL")
+	}
+	return path + "/versions/" + url.PathEscape(purl.Version), nil
+}
+
+// GetPackageData returns the deps.dev data of the package a purl identifies,
+// listing its versions. The version of the purl, if any, is ignored.
+func GetPackageData(ctx context.Context, cfg *Config, purl packageurl.PackageURL) (*Response[Package], error) {
+	path, err := packagePath(purl)
+	if err != nil {
+		return nil, err
+	}
+	return get[Package](ctx, cfg, path)
+}
+
+// GetVersionData returns the deps.dev data of the package version a purl
+// identifies.
+func GetVersionData(ctx context.Context, cfg *Config, purl packageurl.PackageURL) (*Response[Version], error) {
+	path, err := versionPath(purl)
+	if err != nil {
+		return nil, err
+	}
+	return get[Version](ctx, cfg, path)
+}
+
+// GetDependenciesData returns the dependency graph deps.dev resolved for the
+// package version a purl identifies.
+func GetDependenciesData(ctx context.Context, cfg *Config, purl packageurl.PackageURL) (*Response[Dependencies], error) {
+	path, err := versionPath(purl)
+	if err != nil {
+		return nil, err
+	}
+	return get[Dependencies](ctx, cfg, path+":dependencies")
+}
+
+// GetProjectData returns the deps.dev data of a source code project, given
+// its deps.dev name, e.g. github.com/snyk/parlay.
+func GetProjectData(ctx context.Context, cfg *Config, id string) (*Response[Project], error) {
+	return get[Project](ctx, cfg, "/projects/"+url.PathEscape(id))
+}
+
+// get requests path from the deps.dev API and decodes the JSON response. The
+// body of the response is closed before returning. Unsuccessful responses
+// are returned along with the error, so that they can be reported.
+func get[T any](ctx context.Context, cfg *Config, path string) (*Response[T], error) {
+	ctx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.endpoint(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	httpclient.SetHeaders(req, cfg.Header)
+	resp, err := httpclient.Default().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	r := &Response[T]{HTTPResponse: resp}
+	if resp.StatusCode != http.StatusOK {
+		return r, fmt.Errorf("unexpected response from deps.dev: %s", resp.Status)
+	}
+	if r.Body, err = io.ReadAll(resp.Body); err != nil {
+		return r, err
+	}
+	var data T
+	if err := json.Unmarshal(r.Body, &data); err != nil {
+		return r, fmt.Errorf("failed to decode deps.dev response: %w", err)
+	}
+	r.Data = &data
+	return r, nil
+}
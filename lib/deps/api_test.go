@@ -0,0 +1,94 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package deps
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/package-url/packageurl-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionPath(t *testing.T) {
+	tests := []struct {
+		purl string
+		path string
+	}{
+		{"pkg:npm/lodash@4.17.21", "/systems/NPM/packages/lodash/versions/4.17.21"},
+		{"pkg:npm/%40babel/core@7.24.0", "/systems/NPM/packages/@babel%2Fcore/versions/7.24.0"},
+		{"pkg:maven/org.apache.commons/commons-lang3@3.14.0", "/systems/MAVEN/packages/org.apache.commons:commons-lang3/versions/3.14.0"},
+		{"pkg:golang/github.com/spf13/cobra@v1.7.0", "/systems/GO/packages/github.com%2Fspf13%2Fcobra/versions/v1.7.0"},
+		{"pkg:pypi/requests@2.31.0", "/systems/PYPI/packages/requests/versions/2.31.0"},
+		{"pkg:gem/rails@7.1.3", "/systems/RUBYGEMS/packages/rails/versions/7.1.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.purl, func(t *testing.T) {
+			purl, err := packageurl.FromString(tt.purl)
+			require.NoError(t, err)
+
+			path, err := versionPath(purl)
+			require.NoError(t, err)
+			assert.Equal(t, tt.path, path)
+		})
+	}
+}
+
+func TestVersionPath_Errors(t *testing.T) {
+	_, err := versionPath(packageurl.PackageURL{Type: "swift", Name: "example", Version: "1.0.0"})
+	assert.EqualError(t, err, `unsupported purl type "swift"`)
+
+	_, err = versionPath(packageurl.PackageURL{Type: "npm", Name: "example"})
+	assert.EqualError(t, err, "no version on PackageURL")
+}
+
+func TestGetDependenciesData(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "https://deps.example.com/v3/systems/NPM/packages/@example%2Fapp/versions/1.0.0:dependencies",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+			return httpmock.NewStringResponse(http.StatusOK, dependenciesResponse), nil
+		})
+
+	cfg := DefaultConfig()
+	cfg.APIURL = "https://deps.example.com/v3/"
+	cfg.Header = http.Header{"Authorization": {"Bearer token"}}
+	purl, err := packageurl.FromString("pkg:npm/%40example/app@1.0.0")
+	require.NoError(t, err)
+
+	resp, err := GetDependenciesData(context.Background(), cfg, purl)
+	require.NoError(t, err)
+	assert.JSONEq(t, dependenciesResponse, string(resp.Body))
+	assert.Len(t, resp.Data.Nodes, 4)
+	assert.Len(t, resp.Data.Edges, 3)
+}
+
+func TestGetPackageData_NotFound(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "https://api.deps.dev/v3/systems/PYPI/packages/missing",
+		httpmock.NewStringResponder(http.StatusNotFound, `{"error": "package not found"}`))
+
+	resp, err := GetPackageData(context.Background(), DefaultConfig(), packageurl.PackageURL{Type: "pypi", Name: "missing", Version: "1.0.0"})
+	assert.EqualError(t, err, "unexpected response from deps.dev: 404")
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusNotFound, resp.HTTPResponse.StatusCode)
+	assert.Nil(t, resp.Data)
+}
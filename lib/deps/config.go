@@ -0,0 +1,60 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package deps
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/snyk/parlay/internal/pool"
+	"github.com/snyk/parlay/lib/report"
+)
+
+// reportProvider names deps.dev in enrichment reports.
+const reportProvider = "deps"
+
+// Config controls how SBOMs are enriched with deps.dev data.
+type Config struct {
+	// APIURL is the base URL of the deps.dev API, which may be a
+	// self-hosted mirror.
+	APIURL string
+
+	// Header is added to every request to the deps.dev API, e.g. to
+	// authenticate with a private mirror.
+	Header http.Header
+
+	// Concurrency is the number of packages enriched in parallel.
+	Concurrency int
+
+	// RequestTimeout bounds each request to deps.dev. A zero value leaves
+	// requests bound only by the context enrichment runs under.
+	RequestTimeout time.Duration
+
+	// Report, if set, collects the outcome of enriching each package.
+	Report *report.Report
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		APIURL:      "https://api.deps.dev/v3",
+		Concurrency: pool.DefaultConcurrency,
+	}
+}
+
+func (cfg *Config) endpoint(path string) string {
+	return strings.TrimSuffix(cfg.APIURL, "/") + path
+}
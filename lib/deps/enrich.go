@@ -0,0 +1,232 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package deps
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+	"github.com/spdx/tools-golang/spdx"
+
+	"github.com/snyk/parlay/internal/repourl"
+	"github.com/snyk/parlay/lib/report"
+	"github.com/snyk/parlay/lib/sbom"
+	"github.com/snyk/parlay/lib/scorecard"
+)
+
+// EnrichSBOM enriches the packages of the SBOM with deps.dev data about
+// their version: licenses, advisories, links and the OpenSSF Scorecard of
+// their source repository. When ctx is done, packages which weren't enriched
+// yet are left as they are.
+func EnrichSBOM(ctx context.Context, cfg *Config, doc *sbom.SBOMDocument) *sbom.SBOMDocument {
+	cache := &projectCache{entries: make(map[string]*projectEntry)}
+	switch bom := doc.BOM.(type) {
+	case *cdx.BOM:
+		enrichCDX(ctx, cfg, bom, cache)
+	case *spdx.Document:
+		enrichSPDX(ctx, cfg, bom, cache)
+	}
+	return doc
+}
+
+// link describes how a deps.dev link is recorded in an SBOM.
+type link struct {
+	field   string
+	cdxType cdx.ExternalReferenceType
+	spdxRef string
+}
+
+// links maps the labels of deps.dev links to the references they're
+// recorded as. Homepages are recorded in the homepage field of SPDX
+// packages instead.
+var links = map[string]link{
+	"HOMEPAGE":      {"homepage", cdx.ERTypeWebsite, ""},
+	"SOURCE_REPO":   {"repository_url", cdx.ERTypeVCS, "vcs"},
+	"ISSUE_TRACKER": {"issue_tracker_url", cdx.ERTypeIssueTracker, "issue-tracker"},
+	"DOCUMENTATION": {"documentation_url", cdx.ERTypeDocumentation, "documentation"},
+	"ORIGIN":        {"registry_url", cdx.ERTypeDistribution, "distribution"},
+}
+
+// licenseExpression returns the licenses of a version as a single
+// expression, in the form the ecosyste.ms enricher uses. deps.dev reports
+// licenses it couldn't identify as "non-standard", and those are left out.
+func licenseExpression(v *Version) string {
+	var licenses []string
+	for _, l := range v.Licenses {
+		if l != "" && l != "non-standard" {
+			licenses = append(licenses, l)
+		}
+	}
+	switch len(licenses) {
+	case 0:
+		return ""
+	case 1:
+		return licenses[0]
+	default:
+		return "(" + strings.Join(licenses, " OR ") + ")"
+	}
+}
+
+// fieldChanges collects the values fields of a package had before and after
+// enrichment, so that each is recorded once however many values were added
+// to it.
+type fieldChanges struct {
+	fields []string
+	before map[string][]string
+	after  map[string][]string
+}
+
+// update notes the values of field before and after a change to it.
+func (c *fieldChanges) update(field string, before, after []string) {
+	if c.before == nil {
+		c.before = make(map[string][]string)
+		c.after = make(map[string][]string)
+	}
+	if _, ok := c.before[field]; !ok {
+		c.fields = append(c.fields, field)
+		c.before[field] = before
+	}
+	c.after[field] = after
+}
+
+func (c *fieldChanges) record(out *report.Outcome) {
+	for _, field := range c.fields {
+		out.RecordChange(field, c.before[field], c.after[field])
+	}
+}
+
+// advisoryURL returns the OSV page of an advisory. deps.dev names advisories
+// by their OSV IDs.
+func advisoryURL(id string) string {
+	return "https://osv.dev/vulnerability/" + id
+}
+
+// lookupVersion fetches the deps.dev data of a package version. If there is
+// none, it records why on out and returns false.
+func lookupVersion(ctx context.Context, cfg *Config, purl packageurl.PackageURL, out *report.Outcome) (*Version, bool) {
+	if _, err := versionPath(purl); err != nil {
+		out.Skip(err.Error())
+		return nil, false
+	}
+	resp, err := GetVersionData(ctx, cfg, purl)
+	if resp != nil {
+		out.Response(resp.HTTPResponse)
+	}
+	if err != nil {
+		out.Fail("failed to get version data: " + err.Error())
+		return nil, false
+	}
+	return resp.Data, true
+}
+
+// projectID returns the deps.dev name of the source repository of a version,
+// preferring the project deps.dev relates it to over its source link.
+func projectID(v *Version) string {
+	for _, p := range v.RelatedProjects {
+		if p.RelationType == "SOURCE_REPO" && p.ProjectKey.ID != "" {
+			return p.ProjectKey.ID
+		}
+	}
+	for _, l := range v.Links {
+		if l.Label != "SOURCE_REPO" {
+			continue
+		}
+		if repo, ok := repourl.Parse(l.URL); ok {
+			return repo.String()
+		}
+	}
+	return ""
+}
+
+// lookupScorecard returns the OpenSSF Scorecard deps.dev has for the source
+// repository of a version, or nil if it has none. Failing to look up the
+// repository is recorded on out.
+func lookupScorecard(ctx context.Context, cfg *Config, cache *projectCache, v *Version, out *report.Outcome) *scorecard.Result {
+	id := projectID(v)
+	if id == "" {
+		return nil
+	}
+	resp, fetched, err := cache.get(ctx, cfg, id)
+	if fetched && resp != nil {
+		out.Response(resp.HTTPResponse)
+	}
+	if err != nil {
+		out.Fail("failed to get project data: " + err.Error())
+		return nil
+	}
+	sc := resp.Data.Scorecard
+	if sc == nil {
+		return nil
+	}
+
+	result := &scorecard.Result{Date: sc.Date, Score: &sc.OverallScore}
+	result.Repo.Name = sc.Repository.Name
+	result.Repo.Commit = sc.Repository.Commit
+	for _, c := range sc.Checks {
+		result.Checks = append(result.Checks, scorecard.Check{Name: c.Name, Score: c.Score, Reason: c.Reason})
+	}
+	return result
+}
+
+// projectCache holds the projects looked up while enriching an SBOM, so that
+// packages from the same repository only look it up once.
+type projectCache struct {
+	mu      sync.Mutex
+	entries map[string]*projectEntry
+}
+
+type projectEntry struct {
+	done chan struct{}
+	resp *Response[Project]
+	err  error
+}
+
+// get returns the project named id, looking it up unless it was looked up
+// before or is being looked up for another package, in which case it waits
+// for that lookup. fetched reports whether this call made the request.
+// Failed lookups aren't cached, so that they're retried for the next
+// package.
+func (c *projectCache) get(ctx context.Context, cfg *Config, id string) (resp *Response[Project], fetched bool, err error) {
+	c.mu.Lock()
+	if e, ok := c.entries[id]; ok {
+		c.mu.Unlock()
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+		if e.err == nil {
+			return e.resp, false, nil
+		}
+		// The lookup failed for another package, so try again.
+		return c.get(ctx, cfg, id)
+	}
+	e := &projectEntry{done: make(chan struct{})}
+	c.entries[id] = e
+	c.mu.Unlock()
+
+	e.resp, e.err = GetProjectData(ctx, cfg, id)
+	if e.err != nil {
+		c.mu.Lock()
+		delete(c.entries, id)
+		c.mu.Unlock()
+	}
+	close(e.done)
+	return e.resp, true, e.err
+}
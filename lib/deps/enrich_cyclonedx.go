@@ -0,0 +1,122 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package deps
+
+import (
+	"context"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+
+	"github.com/snyk/parlay/internal/pool"
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/report"
+)
+
+func enrichCDX(ctx context.Context, cfg *Config, bom *cdx.BOM, cache *projectCache) {
+	comps := utils.DiscoverCDXComponents(bom)
+
+	pool.Run(cfg.Concurrency, comps, func(comp *cdx.Component) {
+		out := cfg.Report.Outcome(reportProvider, utils.CDXComponentID(comp), comp.PackageURL)
+		if err := ctx.Err(); err != nil {
+			out.Skip("enrichment interrupted: " + err.Error())
+			return
+		}
+
+		purl, err := packageurl.FromString(comp.PackageURL)
+		if err != nil {
+			out.Skip("no usable PackageURL")
+			return
+		}
+		version, ok := lookupVersion(ctx, cfg, purl, out)
+		if !ok {
+			return
+		}
+
+		enrichCDXVersion(comp, version, out)
+		if result := lookupScorecard(ctx, cfg, cache, version, out); result != nil {
+			result.RecordCDX(comp, out)
+		}
+	})
+}
+
+// enrichCDXVersion adds the licenses, links, advisories and publication date
+// of a version to a component. Licenses are only added to components which
+// have none.
+func enrichCDXVersion(comp *cdx.Component, v *Version, out *report.Outcome) {
+	var changes fieldChanges
+
+	if comp.Licenses == nil || len(*comp.Licenses) == 0 {
+		if expression := licenseExpression(v); expression != "" {
+			comp.Licenses = &cdx.Licenses{{Expression: expression}}
+			changes.update("license", nil, []string{expression})
+		}
+	}
+
+	for _, l := range v.Links {
+		link, ok := links[l.Label]
+		if !ok || l.URL == "" {
+			continue
+		}
+		before := cdxReferences(comp, link.cdxType)
+		utils.AddCDXExternalReference(comp, cdx.ExternalReference{URL: l.URL, Type: link.cdxType})
+		changes.update(link.field, before, cdxReferences(comp, link.cdxType))
+	}
+
+	for _, a := range v.AdvisoryKeys {
+		before := cdxReferences(comp, cdx.ERTypeAdvisories)
+		utils.AddCDXExternalReference(comp, cdx.ExternalReference{URL: advisoryURL(a.ID), Comment: a.ID, Type: cdx.ERTypeAdvisories})
+		changes.update("advisories", before, cdxReferences(comp, cdx.ERTypeAdvisories))
+	}
+
+	if v.PublishedAt != "" {
+		before := cdxProperties(comp, "depsdev:published_at")
+		utils.AddCDXProperty(comp, cdx.Property{Name: "depsdev:published_at", Value: v.PublishedAt})
+		changes.update("release_date", before, cdxProperties(comp, "depsdev:published_at"))
+	}
+
+	changes.record(out)
+}
+
+// cdxReferences returns the URLs of the external references of a component
+// which have the given type.
+func cdxReferences(comp *cdx.Component, refType cdx.ExternalReferenceType) []string {
+	if comp.ExternalReferences == nil {
+		return nil
+	}
+	var urls []string
+	for _, ref := range *comp.ExternalReferences {
+		if ref.Type == refType {
+			urls = append(urls, ref.URL)
+		}
+	}
+	return urls
+}
+
+// cdxProperties returns the values of the properties of a component which
+// have the given name.
+func cdxProperties(comp *cdx.Component, name string) []string {
+	if comp.Properties == nil {
+		return nil
+	}
+	var values []string
+	for _, p := range *comp.Properties {
+		if p.Name == name {
+			values = append(values, p.Value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,120 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package deps
+
+import (
+	"context"
+
+	"github.com/spdx/tools-golang/spdx"
+	spdx_2_3 "github.com/spdx/tools-golang/spdx/v2/v2_3"
+
+	"github.com/snyk/parlay/internal/pool"
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/report"
+)
+
+func enrichSPDX(ctx context.Context, cfg *Config, bom *spdx.Document, cache *projectCache) {
+	pool.Run(cfg.Concurrency, bom.Packages, func(pkg *spdx_2_3.Package) {
+		out := cfg.Report.Outcome(reportProvider, string(pkg.PackageSPDXIdentifier), "")
+		if err := ctx.Err(); err != nil {
+			out.Skip("enrichment interrupted: " + err.Error())
+			return
+		}
+
+		purl, err := utils.GetPurlFromSPDXPackage(pkg)
+		if err != nil {
+			out.Skip("no usable PackageURL")
+			return
+		}
+		out = cfg.Report.Outcome(reportProvider, string(pkg.PackageSPDXIdentifier), purl.String())
+
+		version, ok := lookupVersion(ctx, cfg, *purl, out)
+		if !ok {
+			return
+		}
+
+		enrichSPDXVersion(pkg, version, out)
+		if result := lookupScorecard(ctx, cfg, cache, version, out); result != nil {
+			result.RecordSPDX(pkg, out)
+		}
+	})
+}
+
+// enrichSPDXVersion adds the licenses, links, advisories and publication
+// date of a version to a package. Fields holding a single value, such as the
+// concluded license, are only set if the package has no value for them.
+func enrichSPDXVersion(pkg *spdx_2_3.Package, v *Version, out *report.Outcome) {
+	var changes fieldChanges
+
+	// NOASSERTION is as good as no license at all.
+	if pkg.PackageLicenseConcluded == "" || pkg.PackageLicenseConcluded == "NOASSERTION" {
+		if expression := licenseExpression(v); expression != "" {
+			pkg.PackageLicenseConcluded = expression
+			changes.update("license", nil, []string{expression})
+		}
+	}
+
+	for _, l := range v.Links {
+		link, ok := links[l.Label]
+		if !ok || l.URL == "" {
+			continue
+		}
+		if link.spdxRef == "" {
+			if pkg.PackageHomePage == "" {
+				pkg.PackageHomePage = l.URL
+				changes.update(link.field, nil, []string{l.URL})
+			}
+			continue
+		}
+		before := spdxReferences(pkg, link.spdxRef)
+		utils.AddSPDXExternalReference(pkg, &spdx_2_3.PackageExternalReference{
+			Category: spdx.CategoryOther,
+			RefType:  link.spdxRef,
+			Locator:  l.URL,
+		})
+		changes.update(link.field, before, spdxReferences(pkg, link.spdxRef))
+	}
+
+	for _, a := range v.AdvisoryKeys {
+		before := spdxReferences(pkg, "advisory")
+		utils.AddSPDXExternalReference(pkg, &spdx_2_3.PackageExternalReference{
+			Category:           spdx.CategorySecurity,
+			RefType:            "advisory",
+			Locator:            advisoryURL(a.ID),
+			ExternalRefComment: a.ID,
+		})
+		changes.update("advisories", before, spdxReferences(pkg, "advisory"))
+	}
+
+	if v.PublishedAt != "" && pkg.ReleaseDate == "" {
+		pkg.ReleaseDate = v.PublishedAt
+		changes.update("release_date", nil, []string{v.PublishedAt})
+	}
+
+	changes.record(out)
+}
+
+// spdxReferences returns the locators of the external references of a
+// package which have the given type.
+func spdxReferences(pkg *spdx_2_3.Package, refType string) []string {
+	var locators []string
+	for _, ref := range pkg.PackageExternalReferences {
+		if ref.RefType == refType {
+			locators = append(locators, ref.Locator)
+		}
+	}
+	return locators
+}
@@ -0,0 +1,292 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package deps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/jarcoal/httpmock"
+	"github.com/spdx/tools-golang/spdx"
+	spdx_2_3 "github.com/spdx/tools-golang/spdx/v2/v2_3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/snyk/parlay/lib/report"
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+const (
+	versionURL = "https://api.deps.dev/v3/systems/NPM/packages/example/versions/1.0.0"
+	projectURL = "https://api.deps.dev/v3/projects/github.com%2Fexample%2Frepository"
+)
+
+const versionResponse = `{
+  "versionKey": {"system": "NPM", "name": "example", "version": "1.0.0"},
+  "publishedAt": "2024-05-20T00:00:00Z",
+  "licenses": ["MIT"],
+  "advisoryKeys": [{"id": "GHSA-xxxx-yyyy-zzzz"}],
+  "links": [
+    {"label": "HOMEPAGE", "url": "https://example.com"},
+    {"label": "SOURCE_REPO", "url": "git+https://github.com/example/repository.git"},
+    {"label": "ISSUE_TRACKER", "url": "https://github.com/example/repository/issues"},
+    {"label": "ORIGIN", "url": "https://registry.npmjs.org/example/1.0.0"}
+  ],
+  "relatedProjects": [
+    {"projectKey": {"id": "github.com/example/repository"}, "relationProvenance": "UNVERIFIED_METADATA", "relationType": "SOURCE_REPO"}
+  ]
+}`
+
+const projectResponse = `{
+  "projectKey": {"id": "github.com/example/repository"},
+  "scorecard": {
+    "date": "2024-05-20T00:00:00Z",
+    "repository": {"name": "github.com/example/repository", "commit": "4b2c1a"},
+    "overallScore": 6.4,
+    "checks": [
+      {"name": "Maintained", "score": 10, "reason": "30 commit(s) out of 30 found in the last 90 days"},
+      {"name": "Fuzzing", "score": -1, "reason": "internal error"}
+    ]
+  }
+}`
+
+func setupDepsAPIMock(t *testing.T) func() {
+	t.Helper()
+
+	httpmock.Activate()
+	httpmock.RegisterResponder("GET", versionURL, httpmock.NewStringResponder(http.StatusOK, versionResponse))
+	httpmock.RegisterResponder("GET", projectURL, httpmock.NewStringResponder(http.StatusOK, projectResponse))
+	httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("unexpected HTTP request: " + req.URL.String())
+	})
+
+	return httpmock.DeactivateAndReset
+}
+
+func TestEnrichSBOM_CycloneDX(t *testing.T) {
+	teardown := setupDepsAPIMock(t)
+	defer teardown()
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "example", PackageURL: "pkg:npm/example@1.0.0"},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+
+	cfg := DefaultConfig()
+	cfg.Report = report.New()
+	EnrichSBOM(context.Background(), cfg, doc)
+
+	comp := (*bom.Components)[0]
+	assert.Equal(t, &cdx.Licenses{{Expression: "MIT"}}, comp.Licenses)
+	assert.Equal(t, &[]cdx.ExternalReference{
+		{URL: "https://example.com", Type: cdx.ERTypeWebsite},
+		{URL: "git+https://github.com/example/repository.git", Type: cdx.ERTypeVCS},
+		{URL: "https://github.com/example/repository/issues", Type: cdx.ERTypeIssueTracker},
+		{URL: "https://registry.npmjs.org/example/1.0.0", Type: cdx.ERTypeDistribution},
+		{URL: "https://osv.dev/vulnerability/GHSA-xxxx-yyyy-zzzz", Comment: "GHSA-xxxx-yyyy-zzzz", Type: cdx.ERTypeAdvisories},
+	}, comp.ExternalReferences)
+	assert.Equal(t, &[]cdx.Property{
+		{Name: "depsdev:published_at", Value: "2024-05-20T00:00:00Z"},
+		{Name: "scorecard:score", Value: "6.4"},
+		{Name: "scorecard:date", Value: "2024-05-20T00:00:00Z"},
+		{Name: "scorecard:commit", Value: "4b2c1a"},
+		{Name: "scorecard:check:Maintained", Value: "10"},
+		{Name: "scorecard:check:Fuzzing", Value: "-1"},
+	}, comp.Properties)
+
+	outcome := cfg.Report.Outcome(reportProvider, "example", "")
+	assert.Equal(t, report.StatusEnriched, outcome.Status)
+	assert.Equal(t, []string{
+		"license", "homepage", "repository_url", "issue_tracker_url", "registry_url",
+		"advisories", "release_date", "scorecard_results",
+	}, outcome.FieldsAdded)
+	assert.Len(t, outcome.Requests, 2)
+}
+
+func TestEnrichSBOM_CycloneDX_KeepsLicenses(t *testing.T) {
+	teardown := setupDepsAPIMock(t)
+	defer teardown()
+
+	licenses := &cdx.Licenses{{Expression: "Apache-2.0"}}
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{PackageURL: "pkg:npm/example@1.0.0", Licenses: licenses},
+		},
+	}
+
+	EnrichSBOM(context.Background(), DefaultConfig(), &sbom.SBOMDocument{BOM: bom})
+
+	assert.Equal(t, &cdx.Licenses{{Expression: "Apache-2.0"}}, (*bom.Components)[0].Licenses)
+}
+
+func TestEnrichSBOM_SPDX(t *testing.T) {
+	teardown := setupDepsAPIMock(t)
+	defer teardown()
+
+	bom := &spdx.Document{
+		Packages: []*spdx_2_3.Package{
+			{
+				PackageSPDXIdentifier:   "example",
+				PackageLicenseConcluded: "NOASSERTION",
+				PackageExternalReferences: []*spdx_2_3.PackageExternalReference{
+					{Category: spdx.CategoryPackageManager, RefType: "purl", Locator: "pkg:npm/example@1.0.0"},
+				},
+			},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+
+	EnrichSBOM(context.Background(), DefaultConfig(), doc)
+
+	pkg := bom.Packages[0]
+	assert.Equal(t, "MIT", pkg.PackageLicenseConcluded)
+	assert.Equal(t, "https://example.com", pkg.PackageHomePage)
+	assert.Equal(t, "2024-05-20T00:00:00Z", pkg.ReleaseDate)
+	assert.Equal(t, []*spdx_2_3.PackageExternalReference{
+		{Category: spdx.CategoryPackageManager, RefType: "purl", Locator: "pkg:npm/example@1.0.0"},
+		{Category: spdx.CategoryOther, RefType: "vcs", Locator: "git+https://github.com/example/repository.git"},
+		{Category: spdx.CategoryOther, RefType: "issue-tracker", Locator: "https://github.com/example/repository/issues"},
+		{Category: spdx.CategoryOther, RefType: "distribution", Locator: "https://registry.npmjs.org/example/1.0.0"},
+		{Category: spdx.CategorySecurity, RefType: "advisory", Locator: "https://osv.dev/vulnerability/GHSA-xxxx-yyyy-zzzz", ExternalRefComment: "GHSA-xxxx-yyyy-zzzz"},
+	}, pkg.PackageExternalReferences)
+
+	var comments []string
+	for _, a := range pkg.Annotations {
+		comments = append(comments, a.AnnotationComment)
+	}
+	assert.Equal(t, []string{
+		"scorecard:score=6.4",
+		"scorecard:date=2024-05-20T00:00:00Z",
+		"scorecard:commit=4b2c1a",
+		"scorecard:check:Maintained=10",
+		"scorecard:check:Fuzzing=-1",
+	}, comments)
+}
+
+func TestEnrichSBOM_SharedProject(t *testing.T) {
+	teardown := setupDepsAPIMock(t)
+	defer teardown()
+
+	otherVersionURL := "https://api.deps.dev/v3/systems/NPM/packages/other/versions/2.0.0"
+	httpmock.RegisterResponder("GET", otherVersionURL, httpmock.NewStringResponder(http.StatusOK, `{
+  "versionKey": {"system": "NPM", "name": "other", "version": "2.0.0"},
+  "links": [{"label": "SOURCE_REPO", "url": "https://github.com/example/repository"}]
+}`))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{PackageURL: "pkg:npm/example@1.0.0"},
+			{PackageURL: "pkg:npm/other@2.0.0"},
+		},
+	}
+
+	EnrichSBOM(context.Background(), DefaultConfig(), &sbom.SBOMDocument{BOM: bom})
+
+	for _, comp := range *bom.Components {
+		require.NotNil(t, comp.Properties)
+		assert.Contains(t, *comp.Properties, cdx.Property{Name: "scorecard:score", Value: "6.4"})
+	}
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 1, calls["GET "+projectURL])
+}
+
+func TestEnrichSBOM_RetriesFailedProject(t *testing.T) {
+	teardown := setupDepsAPIMock(t)
+	defer teardown()
+
+	otherVersionURL := "https://api.deps.dev/v3/systems/NPM/packages/other/versions/2.0.0"
+	httpmock.RegisterResponder("GET", otherVersionURL, httpmock.NewStringResponder(http.StatusOK, `{
+  "versionKey": {"system": "NPM", "name": "other", "version": "2.0.0"},
+  "links": [{"label": "SOURCE_REPO", "url": "https://github.com/example/repository"}]
+}`))
+	httpmock.RegisterResponder("GET", projectURL,
+		httpmock.NewStringResponder(http.StatusNotFound, `{"error": "not found"}`).
+			Then(httpmock.NewStringResponder(http.StatusOK, projectResponse)))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{PackageURL: "pkg:npm/example@1.0.0"},
+			{PackageURL: "pkg:npm/other@2.0.0"},
+		},
+	}
+
+	EnrichSBOM(context.Background(), DefaultConfig(), &sbom.SBOMDocument{BOM: bom})
+
+	scored := 0
+	for _, comp := range *bom.Components {
+		if comp.Properties != nil && slices.Contains(*comp.Properties, cdx.Property{Name: "scorecard:score", Value: "6.4"}) {
+			scored++
+		}
+	}
+	assert.Equal(t, 1, scored, "the project is looked up again after failing")
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 2, calls["GET "+projectURL])
+}
+
+func TestEnrichSBOM_Skipped(t *testing.T) {
+	teardown := setupDepsAPIMock(t)
+	defer teardown()
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "no-purl", Name: "example"},
+			{BOMRef: "no-version", PackageURL: "pkg:npm/example"},
+			{BOMRef: "unsupported", PackageURL: "pkg:generic/example@1.0.0"},
+		},
+	}
+
+	cfg := DefaultConfig()
+	cfg.Report = report.New()
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	for id, reason := range map[string]string{
+		"no-purl":     "no usable PackageURL",
+		"no-version":  "no version on PackageURL",
+		"unsupported": `unsupported purl type "generic"`,
+	} {
+		outcome := cfg.Report.Outcome(reportProvider, id, "")
+		assert.Equal(t, report.StatusSkipped, outcome.Status, id)
+		assert.Equal(t, reason, outcome.Reason, id)
+	}
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+}
+
+func TestEnrichSBOM_ErrorFetchingVersion(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", versionURL, httpmock.NewStringResponder(http.StatusNotFound, `{"error": "not found"}`))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "example", PackageURL: "pkg:npm/example@1.0.0"},
+		},
+	}
+
+	cfg := DefaultConfig()
+	cfg.Report = report.New()
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	assert.Nil(t, (*bom.Components)[0].ExternalReferences)
+	outcome := cfg.Report.Outcome(reportProvider, "example", "")
+	assert.Equal(t, report.StatusFailed, outcome.Status)
+	assert.Equal(t, "failed to get version data: unexpected response from deps.dev: 404", outcome.Reason)
+}
@@ -0,0 +1,136 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package deps
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
+	"github.com/package-url/packageurl-go"
+
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+// versionPurl returns the purl of a deps.dev package version.
+func versionPurl(key VersionKey) (packageurl.PackageURL, bool) {
+	for purlType, system := range systems {
+		if system != key.System {
+			continue
+		}
+		var namespace string
+		name := key.Name
+		switch purlType {
+		case packageurl.TypeMaven:
+			namespace, name, _ = strings.Cut(name, ":")
+		case packageurl.TypeNPM:
+			if strings.HasPrefix(name, "@") {
+				namespace, name, _ = strings.Cut(name, "/")
+			}
+		case packageurl.TypeGolang:
+			if i := strings.LastIndex(name, "/"); i >= 0 {
+				namespace, name = name[:i], name[i+1:]
+			}
+		}
+		return *packageurl.NewPackageURL(purlType, namespace, name, key.Version, nil, ""), true
+	}
+	return packageurl.PackageURL{}, false
+}
+
+// nodeComponent returns the component of a node of a dependency graph.
+// Versions of systems parlay has no purl type for are identified by their
+// deps.dev version key instead.
+func nodeComponent(key VersionKey) cdx.Component {
+	comp := cdx.Component{
+		Type:    cdx.ComponentTypeLibrary,
+		Name:    key.Name,
+		Version: key.Version,
+	}
+	if purl, ok := versionPurl(key); ok {
+		comp.PackageURL = purl.String()
+		comp.BOMRef = comp.PackageURL
+	} else {
+		comp.BOMRef = key.System + ":" + key.Name + "@" + key.Version
+	}
+	return comp
+}
+
+// DependencyGraphBOM synthesizes a CycloneDX SBOM of a package version from
+// the dependency graph deps.dev resolved for it. The version is the subject
+// of the SBOM, the other nodes of the graph its components, and the edges
+// of the graph the dependencies between them. parlay is recorded as the tool
+// which created it, in the given version.
+func DependencyGraphBOM(graph *Dependencies, version string) (*cdx.BOM, error) {
+	if len(graph.Nodes) == 0 {
+		return nil, errors.New("empty dependency graph")
+	}
+
+	refs := make([]string, len(graph.Nodes))
+	seen := make(map[string]bool)
+	var root cdx.Component
+	components := []cdx.Component{}
+	for i, node := range graph.Nodes {
+		comp := nodeComponent(node.VersionKey)
+		refs[i] = comp.BOMRef
+		switch {
+		case i == 0:
+			root = comp
+		case !seen[comp.BOMRef]:
+			components = append(components, comp)
+		}
+		seen[comp.BOMRef] = true
+	}
+
+	dependsOn := make(map[string][]string)
+	for _, edge := range graph.Edges {
+		if edge.FromNode < 0 || edge.FromNode >= len(refs) || edge.ToNode < 0 || edge.ToNode >= len(refs) {
+			return nil, errors.New("dependency graph has edges to unknown nodes")
+		}
+		from, to := refs[edge.FromNode], refs[edge.ToNode]
+		if !slices.Contains(dependsOn[from], to) {
+			dependsOn[from] = append(dependsOn[from], to)
+		}
+	}
+
+	// Every component is listed, so that those without dependencies are
+	// known to have none rather than unknown ones.
+	dependencies := make([]cdx.Dependency, 0, len(components)+1)
+	for _, ref := range append([]string{root.BOMRef}, componentRefs(components)...) {
+		deps := dependsOn[ref]
+		if deps == nil {
+			deps = []string{}
+		}
+		dependencies = append(dependencies, cdx.Dependency{Ref: ref, Dependencies: &deps})
+	}
+
+	bom := cdx.NewBOM()
+	bom.SerialNumber = uuid.New().URN()
+	bom.Metadata = &cdx.Metadata{Component: &root}
+	sbom.AddCDXTool(bom, "parlay", version)
+	bom.Components = &components
+	bom.Dependencies = &dependencies
+	return bom, nil
+}
+
+func componentRefs(components []cdx.Component) []string {
+	refs := make([]string, len(components))
+	for i, comp := range components {
+		refs[i] = comp.BOMRef
+	}
+	return refs
+}
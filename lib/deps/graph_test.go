@@ -0,0 +1,113 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package deps
+
+import (
+	"encoding/json"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dependenciesResponse = `{
+  "nodes": [
+    {"versionKey": {"system": "NPM", "name": "@example/app", "version": "1.0.0"}, "relation": "SELF"},
+    {"versionKey": {"system": "NPM", "name": "lodash", "version": "4.17.21"}, "relation": "DIRECT"},
+    {"versionKey": {"system": "NPM", "name": "debug", "version": "4.3.4"}, "relation": "DIRECT"},
+    {"versionKey": {"system": "NPM", "name": "ms", "version": "2.1.2"}, "relation": "INDIRECT"}
+  ],
+  "edges": [
+    {"fromNode": 0, "toNode": 1, "requirement": "^4.17.0"},
+    {"fromNode": 0, "toNode": 2, "requirement": "^4.3.0"},
+    {"fromNode": 2, "toNode": 3, "requirement": "2.1.2"}
+  ]
+}`
+
+func TestDependencyGraphBOM(t *testing.T) {
+	var graph Dependencies
+	require.NoError(t, json.Unmarshal([]byte(dependenciesResponse), &graph))
+
+	bom, err := DependencyGraphBOM(&graph, "0.5.0")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, bom.Version, "creating the SBOM isn't a revision of it")
+	assert.NotEmpty(t, bom.SerialNumber)
+	require.NotNil(t, bom.Metadata.Tools)
+	require.NotNil(t, bom.Metadata.Tools.Components)
+	assert.Equal(t, "parlay", (*bom.Metadata.Tools.Components)[0].Name)
+	assert.Equal(t, "0.5.0", (*bom.Metadata.Tools.Components)[0].Version)
+
+	assert.Equal(t, &cdx.Component{
+		BOMRef:     "pkg:npm/%40example/app@1.0.0",
+		Type:       cdx.ComponentTypeLibrary,
+		Name:       "@example/app",
+		Version:    "1.0.0",
+		PackageURL: "pkg:npm/%40example/app@1.0.0",
+	}, bom.Metadata.Component)
+
+	var refs []string
+	for _, comp := range *bom.Components {
+		refs = append(refs, comp.BOMRef)
+	}
+	assert.Equal(t, []string{"pkg:npm/lodash@4.17.21", "pkg:npm/debug@4.3.4", "pkg:npm/ms@2.1.2"}, refs)
+
+	assert.Equal(t, &[]cdx.Dependency{
+		{Ref: "pkg:npm/%40example/app@1.0.0", Dependencies: &[]string{"pkg:npm/lodash@4.17.21", "pkg:npm/debug@4.3.4"}},
+		{Ref: "pkg:npm/lodash@4.17.21", Dependencies: &[]string{}},
+		{Ref: "pkg:npm/debug@4.3.4", Dependencies: &[]string{"pkg:npm/ms@2.1.2"}},
+		{Ref: "pkg:npm/ms@2.1.2", Dependencies: &[]string{}},
+	}, bom.Dependencies)
+}
+
+func TestDependencyGraphBOM_Errors(t *testing.T) {
+	_, err := DependencyGraphBOM(&Dependencies{}, "0.5.0")
+	assert.EqualError(t, err, "empty dependency graph")
+
+	var graph Dependencies
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "nodes": [{"versionKey": {"system": "NPM", "name": "example", "version": "1.0.0"}}],
+  "edges": [{"fromNode": 0, "toNode": 1}]
+}`), &graph))
+	_, err = DependencyGraphBOM(&graph, "0.5.0")
+	assert.EqualError(t, err, "dependency graph has edges to unknown nodes")
+}
+
+func TestVersionPurl(t *testing.T) {
+	tests := []struct {
+		key  VersionKey
+		purl string
+	}{
+		{VersionKey{"NPM", "@babel/core", "7.24.0"}, "pkg:npm/%40babel/core@7.24.0"},
+		{VersionKey{"MAVEN", "org.apache.commons:commons-lang3", "3.14.0"}, "pkg:maven/org.apache.commons/commons-lang3@3.14.0"},
+		{VersionKey{"GO", "github.com/spf13/cobra", "v1.7.0"}, "pkg:golang/github.com/spf13/cobra@v1.7.0"},
+		{VersionKey{"PYPI", "requests", "2.31.0"}, "pkg:pypi/requests@2.31.0"},
+	}
+	for _, tt := range tests {
+		purl, ok := versionPurl(tt.key)
+		require.True(t, ok)
+		assert.Equal(t, tt.purl, purl.String())
+
+		// The purl leads back to the same version on deps.dev.
+		path, err := versionPath(purl)
+		require.NoError(t, err)
+		assert.Contains(t, path, "/systems/"+tt.key.System+"/")
+	}
+
+	_, ok := versionPurl(VersionKey{"UNKNOWN", "example", "1.0.0"})
+	assert.False(t, ok)
+}
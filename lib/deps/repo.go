@@ -17,13 +17,39 @@
 package deps
 
 import (
+	"context"
+
 	"github.com/edoardottt/depsdev/pkg/depsdev"
+
+	"github.com/snyk/parlay/internal/repourl"
 )
 
-func GetRepoData(url string) (*depsdev.Project, error) {
-	proj, err := depsdev.GetProject(url)
-	if err != nil {
-		return nil, err
+// GetRepoData returns the deps.dev project for a repository, given either as
+// a deps.dev project name, e.g. github.com/snyk/parlay, or as any repository
+// URL. The deps.dev client doesn't take a context, so when ctx is done the
+// request is abandoned rather than cancelled.
+func GetRepoData(ctx context.Context, url string) (*depsdev.Project, error) {
+	if repo, ok := repourl.Parse(url); ok {
+		url = repo.String()
+	}
+
+	type result struct {
+		proj depsdev.Project
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		proj, err := depsdev.GetProject(url)
+		done <- result{proj, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return &r.proj, nil
 	}
-	return &proj, nil
 }
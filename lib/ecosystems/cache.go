@@ -17,22 +17,42 @@
 package ecosystems
 
 import (
+	"context"
+	"encoding/json"
 	"sync"
 
 	"github.com/package-url/packageurl-go"
 
 	"github.com/snyk/parlay/ecosystems/packages"
+	"github.com/snyk/parlay/ecosystems/repos"
 )
 
 type Cache interface {
-	GetPackageData(purl packageurl.PackageURL) (*packages.GetRegistryPackageResponse, error)
-	GetPackageVersionData(purl packageurl.PackageURL) (*packages.GetRegistryPackageVersionResponse, error)
+	GetPackageData(ctx context.Context, purl packageurl.PackageURL) (*packages.GetRegistryPackageResponse, error)
+	GetPackageVersionData(ctx context.Context, purl packageurl.PackageURL) (*packages.GetRegistryPackageVersionResponse, error)
+	GetRepoData(ctx context.Context, url string) (*repos.RepositoriesLookupResponse, error)
+	LookupPackage(ctx context.Context, params *packages.LookupPackageParams) (*packages.LookupPackageResponse, error)
 }
 
 type InMemoryCache struct {
-	packageCache        map[string]*packages.GetRegistryPackageResponse
-	packageVersionCache map[string]*packages.GetRegistryPackageVersionResponse
-	mu                  sync.RWMutex
+	packageCache        map[string]*cacheEntry[*packages.GetRegistryPackageResponse]
+	packageVersionCache map[string]*cacheEntry[*packages.GetRegistryPackageVersionResponse]
+	repoCache           map[string]*cacheEntry[*repos.RepositoriesLookupResponse]
+	lookupCache         map[string]*cacheEntry[*packages.LookupPackageResponse]
+	mu                  sync.Mutex
+}
+
+// cacheEntry holds a response once done is closed. Until then, the request
+// is in flight, and callers wanting the same response wait for it rather
+// than making the request again.
+type cacheEntry[T any] struct {
+	done     chan struct{}
+	response T
+	err      error
+
+	// waiters counts the callers which waited for the request, guarded by
+	// the cache's mutex.
+	waiters int
 }
 
 var (
@@ -42,8 +62,10 @@ var (
 
 func NewInMemoryCache() *InMemoryCache {
 	return &InMemoryCache{
-		packageCache:        make(map[string]*packages.GetRegistryPackageResponse),
-		packageVersionCache: make(map[string]*packages.GetRegistryPackageVersionResponse),
+		packageCache:        make(map[string]*cacheEntry[*packages.GetRegistryPackageResponse]),
+		packageVersionCache: make(map[string]*cacheEntry[*packages.GetRegistryPackageVersionResponse]),
+		repoCache:           make(map[string]*cacheEntry[*repos.RepositoriesLookupResponse]),
+		lookupCache:         make(map[string]*cacheEntry[*packages.LookupPackageResponse]),
 	}
 }
 
@@ -63,52 +85,78 @@ func ResetGlobalCache() {
 	globalCacheOnce = sync.Once{}
 }
 
-func (c *InMemoryCache) GetPackageData(purl packageurl.PackageURL) (*packages.GetRegistryPackageResponse, error) {
-	key := purl.ToString()
+func (c *InMemoryCache) GetPackageData(ctx context.Context, purl packageurl.PackageURL) (*packages.GetRegistryPackageResponse, error) {
+	return cachedGet(ctx, &c.mu, c.packageCache, purl.ToString(), func() (*packages.GetRegistryPackageResponse, error) {
+		return getPackageData(ctx, purl, c.lookupRepository)
+	})
+}
 
-	c.mu.RLock()
-	if cached, exists := c.packageCache[key]; exists {
-		c.mu.RUnlock()
-		return cached, nil
-	}
-	c.mu.RUnlock()
+func (c *InMemoryCache) GetPackageVersionData(ctx context.Context, purl packageurl.PackageURL) (*packages.GetRegistryPackageVersionResponse, error) {
+	return cachedGet(ctx, &c.mu, c.packageVersionCache, purl.ToString(), func() (*packages.GetRegistryPackageVersionResponse, error) {
+		return getPackageVersionData(ctx, purl, c.lookupRepository)
+	})
+}
 
-	response, err := GetPackageData(purl)
+func (c *InMemoryCache) GetRepoData(ctx context.Context, url string) (*repos.RepositoriesLookupResponse, error) {
+	return cachedGet(ctx, &c.mu, c.repoCache, url, func() (*repos.RepositoriesLookupResponse, error) {
+		return GetRepoData(ctx, url)
+	})
+}
+
+func (c *InMemoryCache) LookupPackage(ctx context.Context, params *packages.LookupPackageParams) (*packages.LookupPackageResponse, error) {
+	key, err := json.Marshal(params)
 	if err != nil {
 		return nil, err
 	}
-
-	c.mu.Lock()
-	c.packageCache[key] = response
-	c.mu.Unlock()
-
-	return response, nil
+	return cachedGet(ctx, &c.mu, c.lookupCache, string(key), func() (*packages.LookupPackageResponse, error) {
+		return LookupPackage(ctx, params)
+	})
 }
 
-func (c *InMemoryCache) GetPackageVersionData(purl packageurl.PackageURL) (*packages.GetRegistryPackageVersionResponse, error) {
-	key := purl.ToString()
+// lookupRepository returns the packages published from a source repository.
+// Both the package and the package version requests of a repository purl
+// need them, so they're only looked up once.
+func (c *InMemoryCache) lookupRepository(ctx context.Context, url string) (*packages.LookupPackageResponse, error) {
+	return c.LookupPackage(ctx, &packages.LookupPackageParams{RepositoryUrl: &url})
+}
 
-	c.mu.RLock()
-	if cached, exists := c.packageVersionCache[key]; exists {
-		c.mu.RUnlock()
-		return cached, nil
+// cachedGet returns the response cached under key, fetching it unless it was
+// fetched before or is being fetched for another package, in which case it
+// waits for that fetch. Failed requests aren't cached, so that they're
+// retried for the next package.
+func cachedGet[T any](ctx context.Context, mu *sync.Mutex, entries map[string]*cacheEntry[T], key string, fetch func() (T, error)) (T, error) {
+	mu.Lock()
+	if e, ok := entries[key]; ok {
+		e.waiters++
+		mu.Unlock()
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+		if e.err == nil {
+			return e.response, nil
+		}
+		// The request failed for another package, so try again.
+		return cachedGet(ctx, mu, entries, key, fetch)
 	}
-	c.mu.RUnlock()
-
-	response, err := GetPackageVersionData(purl)
-	if err != nil {
-		return nil, err
+	e := &cacheEntry[T]{done: make(chan struct{})}
+	entries[key] = e
+	mu.Unlock()
+
+	e.response, e.err = fetch()
+	if e.err != nil {
+		mu.Lock()
+		delete(entries, key)
+		mu.Unlock()
 	}
-
-	c.mu.Lock()
-	c.packageVersionCache[key] = response
-	c.mu.Unlock()
-
-	return response, nil
+	close(e.done)
+	return e.response, e.err
 }
 
 func (c *InMemoryCache) GetCacheStats() (int, int) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return len(c.packageCache), len(c.packageVersionCache)
 }
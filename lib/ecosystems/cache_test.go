@@ -17,6 +17,9 @@
 package ecosystems
 
 import (
+	"context"
+	"net/http"
+	"runtime"
 	"sync"
 	"testing"
 
@@ -24,6 +27,8 @@ import (
 	"github.com/package-url/packageurl-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/snyk/parlay/internal/httpclient"
 )
 
 func TestInMemoryCache_GetPackageData(t *testing.T) {
@@ -41,11 +46,11 @@ func TestInMemoryCache_GetPackageData(t *testing.T) {
 	purl, err := packageurl.FromString("pkg:npm/test-package@1.0.0")
 	require.NoError(t, err)
 
-	resp1, err := cache.GetPackageData(purl)
+	resp1, err := cache.GetPackageData(context.Background(), purl)
 	assert.NoError(t, err)
 	assert.NotNil(t, resp1)
 
-	resp2, err := cache.GetPackageData(purl)
+	resp2, err := cache.GetPackageData(context.Background(), purl)
 	assert.NoError(t, err)
 	assert.NotNil(t, resp2)
 	assert.Equal(t, resp1, resp2)
@@ -73,11 +78,11 @@ func TestInMemoryCache_GetPackageVersionData(t *testing.T) {
 	purl, err := packageurl.FromString("pkg:npm/test-package@1.0.0")
 	require.NoError(t, err)
 
-	resp1, err := cache.GetPackageVersionData(purl)
+	resp1, err := cache.GetPackageVersionData(context.Background(), purl)
 	assert.NoError(t, err)
 	assert.NotNil(t, resp1)
 
-	resp2, err := cache.GetPackageVersionData(purl)
+	resp2, err := cache.GetPackageVersionData(context.Background(), purl)
 	assert.NoError(t, err)
 	assert.NotNil(t, resp2)
 	assert.Equal(t, resp1, resp2)
@@ -106,9 +111,9 @@ func TestInMemoryCache_DifferentPackages(t *testing.T) {
 	purl2, err := packageurl.FromString("pkg:npm/package2@1.0.0")
 	require.NoError(t, err)
 
-	_, err = cache.GetPackageData(purl1)
+	_, err = cache.GetPackageData(context.Background(), purl1)
 	assert.NoError(t, err)
-	_, err = cache.GetPackageData(purl2)
+	_, err = cache.GetPackageData(context.Background(), purl2)
 	assert.NoError(t, err)
 
 	callCount := httpmock.GetTotalCallCount()
@@ -135,9 +140,9 @@ func TestInMemoryCache_SamePackageDifferentVersions(t *testing.T) {
 	purl2, err := packageurl.FromString("pkg:npm/package@2.0.0")
 	require.NoError(t, err)
 
-	_, err = cache.GetPackageData(purl1)
+	_, err = cache.GetPackageData(context.Background(), purl1)
 	assert.NoError(t, err)
-	_, err = cache.GetPackageData(purl2)
+	_, err = cache.GetPackageData(context.Background(), purl2)
 	assert.NoError(t, err)
 
 	// Different versions = different cache entries
@@ -153,6 +158,12 @@ func TestInMemoryCache_APIError(t *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
 
+	// Don't retry the failed request, so that only the cache is exercised
+	client, err := httpclient.New(&httpclient.Config{}, nil)
+	require.NoError(t, err)
+	httpclient.SetDefault(client)
+	defer httpclient.SetDefault(nil)
+
 	// HTTP client returns a successful response even with 500 status
 	// So we need to test that the client properly handles this case
 	httpmock.RegisterResponder(
@@ -166,11 +177,11 @@ func TestInMemoryCache_APIError(t *testing.T) {
 	require.NoError(t, err)
 
 	// HTTP client doesn't treat 500 as error
-	resp1, err := cache.GetPackageData(purl)
+	resp1, err := cache.GetPackageData(context.Background(), purl)
 	assert.NoError(t, err)
 	assert.Equal(t, 500, resp1.StatusCode())
 
-	resp2, err := cache.GetPackageData(purl)
+	resp2, err := cache.GetPackageData(context.Background(), purl)
 	assert.NoError(t, err)
 	assert.Equal(t, 500, resp2.StatusCode())
 	assert.Equal(t, resp1, resp2)
@@ -201,11 +212,11 @@ func TestInMemoryCache_404NotFound(t *testing.T) {
 	require.NoError(t, err)
 
 	// HTTP client doesn't treat 404 as error
-	resp1, err := cache.GetPackageData(purl)
+	resp1, err := cache.GetPackageData(context.Background(), purl)
 	assert.NoError(t, err)
 	assert.Equal(t, 404, resp1.StatusCode())
 
-	resp2, err := cache.GetPackageData(purl)
+	resp2, err := cache.GetPackageData(context.Background(), purl)
 	assert.NoError(t, err)
 	assert.Equal(t, 404, resp2.StatusCode())
 	assert.Equal(t, resp1, resp2)
@@ -235,11 +246,11 @@ func TestInMemoryCache_404NotFoundVersionData(t *testing.T) {
 	require.NoError(t, err)
 
 	// HTTP client doesn't treat 404 as error
-	resp1, err := cache.GetPackageVersionData(purl)
+	resp1, err := cache.GetPackageVersionData(context.Background(), purl)
 	assert.NoError(t, err)
 	assert.Equal(t, 404, resp1.StatusCode())
 
-	resp2, err := cache.GetPackageVersionData(purl)
+	resp2, err := cache.GetPackageVersionData(context.Background(), purl)
 	assert.NoError(t, err)
 	assert.Equal(t, 404, resp2.StatusCode())
 	assert.Equal(t, resp1, resp2)
@@ -274,7 +285,7 @@ func TestInMemoryCache_ConcurrentAccess(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			_, err := cache.GetPackageData(purl)
+			_, err := cache.GetPackageData(context.Background(), purl)
 			assert.NoError(t, err)
 		}()
 	}
@@ -320,13 +331,13 @@ func TestGetGlobalCache_SharesDataAcrossEnrichments(t *testing.T) {
 	require.NoError(t, err)
 
 	// First enrichment call
-	resp1, err := cache.GetPackageData(purl)
+	resp1, err := cache.GetPackageData(context.Background(), purl)
 	assert.NoError(t, err)
 	assert.Equal(t, 404, resp1.StatusCode())
 
 	// Simulate a second enrichment by getting the global cache again
 	cache2 := GetGlobalCache()
-	resp2, err := cache2.GetPackageData(purl)
+	resp2, err := cache2.GetPackageData(context.Background(), purl)
 	assert.NoError(t, err)
 	assert.Equal(t, 404, resp2.StatusCode())
 
@@ -334,3 +345,76 @@ func TestGetGlobalCache_SharesDataAcrossEnrichments(t *testing.T) {
 	callCount := httpmock.GetTotalCallCount()
 	assert.Equal(t, 1, callCount, "Expected only 1 API call across enrichments, but got %d. Global cache should be shared.", callCount)
 }
+
+func TestInMemoryCache_GetRepoData(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"GET",
+		"https://repos.ecosyste.ms/api/v1/repositories/lookup",
+		httpmock.NewStringResponder(200, `{"stargazers_count": 10}`),
+	)
+
+	cache := NewInMemoryCache()
+
+	resp1, err := cache.GetRepoData(context.Background(), "https://github.com/golang/go")
+	assert.NoError(t, err)
+	resp2, err := cache.GetRepoData(context.Background(), "https://github.com/golang/go")
+	assert.NoError(t, err)
+	assert.Equal(t, resp1, resp2)
+
+	_, err = cache.GetRepoData(context.Background(), "https://github.com/snyk/parlay")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestInMemoryCache_ConcurrentRequests(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	httpmock.RegisterResponder(
+		"GET",
+		`=~^https://repos.ecosyste.ms/api/v1/repositories/lookup`,
+		func(req *http.Request) (*http.Response, error) {
+			close(started)
+			<-release
+			return httpmock.NewStringResponse(200, `{"full_name": "example/repository"}`), nil
+		},
+	)
+
+	const url = "https://github.com/example/repository"
+	cache := NewInMemoryCache()
+	var wg sync.WaitGroup
+	lookup := func() {
+		defer wg.Done()
+		resp, err := cache.GetRepoData(context.Background(), url)
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+	}
+
+	wg.Add(1)
+	go lookup()
+	<-started
+	for i := 0; i < 9; i++ {
+		wg.Add(1)
+		go lookup()
+	}
+
+	// Only complete the request once every other lookup waits for it.
+	waiters := func() int {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		return cache.repoCache[url].waiters
+	}
+	for waiters() < 9 {
+		runtime.Gosched()
+	}
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
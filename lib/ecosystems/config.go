@@ -0,0 +1,80 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ecosystems
+
+import (
+	"time"
+
+	"github.com/snyk/parlay/internal/fields"
+	"github.com/snyk/parlay/internal/pool"
+	"github.com/snyk/parlay/lib/report"
+)
+
+// Config controls how SBOMs are enriched with ecosyste.ms data.
+type Config struct {
+	// MinDownloads, MinDependentPackages and MinDependentRepos are the
+	// adoption thresholds below which a package is flagged as low-adoption.
+	// A zero value disables the respective check.
+	MinDownloads         int
+	MinDependentPackages int
+	MinDependentRepos    int
+
+	// RepositoryMetadata resolves the repository of each package through
+	// repos.ecosyste.ms and records its metadata on the component.
+	RepositoryMetadata bool
+
+	// DependencyGraph reconstructs the dependency graph between the packages
+	// of the SBOM from the dependencies they declare on ecosyste.ms.
+	DependencyGraph bool
+
+	// InferPurls derives purls for packages which lack one from their CPEs,
+	// download locations and names, and keeps those ecosyste.ms confirms.
+	InferPurls bool
+
+	// DefaultEcosystem is the purl type assumed when inferring purls from a
+	// package name alone, e.g. "npm".
+	DefaultEcosystem string
+
+	// Concurrency is the number of packages enriched in parallel.
+	Concurrency int
+
+	// RequestTimeout bounds each request to ecosyste.ms. A zero value leaves
+	// requests bound only by the context enrichment runs under.
+	RequestTimeout time.Duration
+
+	// Merge decides how enriched values are combined with data already in
+	// the SBOM. FieldMerge overrides it for individual fields.
+	Merge      MergePolicy
+	FieldMerge map[string]MergePolicy
+
+	// Fields selects the fields to enrich. All fields are enriched if nil.
+	Fields *fields.Selection
+
+	// Provenance records the service, endpoint and fetch time each enriched
+	// field was taken from.
+	Provenance bool
+
+	// Report, if set, collects the outcome of enriching each package.
+	Report *report.Report
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Concurrency: pool.DefaultConcurrency,
+		Merge:       MergeKeep,
+	}
+}
@@ -0,0 +1,319 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ecosystems
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+	"github.com/rs/zerolog"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/spdx/v2/common"
+
+	"github.com/snyk/parlay/ecosystems/packages"
+	"github.com/snyk/parlay/internal/pool"
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/internal/version"
+)
+
+type dependencyKind int
+
+const (
+	dependencyRuntime dependencyKind = iota
+	dependencyOptional
+	dependencyDevelopment
+)
+
+type dependencyEdge struct {
+	from int
+	to   int
+	kind dependencyKind
+}
+
+var pypiNameSeparatorsRe = regexp.MustCompile(`[-_.]+`)
+
+// resolveDependencies matches the dependencies each package declares on
+// ecosyste.ms against the other packages in the SBOM, identified by their
+// index in purls. Dependencies on packages which are not part of the SBOM are
+// dropped; nil entries in purls are ignored. Packages are looked up in
+// parallel, and the edges are returned in the order of purls.
+func resolveDependencies(ctx context.Context, cfg *Config, cache Cache, purls []*packageurl.PackageURL, logger *zerolog.Logger) []dependencyEdge {
+	index := make(map[string][]int)
+	for i, purl := range purls {
+		if purl == nil {
+			continue
+		}
+		key := dependencyKey(purl.Type, purlToEcosystemsName(*purl))
+		index[key] = append(index[key], i)
+	}
+
+	var pending []int
+	for i, purl := range purls {
+		if purl != nil && isSupportedPurl(*purl) {
+			pending = append(pending, i)
+		}
+	}
+
+	var mu sync.Mutex
+	var edges []dependencyEdge
+	pool.Run(cfg.Concurrency, pending, func(i int) {
+		if ctx.Err() != nil {
+			return
+		}
+		purl := purls[i]
+
+		reqCtx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+		resp, err := cache.GetPackageVersionData(reqCtx, *purl)
+		cancel()
+		if err != nil || resp.JSON200 == nil {
+			logger.Debug().
+				Err(err).
+				Str("purl", purl.ToString()).
+				Msg("Skipping dependency resolution: no package version data")
+			return
+		}
+
+		var found []dependencyEdge
+		seen := make(map[int]int)
+		for _, dep := range resp.JSON200.Dependencies {
+			candidates := index[dependencyKey(purl.Type, dep.PackageName)]
+			to := pickDependency(purls, candidates, i, requirement(purl.Type, dep.Requirements))
+			if to < 0 {
+				continue
+			}
+
+			kind := classifyDependency(dep)
+			if j, ok := seen[to]; ok {
+				// Keep the strongest kind when a package is declared twice,
+				// e.g. as both a runtime and a development dependency.
+				if kind < found[j].kind {
+					found[j].kind = kind
+				}
+				continue
+			}
+			seen[to] = len(found)
+			found = append(found, dependencyEdge{from: i, to: to, kind: kind})
+		}
+
+		mu.Lock()
+		edges = append(edges, found...)
+		mu.Unlock()
+	})
+
+	// Each package's edges were appended together, so a stable sort keeps
+	// them in the order they were declared.
+	sort.SliceStable(edges, func(a, b int) bool {
+		return edges[a].from < edges[b].from
+	})
+	return edges
+}
+
+// requirement returns the version requirement of a dependency in the form
+// version.Satisfies understands. Cargo treats a bare version such as "1.2"
+// as the caret requirement "^1.2", where other ecosystems mean that exact
+// version.
+func requirement(purlType string, requirements *string) string {
+	if requirements == nil {
+		return ""
+	}
+	if purlType != packageurl.TypeCargo {
+		return *requirements
+	}
+
+	clauses := strings.Split(*requirements, ",")
+	for i, clause := range clauses {
+		clause = strings.TrimSpace(clause)
+		if clause != "" && clause[0] >= '0' && clause[0] <= '9' {
+			clause = "^" + clause
+		}
+		clauses[i] = clause
+	}
+	return strings.Join(clauses, ", ")
+}
+
+// pickDependency selects which of the candidate packages satisfies a declared
+// requirement, preferring the highest matching version. Candidates which
+// don't satisfy the requirement are never linked, so a dependency is left
+// out of the graph rather than pointed at the wrong version.
+func pickDependency(purls []*packageurl.PackageURL, candidates []int, self int, req string) int {
+	var others []int
+	for _, c := range candidates {
+		if c != self {
+			others = append(others, c)
+		}
+	}
+
+	best := -1
+	for _, c := range others {
+		if ok, err := version.Satisfies(purls[c].Version, req); err != nil || !ok {
+			continue
+		}
+		if best < 0 || version.Compare(purls[c].Version, purls[best].Version) > 0 {
+			best = c
+		}
+	}
+
+	return best
+}
+
+func classifyDependency(dep packages.Dependency) dependencyKind {
+	if dep.Kind != nil {
+		switch strings.ToLower(*dep.Kind) {
+		case "development", "dev", "test":
+			return dependencyDevelopment
+		case "optional":
+			return dependencyOptional
+		}
+	}
+	if dep.Optional != nil && *dep.Optional {
+		return dependencyOptional
+	}
+	return dependencyRuntime
+}
+
+func dependencyKey(purlType, name string) string {
+	name = strings.ToLower(name)
+	if purlType == packageurl.TypePyPi {
+		name = pypiNameSeparatorsRe.ReplaceAllString(name, "-")
+	}
+	return purlType + "/" + name
+}
+
+// enrichCDXDependencies adds the dependency graph reconstructed from
+// ecosyste.ms to the SBOM. Components which already have dependencies
+// recorded are left untouched.
+func enrichCDXDependencies(ctx context.Context, cfg *Config, bom *cdx.BOM, cache Cache, logger *zerolog.Logger) {
+	comps := utils.DiscoverCDXComponents(bom)
+
+	purls := make([]*packageurl.PackageURL, len(comps))
+	for i, comp := range comps {
+		if comp.BOMRef == "" {
+			continue
+		}
+		if purl, err := packageurl.FromString(comp.PackageURL); err == nil {
+			purls[i] = &purl
+		}
+	}
+
+	recorded := make(map[string]bool)
+	if bom.Dependencies != nil {
+		for _, dep := range *bom.Dependencies {
+			if dep.Dependencies != nil && len(*dep.Dependencies) > 0 {
+				recorded[dep.Ref] = true
+			}
+		}
+	}
+
+	var refs []string
+	dependsOn := make(map[string][]string)
+	for _, edge := range resolveDependencies(ctx, cfg, cache, purls, logger) {
+		from, to := comps[edge.from], comps[edge.to]
+		if recorded[from.BOMRef] {
+			continue
+		}
+
+		if _, ok := dependsOn[from.BOMRef]; !ok {
+			refs = append(refs, from.BOMRef)
+		}
+		dependsOn[from.BOMRef] = append(dependsOn[from.BOMRef], to.BOMRef)
+
+		switch edge.kind {
+		case dependencyDevelopment:
+			enrichProperty(from, "ecosystems:dev_dependency", to.BOMRef)
+		case dependencyOptional:
+			enrichProperty(from, "ecosystems:optional_dependency", to.BOMRef)
+		}
+	}
+
+	if len(refs) == 0 {
+		return
+	}
+
+	if bom.Dependencies == nil {
+		bom.Dependencies = &[]cdx.Dependency{}
+	}
+	for _, ref := range refs {
+		deps := dependsOn[ref]
+		found := false
+		for i := range *bom.Dependencies {
+			if (*bom.Dependencies)[i].Ref == ref {
+				(*bom.Dependencies)[i].Dependencies = &deps
+				found = true
+				break
+			}
+		}
+		if !found {
+			*bom.Dependencies = append(*bom.Dependencies, cdx.Dependency{Ref: ref, Dependencies: &deps})
+		}
+	}
+
+	logger.Debug().Msgf("Added dependencies for %d components", len(refs))
+}
+
+// enrichSPDXDependencies adds DEPENDS_ON, DEV_DEPENDENCY_OF and
+// OPTIONAL_DEPENDENCY_OF relationships reconstructed from ecosyste.ms to the
+// document. Packages which already have dependency relationships are left
+// untouched.
+func enrichSPDXDependencies(ctx context.Context, cfg *Config, bom *spdx.Document, cache Cache, logger *zerolog.Logger) {
+	purls := make([]*packageurl.PackageURL, len(bom.Packages))
+	for i, pkg := range bom.Packages {
+		if purl, err := extractPurl(pkg); err == nil {
+			purls[i] = purl
+		}
+	}
+
+	recorded := make(map[common.ElementID]bool)
+	for _, rel := range bom.Relationships {
+		switch rel.Relationship {
+		case common.TypeRelationshipDependsOn:
+			recorded[rel.RefA.ElementRefID] = true
+		case common.TypeRelationshipDependencyOf,
+			common.TypeRelationshipDevDependencyOf,
+			common.TypeRelationshipOptionalDependencyOf:
+			recorded[rel.RefB.ElementRefID] = true
+		}
+	}
+
+	added := 0
+	for _, edge := range resolveDependencies(ctx, cfg, cache, purls, logger) {
+		from, to := bom.Packages[edge.from], bom.Packages[edge.to]
+		if recorded[from.PackageSPDXIdentifier] {
+			continue
+		}
+
+		fromID := common.MakeDocElementID("", string(from.PackageSPDXIdentifier))
+		toID := common.MakeDocElementID("", string(to.PackageSPDXIdentifier))
+
+		rel := &spdx.Relationship{RefA: fromID, RefB: toID, Relationship: common.TypeRelationshipDependsOn}
+		switch edge.kind {
+		case dependencyDevelopment:
+			rel = &spdx.Relationship{RefA: toID, RefB: fromID, Relationship: common.TypeRelationshipDevDependencyOf}
+		case dependencyOptional:
+			rel = &spdx.Relationship{RefA: toID, RefB: fromID, Relationship: common.TypeRelationshipOptionalDependencyOf}
+		}
+
+		bom.Relationships = append(bom.Relationships, rel)
+		added++
+	}
+
+	logger.Debug().Msgf("Added %d dependency relationships", added)
+}
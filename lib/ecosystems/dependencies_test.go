@@ -0,0 +1,220 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ecosystems
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/jarcoal/httpmock"
+	"github.com/rs/zerolog"
+	"github.com/spdx/tools-golang/spdx/v2/common"
+	"github.com/spdx/tools-golang/spdx/v2/v2_3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+func setupDependenciesMock(t *testing.T) {
+	t.Helper()
+	ResetGlobalCache()
+	httpmock.Activate()
+
+	versions := map[string]interface{}{
+		"app/versions/1.0.0": map[string]interface{}{
+			"dependencies": []map[string]interface{}{
+				{"package_name": "lodash", "requirements": "^4.17.0", "kind": "runtime"},
+				{"package_name": "jest", "requirements": "^29.0.0", "kind": "Development"},
+				{"package_name": "fsevents", "requirements": "^2.3.0", "kind": "runtime", "optional": true},
+				{"package_name": "not-in-sbom", "requirements": "^1.0.0", "kind": "runtime"},
+			},
+		},
+		"jest/versions/29.0.0": map[string]interface{}{
+			"dependencies": []map[string]interface{}{
+				{"package_name": "lodash", "requirements": "~3.10.0", "kind": "runtime"},
+			},
+		},
+	}
+
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries/npmjs.org/packages/.*/versions/`,
+		func(req *http.Request) (*http.Response, error) {
+			for path, body := range versions {
+				if strings.HasSuffix(req.URL.Path, "/packages/"+path) {
+					return httpmock.NewJsonResponse(200, body)
+				}
+			}
+			return httpmock.NewJsonResponse(200, map[string]interface{}{})
+		})
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{}))
+}
+
+func TestEnrichSBOM_CycloneDX_DependencyGraph(t *testing.T) {
+	setupDependenciesMock(t)
+	defer httpmock.DeactivateAndReset()
+
+	bom := &cdx.BOM{
+		Metadata: &cdx.Metadata{
+			Component: &cdx.Component{BOMRef: "app", PackageURL: "pkg:npm/app@1.0.0"},
+		},
+		Components: &[]cdx.Component{
+			{BOMRef: "lodash@4", PackageURL: "pkg:npm/lodash@4.17.21"},
+			{BOMRef: "lodash@3", PackageURL: "pkg:npm/lodash@3.10.1"},
+			{BOMRef: "jest", PackageURL: "pkg:npm/jest@29.0.0"},
+			{BOMRef: "fsevents", PackageURL: "pkg:npm/fsevents@2.3.2"},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+	cfg := DefaultConfig()
+	cfg.DependencyGraph = true
+
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	require.NotNil(t, bom.Dependencies)
+	assert.Equal(t, []cdx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"lodash@4", "jest", "fsevents"}},
+		{Ref: "jest", Dependencies: &[]string{"lodash@3"}},
+	}, *bom.Dependencies)
+
+	assert.Subset(t, *bom.Metadata.Component.Properties, []cdx.Property{
+		{Name: "ecosystems:dev_dependency", Value: "jest"},
+		{Name: "ecosystems:optional_dependency", Value: "fsevents"},
+	})
+}
+
+func TestEnrichSBOM_CycloneDX_DependencyGraphKeepsExisting(t *testing.T) {
+	setupDependenciesMock(t)
+	defer httpmock.DeactivateAndReset()
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "app", PackageURL: "pkg:npm/app@1.0.0"},
+			{BOMRef: "lodash@4", PackageURL: "pkg:npm/lodash@4.17.21"},
+			{BOMRef: "jest", PackageURL: "pkg:npm/jest@29.0.0"},
+		},
+		Dependencies: &[]cdx.Dependency{
+			{Ref: "app", Dependencies: &[]string{"lodash@4"}},
+			{Ref: "jest"},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+	cfg := DefaultConfig()
+	cfg.DependencyGraph = true
+
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	// jest requires lodash ~3.10.0, which the only lodash doesn't satisfy.
+	assert.Equal(t, []cdx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"lodash@4"}},
+		{Ref: "jest"},
+	}, *bom.Dependencies)
+}
+
+func TestEnrichSBOM_SPDX_DependencyGraph(t *testing.T) {
+	setupDependenciesMock(t)
+	defer httpmock.DeactivateAndReset()
+
+	doc, err := sbom.DecodeSBOMDocument([]byte(`{"spdxVersion":"SPDX-2.3","SPDXID":"SPDXRef-DOCUMENT"}`))
+	require.NoError(t, err)
+	bom, ok := doc.BOM.(*v2_3.Document)
+	require.True(t, ok)
+
+	pkg := func(id, purl string) *v2_3.Package {
+		return &v2_3.Package{
+			PackageSPDXIdentifier: common.ElementID(id),
+			PackageExternalReferences: []*v2_3.PackageExternalReference{
+				{Category: common.CategoryPackageManager, RefType: "purl", Locator: purl},
+			},
+		}
+	}
+	bom.Packages = []*v2_3.Package{
+		pkg("app", "pkg:npm/app@1.0.0"),
+		pkg("lodash", "pkg:npm/lodash@4.17.21"),
+		pkg("jest", "pkg:npm/jest@29.0.0"),
+	}
+	logger := zerolog.Nop()
+	cfg := DefaultConfig()
+	cfg.DependencyGraph = true
+
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	id := func(s string) common.DocElementID { return common.MakeDocElementID("", s) }
+	assert.Equal(t, []*v2_3.Relationship{
+		{RefA: id("app"), RefB: id("lodash"), Relationship: "DEPENDS_ON"},
+		{RefA: id("jest"), RefB: id("app"), Relationship: "DEV_DEPENDENCY_OF"},
+	}, bom.Relationships)
+}
+
+func TestRequirement(t *testing.T) {
+	tests := []struct {
+		purlType     string
+		requirements string
+		expected     string
+	}{
+		{"cargo", "1.2", "^1.2"},
+		{"cargo", ">=1.0, 1.4", ">=1.0, ^1.4"},
+		{"cargo", "=1.2.3", "=1.2.3"},
+		{"cargo", "~1.2", "~1.2"},
+		{"npm", "1.2", "1.2"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, requirement(tt.purlType, &tt.requirements), tt.requirements)
+	}
+	assert.Empty(t, requirement("cargo", nil))
+}
+
+func TestEnrichSBOM_CycloneDX_DependencyGraphCargo(t *testing.T) {
+	ResetGlobalCache()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://packages.ecosyste.ms/api/v1/registries/crates.io/packages/app/versions/1.0.0",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"dependencies": []map[string]interface{}{
+				{"package_name": "serde", "requirements": "1.0", "kind": "normal"},
+			},
+		}))
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries/crates.io/packages/serde/versions/`,
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{}))
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries/crates.io/packages/(app|serde)$`,
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{}))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "app", PackageURL: "pkg:cargo/app@1.0.0"},
+			{BOMRef: "serde", PackageURL: "pkg:cargo/serde@1.0.188"},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+	cfg := DefaultConfig()
+	cfg.DependencyGraph = true
+
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	require.NotNil(t, bom.Dependencies)
+	assert.Equal(t, []cdx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"serde"}},
+	}, *bom.Dependencies, "serde 1.0.188 satisfies the bare requirement 1.0")
+}
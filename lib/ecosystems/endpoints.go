@@ -0,0 +1,91 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package ecosystems
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/snyk/parlay/ecosystems/packages"
+	"github.com/snyk/parlay/ecosystems/repos"
+	"github.com/snyk/parlay/internal/httpclient"
+)
+
+// Endpoints are the ecosyste.ms services packages and repositories are looked
+// up on. They can point to self-hosted mirrors.
+type Endpoints struct {
+	// PackagesURL is the base URL of the packages API.
+	PackagesURL string
+
+	// ReposURL is the base URL of the repositories API.
+	ReposURL string
+
+	// Header is added to every request, e.g. to authenticate with a private
+	// mirror.
+	Header http.Header
+}
+
+func DefaultEndpoints() *Endpoints {
+	return &Endpoints{
+		PackagesURL: "https://packages.ecosyste.ms/api/v1",
+		ReposURL:    "https://repos.ecosyste.ms/api/v1",
+	}
+}
+
+var (
+	endpointsMu sync.RWMutex
+	endpoints   = DefaultEndpoints()
+)
+
+// SetEndpoints changes the services all lookups are sent to. Passing nil
+// restores DefaultEndpoints.
+func SetEndpoints(e *Endpoints) {
+	if e == nil {
+		e = DefaultEndpoints()
+	}
+	endpointsMu.Lock()
+	defer endpointsMu.Unlock()
+	endpoints = e
+}
+
+func currentEndpoints() *Endpoints {
+	endpointsMu.RLock()
+	defer endpointsMu.RUnlock()
+	return endpoints
+}
+
+func newPackagesClient() (*packages.ClientWithResponses, error) {
+	e := currentEndpoints()
+	return packages.NewClientWithResponses(e.PackagesURL,
+		packages.WithHTTPClient(httpclient.Default()),
+		packages.WithRequestEditorFn(func(ctx context.Context, req *http.Request) error {
+			req.Header.Set("User-Agent", getUserAgent())
+			httpclient.SetHeaders(req, e.Header)
+			return nil
+		}))
+}
+
+func newReposClient() (*repos.ClientWithResponses, error) {
+	e := currentEndpoints()
+	return repos.NewClientWithResponses(e.ReposURL,
+		repos.WithHTTPClient(httpclient.Default()),
+		repos.WithRequestEditorFn(func(ctx context.Context, req *http.Request) error {
+			req.Header.Set("User-Agent", getUserAgent())
+			httpclient.SetHeaders(req, e.Header)
+			return nil
+		}))
+}
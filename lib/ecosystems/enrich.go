@@ -17,19 +17,113 @@
 package ecosystems
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
 	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
 	"github.com/rs/zerolog"
 	"github.com/spdx/tools-golang/spdx"
 
+	"github.com/snyk/parlay/ecosystems/packages"
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/report"
 	"github.com/snyk/parlay/lib/sbom"
 )
 
-func EnrichSBOM(doc *sbom.SBOMDocument, logger *zerolog.Logger) *sbom.SBOMDocument {
+const (
+	// reportProvider names ecosyste.ms in enrichment reports.
+	reportProvider = "ecosystems"
+
+	packagesSource = "packages.ecosyste.ms"
+	reposSource    = "repos.ecosyste.ms"
+)
+
+var errNoData = errors.New("no data on ecosyste.ms response")
+
+// EnrichSBOM enriches the packages of the SBOM with ecosyste.ms data. When ctx
+// is done, packages which weren't enriched yet are left as they are.
+func EnrichSBOM(ctx context.Context, cfg *Config, doc *sbom.SBOMDocument, logger *zerolog.Logger) *sbom.SBOMDocument {
 	switch bom := doc.BOM.(type) {
 	case *cdx.BOM:
-		enrichCDX(bom, logger)
+		enrichCDX(ctx, cfg, bom, logger)
 	case *spdx.Document:
-		enrichSPDX(bom, logger)
+		enrichSPDX(ctx, cfg, bom, logger)
 	}
 	return doc
 }
+
+// unsupportedTypes counts the packages skipped because ecosyste.ms has no
+// registry for their purl type.
+type unsupportedTypes struct {
+	counts map[string]int
+	mu     sync.Mutex
+}
+
+func (u *unsupportedTypes) add(purlType string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.counts == nil {
+		u.counts = make(map[string]int)
+	}
+	u.counts[purlType]++
+}
+
+func (u *unsupportedTypes) log(logger *zerolog.Logger) {
+	if len(u.counts) == 0 {
+		return
+	}
+
+	types := make([]string, 0, len(u.counts))
+	total := 0
+	for t, n := range u.counts {
+		types = append(types, fmt.Sprintf("%s (%d)", t, n))
+		total += n
+	}
+	sort.Strings(types)
+
+	logger.Debug().
+		Str("types", strings.Join(types, ", ")).
+		Msgf("Skipped %d packages with unsupported purl types", total)
+}
+
+func lookupPackage(ctx context.Context, cfg *Config, cache Cache, purl packageurl.PackageURL, out *report.Outcome) (*packages.Package, *provenance, error) {
+	ctx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+	defer cancel()
+
+	resp, err := cache.GetPackageData(ctx, purl)
+	if err != nil {
+		return nil, nil, err
+	}
+	out.Response(resp.HTTPResponse)
+	if resp.JSON200 == nil {
+		return nil, nil, errNoData
+	}
+	return resp.JSON200, responseProvenance(packagesSource, resp.HTTPResponse), nil
+}
+
+func lookupPackageVersion(ctx context.Context, cfg *Config, cache Cache, purl packageurl.PackageURL, out *report.Outcome) (*packages.VersionWithDependencies, *provenance, error) {
+	ctx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+	defer cancel()
+
+	resp, err := cache.GetPackageVersionData(ctx, purl)
+	if err != nil {
+		return nil, nil, err
+	}
+	out.Response(resp.HTTPResponse)
+	if resp.JSON200 == nil {
+		return nil, nil, errNoData
+	}
+	return resp.JSON200, responseProvenance(packagesSource, resp.HTTPResponse), nil
+}
+
+// needsPackageFallback reports whether the selected version fields need the
+// package data to fall back to, which is the case for licenses missing from
+// the version data.
+func needsPackageFallback(cfg *Config, versionData *packages.VersionWithDependencies) bool {
+	return cfg.Fields.Selected("license") && len(utils.GetLicensesFromEcosystemsLicense(versionData, nil)) == 0
+}
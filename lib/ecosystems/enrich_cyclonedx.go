@@ -17,16 +17,18 @@
 package ecosystems
 
 import (
+	"context"
+	"fmt"
 	"net/url"
 	"strings"
-	"time"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/package-url/packageurl-go"
-	"github.com/remeh/sizedwaitgroup"
 	"github.com/rs/zerolog"
 
 	"github.com/snyk/parlay/ecosystems/packages"
+	"github.com/snyk/parlay/ecosystems/repos"
+	"github.com/snyk/parlay/internal/pool"
 	"github.com/snyk/parlay/internal/utils"
 )
 
@@ -35,25 +37,6 @@ type (
 	cdxPackageVersionEnricher = func(*cdx.Component, *packages.VersionWithDependencies, *packages.Package)
 )
 
-var cdxPackageEnrichers = []cdxPackageEnricher{
-	enrichCDXDescription,
-	enrichCDXHomepage,
-	enrichCDXRegistryURL,
-	enrichCDXRepositoryURL,
-	enrichCDXDocumentationURL,
-	enrichCDXFirstReleasePublishedAt,
-	enrichCDXLatestReleasePublishedAt,
-	enrichCDXRepoArchived,
-	enrichCDXLocation,
-	enrichCDXTopics,
-	enrichCDXAuthor,
-	enrichCDXSupplier,
-}
-
-var cdxPackageVersionEnrichers = []cdxPackageVersionEnricher{
-	enrichCDXLicense,
-}
-
 func enrichCDXDescription(comp *cdx.Component, data *packages.Package) {
 	if data.Description != nil {
 		comp.Description = *data.Description
@@ -75,27 +58,17 @@ func enrichExternalReference(comp *cdx.Component, ref *string, refType cdx.Exter
 	if _, err := url.Parse(*ref); err != nil {
 		return
 	}
-	ext := cdx.ExternalReference{
+	utils.AddCDXExternalReference(comp, cdx.ExternalReference{
 		URL:  *ref,
 		Type: refType,
-	}
-	if comp.ExternalReferences == nil {
-		comp.ExternalReferences = &[]cdx.ExternalReference{ext}
-	} else {
-		*comp.ExternalReferences = append(*comp.ExternalReferences, ext)
-	}
+	})
 }
 
 func enrichProperty(comp *cdx.Component, name string, value string) {
-	prop := cdx.Property{
+	utils.AddCDXProperty(comp, cdx.Property{
 		Name:  name,
 		Value: value,
-	}
-	if comp.Properties == nil {
-		comp.Properties = &[]cdx.Property{prop}
-	} else {
-		*comp.Properties = append(*comp.Properties, prop)
-	}
+	})
 }
 
 func enrichCDXHomepage(comp *cdx.Component, data *packages.Package) {
@@ -115,146 +88,189 @@ func enrichCDXDocumentationURL(comp *cdx.Component, data *packages.Package) {
 }
 
 func enrichCDXFirstReleasePublishedAt(comp *cdx.Component, data *packages.Package) {
-	if data.FirstReleasePublishedAt == nil {
-		return
+	if timestamp := formatTimestamp(data.FirstReleasePublishedAt); timestamp != "" {
+		enrichProperty(comp, "ecosystems:first_release_published_at", timestamp)
 	}
-	timestamp := data.FirstReleasePublishedAt.UTC().Format(time.RFC3339)
-	enrichProperty(comp, "ecosystems:first_release_published_at", timestamp)
 }
 
 func enrichCDXLatestReleasePublishedAt(comp *cdx.Component, data *packages.Package) {
-	if data.LatestReleasePublishedAt == nil {
-		return
+	if timestamp := formatTimestamp(data.LatestReleasePublishedAt); timestamp != "" {
+		enrichProperty(comp, "ecosystems:latest_release_published_at", timestamp)
+	}
+}
+
+func enrichCDXReleaseDate(comp *cdx.Component, pkgVersionData *packages.VersionWithDependencies, _ *packages.Package) {
+	if timestamp := versionPublishedAt(pkgVersionData); timestamp != "" {
+		enrichProperty(comp, "ecosystems:published_at", timestamp)
 	}
-	timestamp := data.LatestReleasePublishedAt.UTC().Format(time.RFC3339)
-	enrichProperty(comp, "ecosystems:latest_release_published_at", timestamp)
 }
 
 func enrichCDXRepoArchived(comp *cdx.Component, data *packages.Package) {
-	if data.RepoMetadata != nil {
-		if archived, ok := (*data.RepoMetadata)["archived"].(bool); ok && archived {
-			enrichProperty(comp, "ecosystems:repository_archived", "true")
-		}
+	if repositoryArchived(data) {
+		enrichProperty(comp, "ecosystems:repository_archived", "true")
 	}
 }
 
 func enrichCDXLocation(comp *cdx.Component, data *packages.Package) {
-	if data.RepoMetadata != nil {
-		meta := *data.RepoMetadata
-		if ownerRecord, ok := meta["owner_record"].(map[string]interface{}); ok {
-			if location, ok := ownerRecord["location"].(string); ok {
-				enrichProperty(comp, "ecosystems:owner_location", location)
-			}
-		}
+	if location := ownerLocation(data); location != "" {
+		enrichProperty(comp, "ecosystems:owner_location", location)
 	}
 }
 
 func enrichCDXAuthor(comp *cdx.Component, data *packages.Package) {
-	if data.RepoMetadata != nil {
-		meta := *data.RepoMetadata
-		if ownerRecord, ok := meta["owner_record"].(map[string]interface{}); ok {
-			if name, ok := ownerRecord["name"].(string); ok {
-				comp.Author = name
-			}
-		}
+	if name := ownerName(data); name != "" {
+		comp.Author = name
 	}
 }
 
 func enrichCDXSupplier(comp *cdx.Component, data *packages.Package) {
-	if data.RepoMetadata != nil {
-		meta := *data.RepoMetadata
-		if ownerRecord, ok := meta["owner_record"].(map[string]interface{}); ok {
-			if name, ok := ownerRecord["name"].(string); ok {
-				supplier := cdx.OrganizationalEntity{
-					Name: name,
-				}
-				if website, ok := ownerRecord["website"].(string); ok {
-					split := strings.Split(website, ", ")
-					for i := range split {
-						split[i] = strings.TrimSpace(split[i])
-					}
-					supplier.URL = &split
-				}
-				comp.Supplier = &supplier
-			}
-		}
+	name := ownerName(data)
+	if name == "" {
+		return
 	}
+	supplier := cdx.OrganizationalEntity{
+		Name: name,
+	}
+	if websites := ownerWebsites(data); websites != nil {
+		supplier.URL = &websites
+	}
+	comp.Supplier = &supplier
 }
 
 func enrichCDXTopics(comp *cdx.Component, data *packages.Package) {
-	if data.RepoMetadata != nil {
-		meta := *data.RepoMetadata
+	for _, topic := range repositoryTopics(data) {
+		enrichProperty(comp, "ecosystems:topic", topic)
+	}
+}
 
-		if topics, ok := meta["topics"].([]interface{}); ok {
-			for _, topic := range topics {
-				if s, ok := topic.(string); ok {
-					enrichProperty(comp, "ecosystems:topic", s)
-				}
-			}
-		}
+func enrichCDXPopularity(comp *cdx.Component, data *packages.Package) {
+	for _, prop := range popularityProperties(data) {
+		enrichProperty(comp, prop.name, prop.value)
+	}
+}
+
+func enrichCDXLowAdoption(cfg *Config, comp *cdx.Component, data *packages.Package) {
+	if reasons := lowAdoptionReasons(cfg, data); len(reasons) > 0 {
+		enrichProperty(comp, "ecosystems:low_adoption", strings.Join(reasons, ","))
+	}
+}
+
+func enrichCDXRepository(comp *cdx.Component, repo *repos.Repository) {
+	for _, prop := range repositoryProperties(repo) {
+		enrichProperty(comp, prop.name, prop.value)
 	}
 }
 
-func enrichCDX(bom *cdx.BOM, logger *zerolog.Logger) {
-	wg := sizedwaitgroup.New(20)
+func enrichCDX(ctx context.Context, cfg *Config, bom *cdx.BOM, logger *zerolog.Logger) {
 	cache := GetGlobalCache()
 
 	comps := utils.DiscoverCDXComponents(bom)
 	logger.Debug().Msgf("Detected %d packages", len(comps))
 
-	for i := range comps {
-		wg.Add()
-		go func(comp *cdx.Component) {
-			defer wg.Done()
-			l := logger.With().Str("bom-ref", comp.BOMRef).Logger()
+	var unsupported unsupportedTypes
 
-			purl, err := packageurl.FromString(comp.PackageURL)
-			if err != nil {
-				l.Debug().
-					Err(err).
-					Msg("Skipping package: no usable PackageURL")
-				return
+	pool.Run(cfg.Concurrency, comps, func(comp *cdx.Component) {
+		l := logger.With().Str("bom-ref", comp.BOMRef).Logger()
+		id := utils.CDXComponentID(comp)
+		out := cfg.Report.Outcome(reportProvider, id, comp.PackageURL)
+		if err := ctx.Err(); err != nil {
+			out.Skip("enrichment interrupted: " + err.Error())
+			return
+		}
+
+		purl, err := packageurl.FromString(comp.PackageURL)
+		if err != nil && cfg.InferPurls {
+			var inferred *packageurl.PackageURL
+			if inferred, err = inferCDXPurl(ctx, cfg, cache, comp); err == nil {
+				purl = *inferred
+				l.Debug().Str("purl", comp.PackageURL).Msg("Inferred PackageURL")
 			}
+		}
+		if err != nil {
+			l.Debug().
+				Err(err).
+				Msg("Skipping package: no usable PackageURL")
+			out.Skip("no usable PackageURL")
+			return
+		}
+		out = cfg.Report.Outcome(reportProvider, id, purl.String())
 
-			packageResp, err := cache.GetPackageData(purl)
+		if !isSupportedPurl(purl) {
+			unsupported.add(purl.Type)
+			out.Skip(fmt.Sprintf("unsupported purl type %q", purl.Type))
+			return
+		}
+
+		var pkgData *packages.Package
+		var pkgSource *provenance
+		if cfg.Fields.Any(packageFieldNames()...) {
+			pkgData, pkgSource, err = lookupPackage(ctx, cfg, cache, purl, out)
 			if err != nil {
 				l.Debug().
 					Err(err).
 					Msg("Skipping package: failed to get package data")
+				out.Fail("failed to get package data: " + err.Error())
 				return
 			}
 
-			if packageResp.JSON200 == nil {
-				l.Debug().
-					Err(err).
-					Msg("Skipping package: no data on ecosyste.ms response")
-				return
+			for _, field := range packageFields {
+				if cfg.Fields.Selected(field.name) {
+					mergeCDXField(cfg, field.name, comp, pkgSource, out, func(c *cdx.Component) {
+						field.cdx(c, pkgData)
+					})
+				}
 			}
-
-			for _, enrichFunc := range cdxPackageEnrichers {
-				enrichFunc(comp, packageResp.JSON200)
+			if cfg.Fields.Selected("low_adoption") {
+				mergeCDXField(cfg, "low_adoption", comp, pkgSource, out, func(c *cdx.Component) {
+					enrichCDXLowAdoption(cfg, c, pkgData)
+				})
 			}
 
-			packageVersionResp, err := cache.GetPackageVersionData(purl)
-			if err != nil {
-				l.Debug().
-					Err(err).
-					Msg("Skipping package version enrichment: failed to get package version data")
-				return
+			if cfg.RepositoryMetadata && cfg.Fields.Selected("repository") {
+				repo, repoSource, err := lookupRepository(ctx, cfg, cache, pkgData, out)
+				if err != nil {
+					l.Debug().
+						Err(err).
+						Msg("Skipping repository enrichment: failed to get repository data")
+					out.Fail("failed to get repository data: " + err.Error())
+				} else if repo != nil {
+					mergeCDXField(cfg, "repository", comp, repoSource, out, func(c *cdx.Component) {
+						enrichCDXRepository(c, repo)
+					})
+				}
 			}
+		}
 
-			if packageVersionResp.JSON200 == nil {
-				l.Debug().
-					Err(err).
-					Msg("Skipping package version enrichment: no data on ecosyste.ms response")
-				return
-			}
+		if !cfg.Fields.Any(packageVersionFieldNames()...) {
+			return
+		}
 
-			for _, enrichFunc := range cdxPackageVersionEnrichers {
-				enrichFunc(comp, packageVersionResp.JSON200, packageResp.JSON200)
+		versionData, versionSource, err := lookupPackageVersion(ctx, cfg, cache, purl, out)
+		if err != nil {
+			l.Debug().
+				Err(err).
+				Msg("Skipping package version enrichment: failed to get package version data")
+			out.Fail("failed to get package version data: " + err.Error())
+			return
+		}
+
+		if pkgData == nil && needsPackageFallback(cfg, versionData) {
+			// Failing to get the package data only loses the fallback.
+			pkgData, _, _ = lookupPackage(ctx, cfg, cache, purl, out)
+		}
+
+		for _, field := range packageVersionFields {
+			if cfg.Fields.Selected(field.name) {
+				mergeCDXField(cfg, field.name, comp, versionSource, out, func(c *cdx.Component) {
+					field.cdx(c, versionData, pkgData)
+				})
 			}
-		}(comps[i])
-	}
+		}
+	})
 
-	wg.Wait()
+	unsupported.log(logger)
+
+	if cfg.DependencyGraph && ctx.Err() == nil {
+		enrichCDXDependencies(ctx, cfg, bom, cache, logger)
+	}
 }
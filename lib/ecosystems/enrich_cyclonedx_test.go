@@ -17,6 +17,9 @@
 package ecosystems
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"net/http"
 	"testing"
 	"time"
@@ -25,8 +28,10 @@ import (
 	"github.com/jarcoal/httpmock"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/snyk/parlay/ecosystems/packages"
+	"github.com/snyk/parlay/lib/report"
 	"github.com/snyk/parlay/lib/sbom"
 )
 
@@ -76,7 +81,7 @@ func TestEnrichSBOM_CycloneDX(t *testing.T) {
 	doc := &sbom.SBOMDocument{BOM: bom}
 	logger := zerolog.Nop()
 
-	EnrichSBOM(doc, &logger)
+	EnrichSBOM(context.Background(), DefaultConfig(), doc, &logger)
 
 	components := *bom.Components
 	component := components[0]
@@ -124,7 +129,7 @@ func TestEnrichSBOM_CycloneDX_NestedComps(t *testing.T) {
 	doc := &sbom.SBOMDocument{BOM: bom}
 	logger := zerolog.Nop()
 
-	EnrichSBOM(doc, &logger)
+	EnrichSBOM(context.Background(), DefaultConfig(), doc, &logger)
 
 	httpmock.GetTotalCallCount()
 	calls := httpmock.GetCallCountInfo()
@@ -158,7 +163,7 @@ func TestEnrichSBOMWithoutLicense(t *testing.T) {
 	doc := &sbom.SBOMDocument{BOM: bom}
 	logger := zerolog.Nop()
 
-	EnrichSBOM(doc, &logger)
+	EnrichSBOM(context.Background(), DefaultConfig(), doc, &logger)
 
 	components := *bom.Components
 
@@ -247,7 +252,7 @@ func TestEnrichBlankSBOM(t *testing.T) {
 	doc := &sbom.SBOMDocument{BOM: bom}
 	logger := zerolog.Nop()
 
-	EnrichSBOM(doc, &logger)
+	EnrichSBOM(context.Background(), DefaultConfig(), doc, &logger)
 
 	assert.Nil(t, bom.Components)
 }
@@ -381,3 +386,420 @@ func TestEnrichLocation(t *testing.T) {
 		{Name: "ecosystems:owner_location", Value: "test_location"},
 	}, component.Properties)
 }
+
+func TestPackageRepository(t *testing.T) {
+	data := &packages.Package{RepoMetadata: &map[string]interface{}{
+		"archived": true,
+		"topics":   []interface{}{"http", "client"},
+		"owner_record": map[string]interface{}{
+			"name":     "Example",
+			"location": "Berlin",
+			"website":  "https://example.com, https://example.org",
+		},
+	}}
+
+	assert.True(t, repositoryArchived(data))
+	assert.Equal(t, []string{"http", "client"}, repositoryTopics(data))
+	assert.Equal(t, "Example", ownerName(data))
+	assert.Equal(t, "Berlin", ownerLocation(data))
+	assert.Equal(t, []string{"https://example.com", "https://example.org"}, ownerWebsites(data))
+
+	data = &packages.Package{RepoMetadata: &map[string]interface{}{
+		"topics": "http",
+	}}
+	assert.Nil(t, repositoryTopics(data), "ignores metadata of the wrong type")
+}
+
+func TestEnrichPopularity(t *testing.T) {
+	component := &cdx.Component{}
+	packageData := &packages.Package{
+		Downloads:              1234,
+		DownloadsPeriod:        pointerToString(t, "last-month"),
+		DependentPackagesCount: 5,
+		DependentReposCount:    42,
+		Rankings: map[string]interface{}{
+			"downloads": 1.5,
+			"average":   3.25,
+		},
+		Critical: true,
+	}
+
+	enrichCDXPopularity(component, packageData)
+
+	assert.Equal(t, &[]cdx.Property{
+		{Name: "ecosystems:downloads", Value: "1234"},
+		{Name: "ecosystems:downloads_period", Value: "last-month"},
+		{Name: "ecosystems:dependent_packages_count", Value: "5"},
+		{Name: "ecosystems:dependent_repos_count", Value: "42"},
+		{Name: "ecosystems:ranking:average", Value: "3.25"},
+		{Name: "ecosystems:ranking:downloads", Value: "1.5"},
+		{Name: "ecosystems:critical", Value: "true"},
+	}, component.Properties)
+}
+
+func TestEnrichPopularityWithoutDownloadStats(t *testing.T) {
+	component := &cdx.Component{}
+	packageData := &packages.Package{}
+
+	enrichCDXPopularity(component, packageData)
+
+	assert.Equal(t, &[]cdx.Property{
+		{Name: "ecosystems:dependent_packages_count", Value: "0"},
+		{Name: "ecosystems:dependent_repos_count", Value: "0"},
+	}, component.Properties)
+}
+
+func TestEnrichLowAdoption(t *testing.T) {
+	packageData := &packages.Package{
+		Downloads:              10,
+		DownloadsPeriod:        pointerToString(t, "last-month"),
+		DependentPackagesCount: 0,
+		DependentReposCount:    100,
+	}
+
+	component := &cdx.Component{}
+	enrichCDXLowAdoption(DefaultConfig(), component, packageData)
+	assert.Nil(t, component.Properties)
+
+	cfg := &Config{MinDownloads: 1000, MinDependentPackages: 1, MinDependentRepos: 10}
+	enrichCDXLowAdoption(cfg, component, packageData)
+	assert.Equal(t, &[]cdx.Property{
+		{Name: "ecosystems:low_adoption", Value: "downloads,dependent_packages_count"},
+	}, component.Properties)
+}
+
+func TestEnrichLowAdoptionIgnoresMissingDownloadStats(t *testing.T) {
+	component := &cdx.Component{}
+	packageData := &packages.Package{DependentReposCount: 100}
+
+	enrichCDXLowAdoption(&Config{MinDownloads: 1000}, component, packageData)
+
+	assert.Nil(t, component.Properties)
+}
+
+func TestEnrichSBOM_CycloneDX_RepositoryMetadata(t *testing.T) {
+	ResetGlobalCache()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries/.*/packages/.*/versions`,
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{}))
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"repository_url": "https://github.com/CycloneDX/cyclonedx-go",
+		}))
+	httpmock.RegisterResponder("GET", "https://repos.ecosyste.ms/api/v1/repositories/lookup",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"stargazers_count":  100,
+			"forks_count":       20,
+			"open_issues_count": 3,
+			"pushed_at":         "2024-01-02T03:04:05Z",
+			"default_branch":    "main",
+			"license":           "apache-2.0",
+			"fork":              false,
+			"template":          true,
+			"latest_tag_name":   "v0.9.0",
+		}))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{
+				BOMRef:     "pkg:golang/github.com/CycloneDX/cyclonedx-go@v0.3.0",
+				PackageURL: "pkg:golang/github.com/CycloneDX/cyclonedx-go@v0.3.0",
+			},
+			{
+				BOMRef:     "pkg:golang/github.com/CycloneDX/cyclonedx-go@v0.4.0",
+				PackageURL: "pkg:golang/github.com/CycloneDX/cyclonedx-go@v0.4.0",
+			},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+	cfg := DefaultConfig()
+	cfg.RepositoryMetadata = true
+
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	for _, comp := range *bom.Components {
+		props := make(map[string]string)
+		for _, p := range *comp.Properties {
+			props[p.Name] = p.Value
+		}
+		assert.Equal(t, "100", props["ecosystems:repository_stars"])
+		assert.Equal(t, "20", props["ecosystems:repository_forks"])
+		assert.Equal(t, "3", props["ecosystems:repository_open_issues"])
+		assert.Equal(t, "2024-01-02T03:04:05Z", props["ecosystems:repository_pushed_at"])
+		assert.Equal(t, "main", props["ecosystems:repository_default_branch"])
+		assert.Equal(t, "apache-2.0", props["ecosystems:repository_license"])
+		assert.Equal(t, "true", props["ecosystems:repository_template"])
+		assert.Equal(t, "v0.9.0", props["ecosystems:repository_latest_tag"])
+		assert.NotContains(t, props, "ecosystems:repository_fork")
+	}
+
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 1, calls["GET https://repos.ecosyste.ms/api/v1/repositories/lookup"])
+}
+
+func TestEnrichSBOM_CycloneDX_SelectedFields(t *testing.T) {
+	ResetGlobalCache()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries/.*/packages/.*/versions`,
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"licenses": "MIT",
+		}))
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"description": "description",
+		}))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{
+				BOMRef:     "pkg:golang/github.com/CycloneDX/cyclonedx-go@v0.3.0",
+				Type:       cdx.ComponentTypeLibrary,
+				Name:       "cyclonedx-go",
+				Version:    "v0.3.0",
+				PackageURL: "pkg:golang/github.com/CycloneDX/cyclonedx-go@v0.3.0",
+			},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+
+	cfg := DefaultConfig()
+	require.NoError(t, cfg.SelectFields([]string{"license"}, nil))
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	component := (*bom.Components)[0]
+	assert.Equal(t, &cdx.Licenses{{Expression: "(MIT)"}}, component.Licenses)
+	assert.Empty(t, component.Description)
+	assert.Nil(t, component.Properties)
+
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 1, calls[`GET =~^https://packages.ecosyste.ms/api/v1/registries/.*/packages/.*/versions`])
+	assert.Equal(t, 0, calls[`GET =~^https://packages.ecosyste.ms/api/v1/registries`])
+}
+
+func TestEnrichSBOM_CycloneDX_ExcludedFields(t *testing.T) {
+	ResetGlobalCache()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries/.*/packages/.*/versions`,
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"licenses": "MIT",
+		}))
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"description": "description",
+			"homepage":    "https://example.com",
+		}))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{
+				BOMRef:     "pkg:golang/github.com/CycloneDX/cyclonedx-go@v0.3.0",
+				PackageURL: "pkg:golang/github.com/CycloneDX/cyclonedx-go@v0.3.0",
+			},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+
+	cfg := DefaultConfig()
+	require.NoError(t, cfg.SelectFields(nil, []string{"externalRefs", "properties", "license", "release_date"}))
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	component := (*bom.Components)[0]
+	assert.Equal(t, "description", component.Description)
+	assert.Nil(t, component.ExternalReferences)
+	assert.Nil(t, component.Properties)
+
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 0, calls[`GET =~^https://packages.ecosyste.ms/api/v1/registries/.*/packages/.*/versions`])
+}
+
+func TestEnrichSBOM_CycloneDX_Provenance(t *testing.T) {
+	ResetGlobalCache()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	respond := func(body map[string]interface{}) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			resp, err := httpmock.NewJsonResponse(200, body)
+			// http.Transport sets the request of a response, httpmock doesn't.
+			resp.Request = req
+			resp.Header.Set("Date", "Mon, 07 Oct 2024 13:27:41 GMT")
+			return resp, err
+		}
+	}
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries/.*/packages/.*/versions`,
+		respond(map[string]interface{}{"licenses": "MIT"}))
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
+		respond(map[string]interface{}{"description": "description"}))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{
+				BOMRef:     "pkg:npm/cookie@1.0.0",
+				PackageURL: "pkg:npm/cookie@1.0.0",
+			},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+
+	cfg := DefaultConfig()
+	cfg.Provenance = true
+	require.NoError(t, cfg.SelectFields([]string{"description", "license"}, nil))
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	assert.Equal(t, &[]cdx.Property{
+		{
+			Name:  "parlay:provenance:description",
+			Value: `{"source":"packages.ecosyste.ms","endpoint":"https://packages.ecosyste.ms/api/v1/registries/npmjs.org/packages/cookie","fetched_at":"2024-10-07T13:27:41Z"}`,
+		},
+		{
+			Name:  "parlay:provenance:license",
+			Value: `{"source":"packages.ecosyste.ms","endpoint":"https://packages.ecosyste.ms/api/v1/registries/npmjs.org/packages/cookie/versions/1.0.0","fetched_at":"2024-10-07T13:27:41Z"}`,
+		},
+	}, (*bom.Components)[0].Properties)
+}
+
+func TestEnrichSBOM_CycloneDX_Report(t *testing.T) {
+	ResetGlobalCache()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	respond := func(status int, body map[string]interface{}) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			resp, err := httpmock.NewJsonResponse(status, body)
+			resp.Request = req
+			return resp, err
+		}
+	}
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries/npmjs.org/packages/missing`,
+		respond(404, map[string]interface{}{"error": "not found"}))
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries/.*/packages/.*/versions`,
+		respond(200, map[string]interface{}{"licenses": "MIT"}))
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
+		respond(200, map[string]interface{}{"description": "description"}))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{
+				BOMRef:      "cookie",
+				PackageURL:  "pkg:npm/cookie@1.0.0",
+				Description: "from the build",
+			},
+			{BOMRef: "missing", PackageURL: "pkg:npm/missing@1.0.0"},
+			{BOMRef: "generic", PackageURL: "pkg:generic/foo@1.0.0"},
+			{BOMRef: "nopurl", Name: "foo"},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+
+	cfg := DefaultConfig()
+	cfg.Report = report.New()
+	require.NoError(t, cfg.SetMergePolicy("description=overwrite"))
+	require.NoError(t, cfg.SelectFields([]string{"description", "license"}, nil))
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.Report.Write(&buf))
+	var got struct {
+		Summary    map[string]report.Summary `json:"summary"`
+		Components []report.Component        `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+
+	assert.Equal(t, report.Summary{Total: 4, Enriched: 1, Skipped: 2, Failed: 1, Coverage: 25}, got.Summary["ecosystems"])
+
+	outcomes := make(map[string]*report.Outcome)
+	for _, comp := range got.Components {
+		outcomes[comp.ID] = comp.Providers["ecosystems"]
+	}
+
+	cookie := outcomes["cookie"]
+	assert.Equal(t, report.StatusEnriched, cookie.Status)
+	assert.Equal(t, []string{"license"}, cookie.FieldsAdded)
+	assert.Equal(t, []string{"description"}, cookie.FieldsChanged)
+	assert.Equal(t, []report.Request{
+		{Endpoint: "https://packages.ecosyste.ms/api/v1/registries/npmjs.org/packages/cookie", StatusCode: 200},
+		{Endpoint: "https://packages.ecosyste.ms/api/v1/registries/npmjs.org/packages/cookie/versions/1.0.0", StatusCode: 200},
+	}, cookie.Requests)
+
+	assert.Equal(t, report.StatusFailed, outcomes["missing"].Status)
+	assert.Equal(t, 404, outcomes["missing"].Requests[0].StatusCode)
+	assert.Equal(t, report.StatusSkipped, outcomes["generic"].Status)
+	assert.Equal(t, `unsupported purl type "generic"`, outcomes["generic"].Reason)
+	assert.Equal(t, report.StatusSkipped, outcomes["nopurl"].Status)
+}
+
+func TestEnrichSBOM_CycloneDX_Cancelled(t *testing.T) {
+	ResetGlobalCache()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"description": "description",
+		}))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "cookie", PackageURL: "pkg:npm/cookie@1.0.0"},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cfg := DefaultConfig()
+	cfg.Report = report.New()
+	EnrichSBOM(ctx, cfg, doc, &logger)
+
+	assert.Empty(t, (*bom.Components)[0].Description)
+	assert.Zero(t, httpmock.GetTotalCallCount())
+
+	out := cfg.Report.Outcome("ecosystems", "cookie", "")
+	assert.Equal(t, report.StatusSkipped, out.Status)
+	assert.Equal(t, "enrichment interrupted: context canceled", out.Reason)
+}
+
+func TestEnrichSBOM_CycloneDX_RequestTimeout(t *testing.T) {
+	ResetGlobalCache()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
+		func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "cookie", PackageURL: "pkg:npm/cookie@1.0.0"},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+
+	cfg := DefaultConfig()
+	cfg.Report = report.New()
+	cfg.RequestTimeout = 10 * time.Millisecond
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	assert.Empty(t, (*bom.Components)[0].Description)
+
+	out := cfg.Report.Outcome("ecosystems", "cookie", "")
+	assert.Equal(t, report.StatusFailed, out.Status)
+	assert.Contains(t, out.Reason, "context deadline exceeded")
+}
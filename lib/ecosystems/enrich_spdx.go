@@ -17,8 +17,12 @@
 package ecosystems
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/package-url/packageurl-go"
 	"github.com/rs/zerolog"
@@ -27,47 +31,122 @@ import (
 	"github.com/spdx/tools-golang/spdx/v2/v2_3"
 
 	"github.com/snyk/parlay/ecosystems/packages"
+	"github.com/snyk/parlay/ecosystems/repos"
+	"github.com/snyk/parlay/internal/pool"
 	"github.com/snyk/parlay/internal/utils"
 )
 
-func enrichSPDX(bom *spdx.Document, logger *zerolog.Logger) {
-	packages := bom.Packages
+type (
+	spdxPackageEnricher        = func(*v2_3.Package, *packages.Package)
+	spdxPackageVersionEnricher = func(*v2_3.Package, *packages.VersionWithDependencies, *packages.Package)
+)
+
+func enrichSPDX(ctx context.Context, cfg *Config, bom *spdx.Document, logger *zerolog.Logger) {
+	pkgs := bom.Packages
 
-	logger.Debug().Msgf("Detected %d packages", len(packages))
+	logger.Debug().Msgf("Detected %d packages", len(pkgs))
 
 	cache := GetGlobalCache()
+	var unsupported unsupportedTypes
+
+	pool.Run(cfg.Concurrency, pkgs, func(pkg *v2_3.Package) {
+		l := logger.With().Str("SPDXID", string(pkg.PackageSPDXIdentifier)).Logger()
+		out := cfg.Report.Outcome(reportProvider, string(pkg.PackageSPDXIdentifier), "")
+		if err := ctx.Err(); err != nil {
+			out.Skip("enrichment interrupted: " + err.Error())
+			return
+		}
 
-	for _, pkg := range packages {
 		purl, err := extractPurl(pkg)
+		if err != nil && cfg.InferPurls {
+			purl, err = inferSPDXPurl(ctx, cfg, cache, pkg)
+		}
 		if err != nil {
-			continue
+			l.Debug().
+				Err(err).
+				Msg("Skipping package: no usable PackageURL")
+			out.Skip("no usable PackageURL")
+			return
 		}
+		out = cfg.Report.Outcome(reportProvider, string(pkg.PackageSPDXIdentifier), purl.String())
 
-		packageResp, err := cache.GetPackageData(*purl)
-		if err != nil {
-			continue
+		if !isSupportedPurl(*purl) {
+			unsupported.add(purl.Type)
+			out.Skip(fmt.Sprintf("unsupported purl type %q", purl.Type))
+			return
 		}
 
-		pkgData := packageResp.JSON200
-		if pkgData == nil {
-			continue
+		var pkgData *packages.Package
+		var pkgSource *provenance
+		if cfg.Fields.Any(packageFieldNames()...) {
+			pkgData, pkgSource, err = lookupPackage(ctx, cfg, cache, *purl, out)
+			if err != nil {
+				l.Debug().
+					Err(err).
+					Msg("Skipping package: failed to get package data")
+				out.Fail("failed to get package data: " + err.Error())
+				return
+			}
+
+			for _, field := range packageFields {
+				if cfg.Fields.Selected(field.name) {
+					mergeSPDXField(cfg, field.name, pkg, pkgSource, out, func(p *v2_3.Package) {
+						field.spdx(p, pkgData)
+					})
+				}
+			}
+			if cfg.Fields.Selected("low_adoption") {
+				mergeSPDXField(cfg, "low_adoption", pkg, pkgSource, out, func(p *v2_3.Package) {
+					enrichSPDXLowAdoption(cfg, p, pkgData)
+				})
+			}
+
+			if cfg.RepositoryMetadata && cfg.Fields.Selected("repository") {
+				repo, repoSource, err := lookupRepository(ctx, cfg, cache, pkgData, out)
+				if err != nil {
+					l.Debug().
+						Err(err).
+						Msg("Skipping repository enrichment: failed to get repository data")
+					out.Fail("failed to get repository data: " + err.Error())
+				} else if repo != nil {
+					mergeSPDXField(cfg, "repository", pkg, repoSource, out, func(p *v2_3.Package) {
+						enrichSPDXRepository(p, repo)
+					})
+				}
+			}
 		}
 
-		enrichSPDXDescription(pkg, pkgData)
-		enrichSPDXHomepage(pkg, pkgData)
-		enrichSPDXSupplier(pkg, pkgData)
+		if !cfg.Fields.Any(packageVersionFieldNames()...) {
+			return
+		}
 
-		packageVersionResp, err := cache.GetPackageVersionData(*purl)
+		versionData, versionSource, err := lookupPackageVersion(ctx, cfg, cache, *purl, out)
 		if err != nil {
-			continue
+			l.Debug().
+				Err(err).
+				Msg("Skipping package version enrichment: failed to get package version data")
+			out.Fail("failed to get package version data: " + err.Error())
+			return
 		}
 
-		pkgVersionData := packageVersionResp.JSON200
-		if pkgVersionData == nil {
-			continue
+		if pkgData == nil && needsPackageFallback(cfg, versionData) {
+			// Failing to get the package data only loses the fallback.
+			pkgData, _, _ = lookupPackage(ctx, cfg, cache, *purl, out)
 		}
 
-		enrichSPDXLicense(pkg, pkgVersionData, pkgData)
+		for _, field := range packageVersionFields {
+			if cfg.Fields.Selected(field.name) {
+				mergeSPDXField(cfg, field.name, pkg, versionSource, out, func(p *v2_3.Package) {
+					field.spdx(p, versionData, pkgData)
+				})
+			}
+		}
+	})
+
+	unsupported.log(logger)
+
+	if cfg.DependencyGraph && ctx.Err() == nil {
+		enrichSPDXDependencies(ctx, cfg, bom, cache, logger)
 	}
 }
 
@@ -86,15 +165,19 @@ func extractPurl(pkg *v2_3.Package) (*packageurl.PackageURL, error) {
 }
 
 func enrichSPDXSupplier(pkg *v2_3.Package, data *packages.Package) {
-	if data.RepoMetadata != nil {
-		meta := *data.RepoMetadata
-		if ownerRecord, ok := meta["owner_record"].(map[string]interface{}); ok {
-			if name, ok := ownerRecord["name"].(string); ok && name != "" {
-				pkg.PackageSupplier = &common.Supplier{
-					SupplierType: "Organization",
-					Supplier:     name,
-				}
-			}
+	if name := ownerName(data); name != "" {
+		pkg.PackageSupplier = &common.Supplier{
+			SupplierType: "Organization",
+			Supplier:     name,
+		}
+	}
+}
+
+func enrichSPDXOriginator(pkg *v2_3.Package, data *packages.Package) {
+	if name := ownerName(data); name != "" {
+		pkg.PackageOriginator = &common.Originator{
+			OriginatorType: "Organization",
+			Originator:     name,
 		}
 	}
 }
@@ -118,4 +201,104 @@ func enrichSPDXDescription(pkg *v2_3.Package, data *packages.Package) {
 		return
 	}
 	pkg.PackageDescription = *data.Description
+	pkg.PackageSummary = *data.Description
+}
+
+func enrichSPDXExternalReference(pkg *v2_3.Package, ref *string, refType string) {
+	if ref == nil || *ref == "" || strings.ContainsAny(*ref, " \t\n") {
+		return
+	}
+	if _, err := url.Parse(*ref); err != nil {
+		return
+	}
+	utils.AddSPDXExternalReference(pkg, &v2_3.PackageExternalReference{
+		Category: common.CategoryOther,
+		RefType:  refType,
+		Locator:  *ref,
+	})
+}
+
+func enrichSPDXRegistryURL(pkg *v2_3.Package, data *packages.Package) {
+	enrichSPDXExternalReference(pkg, data.RegistryUrl, "distribution")
+}
+
+func enrichSPDXRepositoryURL(pkg *v2_3.Package, data *packages.Package) {
+	enrichSPDXExternalReference(pkg, data.RepositoryUrl, "vcs")
+}
+
+func enrichSPDXDocumentationURL(pkg *v2_3.Package, data *packages.Package) {
+	enrichSPDXExternalReference(pkg, data.DocumentationUrl, "documentation")
+}
+
+func enrichSPDXFirstReleasePublishedAt(pkg *v2_3.Package, data *packages.Package) {
+	if timestamp := formatTimestamp(data.FirstReleasePublishedAt); timestamp != "" {
+		enrichSPDXAnnotation(pkg, "ecosystems:first_release_published_at", timestamp)
+	}
+}
+
+func enrichSPDXLatestReleasePublishedAt(pkg *v2_3.Package, data *packages.Package) {
+	if timestamp := formatTimestamp(data.LatestReleasePublishedAt); timestamp != "" {
+		enrichSPDXAnnotation(pkg, "ecosystems:latest_release_published_at", timestamp)
+	}
+}
+
+func enrichSPDXReleaseDate(pkg *v2_3.Package, pkgVersionData *packages.VersionWithDependencies, _ *packages.Package) {
+	if timestamp := versionPublishedAt(pkgVersionData); timestamp != "" {
+		pkg.ReleaseDate = timestamp
+	}
+}
+
+func enrichSPDXRepoArchived(pkg *v2_3.Package, data *packages.Package) {
+	if repositoryArchived(data) {
+		enrichSPDXAnnotation(pkg, "ecosystems:repository_archived", "true")
+	}
+}
+
+func enrichSPDXLocation(pkg *v2_3.Package, data *packages.Package) {
+	if location := ownerLocation(data); location != "" {
+		enrichSPDXAnnotation(pkg, "ecosystems:owner_location", location)
+	}
+}
+
+func enrichSPDXTopics(pkg *v2_3.Package, data *packages.Package) {
+	for _, topic := range repositoryTopics(data) {
+		enrichSPDXAnnotation(pkg, "ecosystems:topic", topic)
+	}
+}
+
+func enrichSPDXAnnotation(pkg *v2_3.Package, name, value string) {
+	comment := fmt.Sprintf("%s=%s", name, value)
+	for _, a := range pkg.Annotations {
+		if a.AnnotationComment == comment {
+			return
+		}
+	}
+	pkg.Annotations = append(pkg.Annotations, v2_3.Annotation{
+		Annotator: common.Annotator{
+			Annotator:     "parlay",
+			AnnotatorType: "Tool",
+		},
+		AnnotationDate:           time.Now().UTC().Format(time.RFC3339),
+		AnnotationType:           "OTHER",
+		AnnotationSPDXIdentifier: common.MakeDocElementID("", string(pkg.PackageSPDXIdentifier)),
+		AnnotationComment:        comment,
+	})
+}
+
+func enrichSPDXPopularity(pkg *v2_3.Package, data *packages.Package) {
+	for _, prop := range popularityProperties(data) {
+		enrichSPDXAnnotation(pkg, prop.name, prop.value)
+	}
+}
+
+func enrichSPDXLowAdoption(cfg *Config, pkg *v2_3.Package, data *packages.Package) {
+	if reasons := lowAdoptionReasons(cfg, data); len(reasons) > 0 {
+		enrichSPDXAnnotation(pkg, "ecosystems:low_adoption", strings.Join(reasons, ","))
+	}
+}
+
+func enrichSPDXRepository(pkg *v2_3.Package, repo *repos.Repository) {
+	for _, prop := range repositoryProperties(repo) {
+		enrichSPDXAnnotation(pkg, prop.name, prop.value)
+	}
 }
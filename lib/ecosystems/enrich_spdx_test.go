@@ -18,10 +18,13 @@ package ecosystems
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"testing"
+	"time"
 
+	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/jarcoal/httpmock"
 	"github.com/rs/zerolog"
 	"github.com/spdx/tools-golang/spdx/v2/common"
@@ -29,6 +32,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/snyk/parlay/ecosystems/packages"
 	"github.com/snyk/parlay/lib/sbom"
 )
 
@@ -98,7 +102,7 @@ func TestEnrichSBOM_SPDX(t *testing.T) {
 	}
 	logger := zerolog.Nop()
 
-	EnrichSBOM(doc, &logger)
+	EnrichSBOM(context.Background(), DefaultConfig(), doc, &logger)
 
 	pkgs := bom.Packages
 
@@ -156,7 +160,7 @@ func TestEnrichSBOM_MissingVersionedLicense(t *testing.T) {
 	}
 	logger := zerolog.Nop()
 
-	EnrichSBOM(doc, &logger)
+	EnrichSBOM(context.Background(), DefaultConfig(), doc, &logger)
 
 	pkgs := bom.Packages
 
@@ -210,8 +214,138 @@ func TestEnrichSBOM_SPDX_NoSupplierName(t *testing.T) {
 	}
 	logger := zerolog.Nop()
 
-	EnrichSBOM(doc, &logger)
+	EnrichSBOM(context.Background(), DefaultConfig(), doc, &logger)
 
 	buf := bytes.NewBuffer(nil)
 	require.NoError(t, doc.Encode(buf))
 }
+
+func TestEnrichSPDXPopularity(t *testing.T) {
+	pkg := &v2_3.Package{PackageSPDXIdentifier: "SPDXRef-pkg"}
+	data := &packages.Package{
+		DependentPackagesCount: 0,
+		DependentReposCount:    3,
+		Critical:               true,
+	}
+
+	enrichSPDXPopularity(pkg, data)
+	enrichSPDXLowAdoption(&Config{MinDependentPackages: 5}, pkg, data)
+
+	comments := make([]string, 0, len(pkg.Annotations))
+	for _, a := range pkg.Annotations {
+		assert.Equal(t, "parlay", a.Annotator.Annotator)
+		assert.Equal(t, "Tool", a.Annotator.AnnotatorType)
+		assert.Equal(t, "OTHER", a.AnnotationType)
+		comments = append(comments, a.AnnotationComment)
+	}
+	assert.Equal(t, []string{
+		"ecosystems:dependent_packages_count=0",
+		"ecosystems:dependent_repos_count=3",
+		"ecosystems:critical=true",
+		"ecosystems:low_adoption=dependent_packages_count",
+	}, comments)
+}
+
+func TestEnrichSPDXExternalReferences(t *testing.T) {
+	pkg := &v2_3.Package{PackageSPDXIdentifier: "SPDXRef-pkg"}
+	data := &packages.Package{
+		RegistryUrl:      pointerToString(t, "https://www.npmjs.com/package/cookie"),
+		RepositoryUrl:    pointerToString(t, "https://github.com/jshttp/cookie"),
+		DocumentationUrl: pointerToString(t, "not a url"),
+	}
+
+	enrichSPDXRegistryURL(pkg, data)
+	enrichSPDXRepositoryURL(pkg, data)
+	enrichSPDXDocumentationURL(pkg, data)
+
+	assert.Equal(t, []*v2_3.PackageExternalReference{
+		{Category: common.CategoryOther, RefType: "distribution", Locator: "https://www.npmjs.com/package/cookie"},
+		{Category: common.CategoryOther, RefType: "vcs", Locator: "https://github.com/jshttp/cookie"},
+	}, pkg.PackageExternalReferences)
+}
+
+func TestEnrichSPDXPackageFields(t *testing.T) {
+	pkg := &v2_3.Package{PackageSPDXIdentifier: "SPDXRef-pkg"}
+	data := &packages.Package{
+		Description: pointerToString(t, "HTTP server cookie parsing and serialization"),
+		RepoMetadata: &map[string]interface{}{
+			"owner_record": map[string]interface{}{
+				"name": "jshttp",
+			},
+		},
+	}
+	versionData := &packages.VersionWithDependencies{
+		PublishedAt: pointerToString(t, "2024-10-07T15:27:41.000+02:00"),
+	}
+
+	enrichSPDXDescription(pkg, data)
+	enrichSPDXOriginator(pkg, data)
+	enrichSPDXReleaseDate(pkg, versionData, data)
+
+	assert.Equal(t, "HTTP server cookie parsing and serialization", pkg.PackageSummary)
+	assert.Equal(t, "HTTP server cookie parsing and serialization", pkg.PackageDescription)
+	assert.Equal(t, &common.Originator{OriginatorType: "Organization", Originator: "jshttp"}, pkg.PackageOriginator)
+	assert.Equal(t, "2024-10-07T13:27:41Z", pkg.ReleaseDate)
+}
+
+func TestEnrichFieldsParity(t *testing.T) {
+	published := time.Date(2024, 10, 7, 13, 27, 41, 0, time.UTC)
+	data := &packages.Package{
+		Description:              pointerToString(t, "description"),
+		Homepage:                 pointerToString(t, "https://example.com"),
+		RegistryUrl:              pointerToString(t, "https://registry.example.com/pkg"),
+		RepositoryUrl:            pointerToString(t, "https://github.com/example/pkg"),
+		DocumentationUrl:         pointerToString(t, "https://docs.example.com"),
+		FirstReleasePublishedAt:  &published,
+		LatestReleasePublishedAt: &published,
+		DependentReposCount:      3,
+		RepoMetadata: &map[string]interface{}{
+			"archived": true,
+			"topics":   []interface{}{"http", "cookies"},
+			"owner_record": map[string]interface{}{
+				"name":     "Example",
+				"location": "Berlin",
+			},
+		},
+	}
+
+	comp := &cdx.Component{}
+	pkg := &v2_3.Package{PackageSPDXIdentifier: "SPDXRef-pkg"}
+	for _, field := range packageFields {
+		field.cdx(comp, data)
+		field.spdx(pkg, data)
+	}
+
+	properties := make([]string, 0, len(*comp.Properties))
+	for _, p := range *comp.Properties {
+		properties = append(properties, p.Name+"="+p.Value)
+	}
+	annotations := make([]string, 0, len(pkg.Annotations))
+	for _, a := range pkg.Annotations {
+		annotations = append(annotations, a.AnnotationComment)
+	}
+	assert.Equal(t, properties, annotations)
+
+	refTypes := map[cdx.ExternalReferenceType]string{
+		cdx.ERTypeDistribution:  "distribution",
+		cdx.ERTypeVCS:           "vcs",
+		cdx.ERTypeDocumentation: "documentation",
+	}
+	var cdxRefs, spdxRefs []string
+	for _, ref := range *comp.ExternalReferences {
+		if refType, ok := refTypes[ref.Type]; ok {
+			cdxRefs = append(cdxRefs, refType+"="+ref.URL)
+		} else {
+			assert.Equal(t, cdx.ERTypeWebsite, ref.Type)
+			assert.Equal(t, ref.URL, pkg.PackageHomePage)
+		}
+	}
+	for _, ref := range pkg.PackageExternalReferences {
+		spdxRefs = append(spdxRefs, ref.RefType+"="+ref.Locator)
+	}
+	assert.Equal(t, cdxRefs, spdxRefs)
+
+	assert.Equal(t, comp.Description, pkg.PackageDescription)
+	assert.Equal(t, comp.Author, pkg.PackageOriginator.Originator)
+	assert.Equal(t, comp.Supplier.Name, pkg.PackageSupplier.Supplier)
+}
@@ -0,0 +1,195 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ecosystems
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/snyk/parlay/ecosystems/packages"
+	"github.com/snyk/parlay/ecosystems/repos"
+	"github.com/snyk/parlay/internal/fields"
+)
+
+// packageField pairs the CycloneDX and SPDX enrichment of a single field of
+// the ecosyste.ms package data, so that both formats are enriched alike.
+type packageField struct {
+	name string
+	cdx  cdxPackageEnricher
+	spdx spdxPackageEnricher
+}
+
+// packageVersionField is the equivalent of packageField for fields taken
+// from the ecosyste.ms package version data.
+type packageVersionField struct {
+	name string
+	cdx  cdxPackageVersionEnricher
+	spdx spdxPackageVersionEnricher
+}
+
+var packageFields = []packageField{
+	{"description", enrichCDXDescription, enrichSPDXDescription},
+	{"homepage", enrichCDXHomepage, enrichSPDXHomepage},
+	{"registry_url", enrichCDXRegistryURL, enrichSPDXRegistryURL},
+	{"repository_url", enrichCDXRepositoryURL, enrichSPDXRepositoryURL},
+	{"documentation_url", enrichCDXDocumentationURL, enrichSPDXDocumentationURL},
+	{"first_release_published_at", enrichCDXFirstReleasePublishedAt, enrichSPDXFirstReleasePublishedAt},
+	{"latest_release_published_at", enrichCDXLatestReleasePublishedAt, enrichSPDXLatestReleasePublishedAt},
+	{"repository_archived", enrichCDXRepoArchived, enrichSPDXRepoArchived},
+	{"owner_location", enrichCDXLocation, enrichSPDXLocation},
+	{"topics", enrichCDXTopics, enrichSPDXTopics},
+	{"author", enrichCDXAuthor, enrichSPDXOriginator},
+	{"supplier", enrichCDXSupplier, enrichSPDXSupplier},
+	{"popularity", enrichCDXPopularity, enrichSPDXPopularity},
+}
+
+var packageVersionFields = []packageVersionField{
+	{"license", enrichCDXLicense, enrichSPDXLicense},
+	{"release_date", enrichCDXReleaseDate, enrichSPDXReleaseDate},
+}
+
+// packageDataFields are the fields taken from the ecosyste.ms package data
+// which aren't part of packageFields, as they depend on configuration.
+var packageDataFields = []string{"low_adoption", "repository"}
+
+// fieldAliases name groups of fields by the part of the SBOM they enrich.
+var fieldAliases = map[string][]string{
+	"externalRefs": {"homepage", "registry_url", "repository_url", "documentation_url"},
+	"properties": {
+		"first_release_published_at", "latest_release_published_at", "release_date",
+		"repository_archived", "owner_location", "topics", "popularity", "low_adoption", "repository",
+	},
+}
+
+func packageFieldNames() []string {
+	names := make([]string, 0, len(packageFields)+len(packageDataFields))
+	for _, field := range packageFields {
+		names = append(names, field.name)
+	}
+	return append(names, packageDataFields...)
+}
+
+func packageVersionFieldNames() []string {
+	names := make([]string, 0, len(packageVersionFields))
+	for _, field := range packageVersionFields {
+		names = append(names, field.name)
+	}
+	return names
+}
+
+func knownFields() []string {
+	return append(packageFieldNames(), packageVersionFieldNames()...)
+}
+
+// SelectFields restricts enrichment to the included fields, or to all fields
+// if none are included, minus the excluded fields.
+func (cfg *Config) SelectFields(include, exclude []string) error {
+	selection, err := fields.NewSelection(knownFields(), fieldAliases, include, exclude)
+	if err != nil {
+		return err
+	}
+	cfg.Fields = selection
+	return nil
+}
+
+func formatTimestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// versionPublishedAt returns the publication time of a package version,
+// normalised to RFC 3339 in UTC.
+func versionPublishedAt(data *packages.VersionWithDependencies) string {
+	if data.PublishedAt == nil {
+		return ""
+	}
+	t, err := time.Parse(time.RFC3339, *data.PublishedAt)
+	if err != nil {
+		return ""
+	}
+	return formatTimestamp(&t)
+}
+
+// repositoryMetadata holds the facts of a package's repository which
+// ecosyste.ms embeds in the package data, in the form repos.ecosyste.ms
+// reports them, along with the record of the repository's owner.
+type repositoryMetadata struct {
+	Archived    *bool        `json:"archived,omitempty"`
+	Topics      *[]string    `json:"topics,omitempty"`
+	OwnerRecord *repos.Owner `json:"owner_record,omitempty"`
+}
+
+// packageRepository decodes the repository metadata of a package. Metadata
+// which doesn't have the expected types is ignored.
+func packageRepository(data *packages.Package) repositoryMetadata {
+	var repo repositoryMetadata
+	if data.RepoMetadata == nil {
+		return repo
+	}
+	b, err := json.Marshal(*data.RepoMetadata)
+	if err != nil {
+		return repo
+	}
+	if err := json.Unmarshal(b, &repo); err != nil {
+		return repositoryMetadata{}
+	}
+	return repo
+}
+
+func ownerName(data *packages.Package) string {
+	owner := packageRepository(data).OwnerRecord
+	if owner == nil || owner.Name == nil {
+		return ""
+	}
+	return *owner.Name
+}
+
+func ownerLocation(data *packages.Package) string {
+	owner := packageRepository(data).OwnerRecord
+	if owner == nil || owner.Location == nil {
+		return ""
+	}
+	return *owner.Location
+}
+
+func ownerWebsites(data *packages.Package) []string {
+	owner := packageRepository(data).OwnerRecord
+	if owner == nil || owner.Website == nil || *owner.Website == "" {
+		return nil
+	}
+	split := strings.Split(*owner.Website, ", ")
+	for i := range split {
+		split[i] = strings.TrimSpace(split[i])
+	}
+	return split
+}
+
+func repositoryArchived(data *packages.Package) bool {
+	archived := packageRepository(data).Archived
+	return archived != nil && *archived
+}
+
+func repositoryTopics(data *packages.Package) []string {
+	topics := packageRepository(data).Topics
+	if topics == nil {
+		return nil
+	}
+	return *topics
+}
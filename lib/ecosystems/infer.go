@@ -0,0 +1,301 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ecosystems
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+	"github.com/spdx/tools-golang/spdx/v2/common"
+	"github.com/spdx/tools-golang/spdx/v2/v2_3"
+
+	"github.com/snyk/parlay/ecosystems/packages"
+	"github.com/snyk/parlay/internal/repourl"
+	"github.com/snyk/parlay/internal/utils"
+)
+
+const (
+	inferredFromDownloadLocation = "download_location"
+	inferredFromCPE              = "cpe"
+	// inferredFromCPERepository marks purls found through the GitHub
+	// repository guessed from the vendor and product of a CPE, which is less
+	// certain than the other sources.
+	inferredFromCPERepository = "cpe_repository"
+	inferredFromName          = "name"
+)
+
+// purlCandidate is a possible identity for a package without a purl. Either
+// purl is set, or repositoryURL together with the expected package name.
+// When the repository was guessed, only a package with that name confirms
+// it.
+type purlCandidate struct {
+	source        string
+	purl          *packageurl.PackageURL
+	repositoryURL string
+	name          string
+	version       string
+	guessed       bool
+}
+
+// downloadLocationPatterns derive purls from registry download URLs. Each
+// pattern captures the namespace (optional), name and version.
+var downloadLocationPatterns = []struct {
+	purlType string
+	re       *regexp.Regexp
+}{
+	{packageurl.TypeNPM, regexp.MustCompile(`registry\.npmjs\.org/(?:(@[^/]+)/)?([^/]+)/-/[^/]+?-(\d[^/]*)\.tgz$`)},
+	{packageurl.TypePyPi, regexp.MustCompile(`files\.pythonhosted\.org/packages/.+/()([A-Za-z0-9_.]+?)-(\d[^-/]*?)(?:\.tar\.gz|\.zip|-[^/]*\.whl)$`)},
+	{packageurl.TypeMaven, regexp.MustCompile(`/maven2/(.+)/([^/]+)/([^/]+)/[^/]+\.(?:jar|pom|war|aar)$`)},
+	{packageurl.TypeGem, regexp.MustCompile(`rubygems\.org/(?:gems|downloads)/()([^/]+?)-(\d[^/]*)\.gem$`)},
+	{packageurl.TypeCargo, regexp.MustCompile(`crates\.io/api/v1/crates/()([^/]+)/([^/]+)/download$`)},
+	{packageurl.TypeGolang, regexp.MustCompile(`proxy\.golang\.org/(.+)/([^/]+)/@v/([^/]+)\.(?:zip|mod|info)$`)},
+}
+
+func candidatesFromDownloadLocation(location, name, version string) []purlCandidate {
+	for _, p := range downloadLocationPatterns {
+		m := p.re.FindStringSubmatch(location)
+		if m == nil {
+			continue
+		}
+		namespace, n, v := m[1], m[2], m[3]
+		if p.purlType == packageurl.TypeMaven {
+			namespace = strings.ReplaceAll(namespace, "/", ".")
+		}
+		if version == "" {
+			version = v
+		}
+		purl := packageurl.NewPackageURL(p.purlType, namespace, n, version, nil, "")
+		return []purlCandidate{{source: inferredFromDownloadLocation, purl: purl}}
+	}
+
+	if repo := repourl.Normalize(location); repo != "" {
+		return []purlCandidate{{source: inferredFromDownloadLocation, repositoryURL: repo, name: name, version: version}}
+	}
+
+	return nil
+}
+
+// parseCPE returns the vendor, product and version of a CPE 2.2 URI or CPE
+// 2.3 formatted string.
+func parseCPE(cpe string) (vendor, product, version string, ok bool) {
+	var fields []string
+	switch {
+	case strings.HasPrefix(cpe, "cpe:2.3:"):
+		fields = splitCPE(strings.TrimPrefix(cpe, "cpe:2.3:"))
+	case strings.HasPrefix(cpe, "cpe:/"):
+		fields = strings.Split(strings.TrimPrefix(cpe, "cpe:/"), ":")
+	default:
+		return "", "", "", false
+	}
+
+	value := func(i int) string {
+		if i >= len(fields) || fields[i] == "*" || fields[i] == "-" {
+			return ""
+		}
+		return strings.ReplaceAll(fields[i], `\`, "")
+	}
+
+	vendor, product, version = value(1), value(2), value(3)
+	return vendor, product, version, product != ""
+}
+
+// splitCPE splits a CPE 2.3 formatted string on unescaped colons.
+func splitCPE(s string) []string {
+	var fields []string
+	var cur strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			cur.WriteByte(s[i])
+			cur.WriteByte(s[i+1])
+			i++
+		case s[i] == ':':
+			fields = append(fields, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(s[i])
+		}
+	}
+	return append(fields, cur.String())
+}
+
+func candidatesFromCPE(cfg *Config, cpe, version string) []purlCandidate {
+	vendor, product, cpeVersion, ok := parseCPE(cpe)
+	if !ok {
+		return nil
+	}
+	if version == "" {
+		version = cpeVersion
+	}
+
+	var candidates []purlCandidate
+	if vendor != "" {
+		candidates = append(candidates, purlCandidate{
+			source:        inferredFromCPERepository,
+			repositoryURL: "https://github.com/" + vendor + "/" + product,
+			name:          product,
+			version:       version,
+			guessed:       true,
+		})
+	}
+	if cfg.DefaultEcosystem != "" {
+		purl := packageurl.NewPackageURL(cfg.DefaultEcosystem, "", product, version, nil, "")
+		candidates = append(candidates, purlCandidate{source: inferredFromCPE, purl: purl})
+	}
+	return candidates
+}
+
+func candidatesFromName(cfg *Config, group, name, version string) []purlCandidate {
+	if cfg.DefaultEcosystem == "" || name == "" {
+		return nil
+	}
+	purl := packageurl.NewPackageURL(cfg.DefaultEcosystem, group, name, version, nil, "")
+	return []purlCandidate{{source: inferredFromName, purl: purl}}
+}
+
+// verifyCandidates returns the first candidate which ecosyste.ms knows about,
+// along with the source it was derived from.
+func verifyCandidates(ctx context.Context, cfg *Config, cache Cache, candidates []purlCandidate) (*packageurl.PackageURL, string, error) {
+	for _, c := range candidates {
+		params := &packages.LookupPackageParams{}
+		version := c.version
+		if c.purl != nil {
+			p := *c.purl
+			version, p.Version = p.Version, ""
+			s := p.ToString()
+			params.Purl = &s
+		} else {
+			params.RepositoryUrl = &c.repositoryURL
+		}
+
+		reqCtx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+		resp, err := cache.LookupPackage(reqCtx, params)
+		cancel()
+		if ctx.Err() != nil {
+			return nil, "", ctx.Err()
+		}
+		if err != nil || resp.JSON200 == nil {
+			continue
+		}
+
+		name := c.name
+		if c.purl != nil {
+			name = c.purl.Name
+		}
+		var match *packages.PackageWithRegistry
+		if c.guessed {
+			match = matchLookupName(*resp.JSON200, name)
+		} else {
+			match = matchLookupResult(*resp.JSON200, name)
+		}
+		if match == nil || match.Purl == "" {
+			continue
+		}
+
+		purl, err := packageurl.FromString(match.Purl)
+		if err != nil {
+			continue
+		}
+		purl.Version = version
+		return &purl, c.source, nil
+	}
+
+	return nil, "", errors.New("no purl candidate could be verified")
+}
+
+// matchLookupResult picks the package matching name from lookup results. When
+// no result matches by name, the lookup must be unambiguous.
+func matchLookupResult(results []packages.PackageWithRegistry, name string) *packages.PackageWithRegistry {
+	if match := matchLookupName(results, name); match != nil {
+		return match
+	}
+	if len(results) == 1 {
+		return &results[0]
+	}
+	return nil
+}
+
+// matchLookupName picks the package named name from lookup results,
+// ignoring the namespace or group the name may be prefixed with.
+func matchLookupName(results []packages.PackageWithRegistry, name string) *packages.PackageWithRegistry {
+	if name == "" {
+		return nil
+	}
+	name = strings.ToLower(name)
+	for i, r := range results {
+		n := strings.ToLower(r.Name)
+		if n == name || strings.HasSuffix(n, "/"+name) || strings.HasSuffix(n, ":"+name) {
+			return &results[i]
+		}
+	}
+	return nil
+}
+
+// inferCDXPurl derives a purl for a component without a usable one, and
+// records it on the component when ecosyste.ms confirms it.
+func inferCDXPurl(ctx context.Context, cfg *Config, cache Cache, comp *cdx.Component) (*packageurl.PackageURL, error) {
+	var candidates []purlCandidate
+	if comp.ExternalReferences != nil {
+		for _, ref := range *comp.ExternalReferences {
+			if ref.Type == cdx.ERTypeDistribution || ref.Type == cdx.ERTypeVCS {
+				candidates = append(candidates, candidatesFromDownloadLocation(ref.URL, comp.Name, comp.Version)...)
+			}
+		}
+	}
+	if comp.CPE != "" {
+		candidates = append(candidates, candidatesFromCPE(cfg, comp.CPE, comp.Version)...)
+	}
+	candidates = append(candidates, candidatesFromName(cfg, comp.Group, comp.Name, comp.Version)...)
+
+	purl, source, err := verifyCandidates(ctx, cfg, cache, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	comp.PackageURL = purl.ToString()
+	enrichProperty(comp, "ecosystems:purl_inferred", source)
+	return purl, nil
+}
+
+// inferSPDXPurl derives a purl for a package without a usable one, and adds
+// it as an external reference when ecosyste.ms confirms it.
+func inferSPDXPurl(ctx context.Context, cfg *Config, cache Cache, pkg *v2_3.Package) (*packageurl.PackageURL, error) {
+	candidates := candidatesFromDownloadLocation(pkg.PackageDownloadLocation, pkg.PackageName, pkg.PackageVersion)
+	for _, ref := range pkg.PackageExternalReferences {
+		if ref.RefType == common.TypeSecurityCPE23Type || ref.RefType == common.TypeSecurityCPE22Type {
+			candidates = append(candidates, candidatesFromCPE(cfg, ref.Locator, pkg.PackageVersion)...)
+		}
+	}
+	candidates = append(candidates, candidatesFromName(cfg, "", pkg.PackageName, pkg.PackageVersion)...)
+
+	purl, source, err := verifyCandidates(ctx, cfg, cache, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg.PackageExternalReferences = append(pkg.PackageExternalReferences, &v2_3.PackageExternalReference{
+		Category: common.CategoryPackageManager,
+		RefType:  common.TypePackageManagerPURL,
+		Locator:  purl.ToString(),
+	})
+	enrichSPDXAnnotation(pkg, "ecosystems:purl_inferred", source)
+	return purl, nil
+}
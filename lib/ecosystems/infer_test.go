@@ -0,0 +1,236 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ecosystems
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/jarcoal/httpmock"
+	"github.com/rs/zerolog"
+	"github.com/spdx/tools-golang/spdx/v2/common"
+	"github.com/spdx/tools-golang/spdx/v2/v2_3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/snyk/parlay/ecosystems/packages"
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+func TestCandidatesFromDownloadLocation(t *testing.T) {
+	tests := []struct {
+		location string
+		purl     string
+		repo     string
+	}{
+		{"https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz", "pkg:npm/lodash@4.17.21", ""},
+		{"https://registry.npmjs.org/@babel/core/-/core-7.22.0.tgz", "pkg:npm/%40babel/core@7.22.0", ""},
+		{"https://files.pythonhosted.org/packages/ab/cd/requests-2.31.0.tar.gz", "pkg:pypi/requests@2.31.0", ""},
+		{"https://files.pythonhosted.org/packages/ab/cd/requests-2.31.0-py3-none-any.whl", "pkg:pypi/requests@2.31.0", ""},
+		{"https://repo1.maven.org/maven2/org/apache/commons/commons-lang3/3.12.0/commons-lang3-3.12.0.jar", "pkg:maven/org.apache.commons/commons-lang3@3.12.0", ""},
+		{"https://rubygems.org/gems/rails-7.0.4.gem", "pkg:gem/rails@7.0.4", ""},
+		{"https://crates.io/api/v1/crates/serde/1.0.188/download", "pkg:cargo/serde@1.0.188", ""},
+		{"https://proxy.golang.org/github.com/spf13/cobra/@v/v1.7.0.zip", "pkg:golang/github.com/spf13/cobra@v1.7.0", ""},
+		{"git+https://github.com/snyk/parlay.git", "", "https://github.com/snyk/parlay"},
+		{"git@github.com:snyk/parlay.git", "", "https://github.com/snyk/parlay"},
+		{"NOASSERTION", "", ""},
+	}
+
+	for _, tt := range tests {
+		candidates := candidatesFromDownloadLocation(tt.location, "", "")
+		if tt.purl == "" && tt.repo == "" {
+			assert.Empty(t, candidates, tt.location)
+			continue
+		}
+		require.Len(t, candidates, 1, tt.location)
+		if tt.purl != "" {
+			require.NotNil(t, candidates[0].purl, tt.location)
+			assert.Equal(t, tt.purl, candidates[0].purl.ToString(), tt.location)
+		}
+		assert.Equal(t, tt.repo, candidates[0].repositoryURL, tt.location)
+	}
+}
+
+func TestParseCPE(t *testing.T) {
+	vendor, product, version, ok := parseCPE("cpe:2.3:a:expressjs:express:4.18.2:*:*:*:*:node.js:*:*")
+	assert.True(t, ok)
+	assert.Equal(t, "expressjs", vendor)
+	assert.Equal(t, "express", product)
+	assert.Equal(t, "4.18.2", version)
+
+	vendor, product, version, ok = parseCPE("cpe:/a:apache:log4j:2.14.1")
+	assert.True(t, ok)
+	assert.Equal(t, "apache", vendor)
+	assert.Equal(t, "log4j", product)
+	assert.Equal(t, "2.14.1", version)
+
+	_, product, _, ok = parseCPE(`cpe:2.3:a:acme:foo\:bar:1.0:*:*:*:*:*:*:*`)
+	assert.True(t, ok)
+	assert.Equal(t, "foo:bar", product)
+
+	_, _, _, ok = parseCPE("not-a-cpe")
+	assert.False(t, ok)
+}
+
+func TestMatchLookupResult(t *testing.T) {
+	results := []packages.PackageWithRegistry{
+		{Name: "github.com/acme/tools", Purl: "pkg:golang/github.com/acme/tools"},
+		{Name: "acme-cli", Purl: "pkg:npm/acme-cli"},
+	}
+
+	match := matchLookupResult(results, "acme-cli")
+	require.NotNil(t, match)
+	assert.Equal(t, "pkg:npm/acme-cli", match.Purl)
+
+	assert.Nil(t, matchLookupResult(results, ""), "ambiguous lookup without a name")
+	assert.Nil(t, matchLookupResult(results, "other"), "ambiguous lookup without a matching name")
+
+	match = matchLookupResult(results[:1], "")
+	require.NotNil(t, match)
+	assert.Equal(t, "pkg:golang/github.com/acme/tools", match.Purl)
+}
+
+func TestEnrichSBOM_CycloneDX_InferPurl(t *testing.T) {
+	ResetGlobalCache()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://packages.ecosyste.ms/api/v1/packages/lookup",
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("purl") == "pkg:npm/lodash" {
+				return httpmock.NewJsonResponse(200, []map[string]interface{}{
+					{"name": "lodash", "purl": "pkg:npm/lodash"},
+				})
+			}
+			return httpmock.NewJsonResponse(200, []map[string]interface{}{})
+		})
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{"description": "Lodash modular utilities."}))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "lodash", Name: "lodash", Version: "4.17.21"},
+			{BOMRef: "unknown", Name: "unknown", Version: "1.0.0"},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+	cfg := DefaultConfig()
+	cfg.InferPurls = true
+	cfg.DefaultEcosystem = "npm"
+
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	comps := *bom.Components
+	assert.Equal(t, "pkg:npm/lodash@4.17.21", comps[0].PackageURL)
+	assert.Equal(t, "Lodash modular utilities.", comps[0].Description)
+	assert.Contains(t, *comps[0].Properties, cdx.Property{Name: "ecosystems:purl_inferred", Value: "name"})
+
+	assert.Empty(t, comps[1].PackageURL)
+	assert.Nil(t, comps[1].Properties)
+}
+
+func TestEnrichSBOM_SPDX_InferPurlFromDownloadLocation(t *testing.T) {
+	ResetGlobalCache()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://packages.ecosyste.ms/api/v1/packages/lookup",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "https://github.com/spf13/cobra", req.URL.Query().Get("repository_url"))
+			return httpmock.NewJsonResponse(200, []map[string]interface{}{
+				{"name": "github.com/spf13/cobra", "purl": "pkg:golang/github.com/spf13/cobra"},
+			})
+		})
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{}))
+
+	doc, err := sbom.DecodeSBOMDocument([]byte(`{"spdxVersion":"SPDX-2.3","SPDXID":"SPDXRef-DOCUMENT"}`))
+	require.NoError(t, err)
+	bom, ok := doc.BOM.(*v2_3.Document)
+	require.True(t, ok)
+
+	bom.Packages = []*v2_3.Package{
+		{
+			PackageSPDXIdentifier:   "cobra",
+			PackageName:             "cobra",
+			PackageVersion:          "v1.7.0",
+			PackageDownloadLocation: "git+https://github.com/spf13/cobra.git",
+		},
+	}
+	logger := zerolog.Nop()
+	cfg := DefaultConfig()
+	cfg.InferPurls = true
+
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	pkg := bom.Packages[0]
+	require.Len(t, pkg.PackageExternalReferences, 1)
+	assert.Equal(t, common.CategoryPackageManager, pkg.PackageExternalReferences[0].Category)
+	assert.Equal(t, "purl", pkg.PackageExternalReferences[0].RefType)
+	assert.Equal(t, "pkg:golang/github.com/spf13/cobra@v1.7.0", pkg.PackageExternalReferences[0].Locator)
+	assert.Equal(t, "ecosystems:purl_inferred=download_location", pkg.Annotations[0].AnnotationComment)
+}
+
+func TestEnrichSBOM_CycloneDX_InferPurlFromCPERepository(t *testing.T) {
+	ResetGlobalCache()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://packages.ecosyste.ms/api/v1/packages/lookup",
+		func(req *http.Request) (*http.Response, error) {
+			switch req.URL.Query().Get("repository_url") {
+			case "https://github.com/acme/widget":
+				return httpmock.NewJsonResponse(200, []map[string]interface{}{
+					{"name": "widget", "purl": "pkg:npm/widget"},
+				})
+			case "https://github.com/acme/gadget":
+				return httpmock.NewJsonResponse(200, []map[string]interface{}{
+					{"name": "unrelated", "purl": "pkg:npm/unrelated"},
+				})
+			}
+			return httpmock.NewJsonResponse(200, []map[string]interface{}{})
+		})
+	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{}))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "widget", Name: "widget", Version: "1.0.0", CPE: "cpe:2.3:a:acme:widget:1.0.0:*:*:*:*:*:*:*"},
+			{BOMRef: "gadget", Name: "gadget", Version: "1.0.0", CPE: "cpe:2.3:a:acme:gadget:1.0.0:*:*:*:*:*:*:*"},
+			{BOMRef: "gadget-copy", Name: "gadget", Version: "1.0.0", CPE: "cpe:2.3:a:acme:gadget:1.0.0:*:*:*:*:*:*:*"},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+	cfg := DefaultConfig()
+	cfg.InferPurls = true
+
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	comps := *bom.Components
+	assert.Equal(t, "pkg:npm/widget@1.0.0", comps[0].PackageURL)
+	assert.Contains(t, *comps[0].Properties, cdx.Property{Name: "ecosystems:purl_inferred", Value: "cpe_repository"})
+
+	assert.Empty(t, comps[1].PackageURL, "guessed repository publishes a package with another name")
+	assert.Empty(t, comps[2].PackageURL)
+
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 2, calls["GET https://packages.ecosyste.ms/api/v1/packages/lookup"], "each repository is looked up once")
+}
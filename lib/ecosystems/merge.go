@@ -0,0 +1,351 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ecosystems
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/spdx/v2/v2_3"
+
+	"github.com/snyk/parlay/lib/report"
+)
+
+// MergePolicy decides how an enriched value is combined with a value the
+// SBOM already holds for the same field.
+type MergePolicy string
+
+const (
+	// MergeKeep leaves existing values untouched and only fills in fields
+	// which are empty.
+	MergeKeep MergePolicy = "keep"
+	// MergeOverwrite replaces existing values with the enriched ones.
+	MergeOverwrite MergePolicy = "overwrite"
+	// MergeAppend adds enriched values to lists such as licenses, external
+	// references and properties, and behaves like MergeKeep for fields
+	// holding a single value.
+	MergeAppend MergePolicy = "append"
+)
+
+func parseMergePolicy(s string) (MergePolicy, error) {
+	switch p := MergePolicy(s); p {
+	case MergeKeep, MergeOverwrite, MergeAppend:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown merge policy %q, expected one of keep, overwrite or append", s)
+	}
+}
+
+// SetMergePolicy parses a merge policy given either as "<policy>", which
+// applies to all fields, or as "<field>=<policy>" for a single field.
+func (cfg *Config) SetMergePolicy(spec string) error {
+	field, policy, ok := strings.Cut(spec, "=")
+	if !ok {
+		p, err := parseMergePolicy(spec)
+		if err != nil {
+			return err
+		}
+		cfg.Merge = p
+		return nil
+	}
+
+	if !slices.Contains(knownFields(), field) {
+		return fmt.Errorf("unknown field %q", field)
+	}
+	p, err := parseMergePolicy(policy)
+	if err != nil {
+		return err
+	}
+	if cfg.FieldMerge == nil {
+		cfg.FieldMerge = make(map[string]MergePolicy)
+	}
+	cfg.FieldMerge[field] = p
+	return nil
+}
+
+func (cfg *Config) mergePolicy(field string) MergePolicy {
+	if p, ok := cfg.FieldMerge[field]; ok {
+		return p
+	}
+	if cfg.Merge == "" {
+		return MergeKeep
+	}
+	return cfg.Merge
+}
+
+// mergeValue sets dst to src according to the merge policy, ignoring empty
+// values of src. It reports whether dst changed.
+func mergeValue[T comparable](policy MergePolicy, dst *T, src T) bool {
+	var zero T
+	if src == zero {
+		return false
+	}
+	if *dst != zero && policy != MergeOverwrite {
+		return false
+	}
+	changed := !reflect.DeepEqual(*dst, src)
+	*dst = src
+	return changed
+}
+
+// mergeValues merges the values of src into dst. Values are grouped by key:
+// MergeKeep only adds values for keys dst has no values for yet,
+// MergeOverwrite replaces the values dst has for the keys in src, and
+// MergeAppend adds all values. Values with the same identity as one already
+// present are never added twice. It reports whether the values changed.
+func mergeValues[T any](policy MergePolicy, dst, src []T, key, identity func(T) string) ([]T, bool) {
+	if len(src) == 0 {
+		return dst, false
+	}
+
+	srcKeys := make(map[string]bool)
+	for _, v := range src {
+		srcKeys[key(v)] = true
+	}
+
+	merged := make([]T, 0, len(dst)+len(src))
+	dstKeys := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, v := range dst {
+		if policy == MergeOverwrite && srcKeys[key(v)] {
+			continue
+		}
+		dstKeys[key(v)] = true
+		seen[identity(v)] = true
+		merged = append(merged, v)
+	}
+
+	for _, v := range src {
+		if policy == MergeKeep && dstKeys[key(v)] {
+			continue
+		}
+		if seen[identity(v)] {
+			continue
+		}
+		seen[identity(v)] = true
+		merged = append(merged, v)
+	}
+
+	changed := len(merged) != len(dst)
+	for i := 0; !changed && i < len(dst); i++ {
+		changed = identity(merged[i]) != identity(dst[i])
+	}
+
+	return merged, changed
+}
+
+func mergeCDXSlice[T any](policy MergePolicy, dst **[]T, src *[]T, key, identity func(T) string) bool {
+	if src == nil {
+		return false
+	}
+	var existing []T
+	if *dst != nil {
+		existing = **dst
+	}
+	merged, changed := mergeValues(policy, existing, *src, key, identity)
+	if len(merged) > 0 {
+		*dst = &merged
+	}
+	return changed
+}
+
+func cdxLicenseIdentity(l cdx.LicenseChoice) string {
+	if l.Expression != "" {
+		return l.Expression
+	}
+	if l.License != nil {
+		return l.License.ID + "\x00" + l.License.Name
+	}
+	return ""
+}
+
+func cdxPropertyIdentity(p cdx.Property) string {
+	return p.Name + "\x00" + p.Value
+}
+
+// mergeCDXComponent merges the fields an enricher set on src into dst. It
+// reports whether dst changed.
+func mergeCDXComponent(policy MergePolicy, dst, src *cdx.Component) bool {
+	changed := mergeValue(policy, &dst.Description, src.Description)
+	changed = mergeValue(policy, &dst.Author, src.Author) || changed
+	changed = mergeValue(policy, &dst.Supplier, src.Supplier) || changed
+
+	if src.Licenses != nil {
+		var existing []cdx.LicenseChoice
+		if dst.Licenses != nil {
+			existing = *dst.Licenses
+		}
+		merged, licensesChanged := mergeValues(policy, existing, *src.Licenses,
+			func(cdx.LicenseChoice) string { return "" },
+			cdxLicenseIdentity)
+		if len(merged) > 0 {
+			licenses := cdx.Licenses(merged)
+			dst.Licenses = &licenses
+		}
+		changed = licensesChanged || changed
+	}
+
+	changed = mergeCDXSlice(policy, &dst.ExternalReferences, src.ExternalReferences,
+		func(r cdx.ExternalReference) string { return string(r.Type) },
+		func(r cdx.ExternalReference) string { return string(r.Type) + "\x00" + r.URL }) || changed
+	changed = mergeCDXSlice(policy, &dst.Properties, src.Properties,
+		func(p cdx.Property) string { return p.Name },
+		cdxPropertyIdentity) || changed
+
+	return changed
+}
+
+// cdxHasValues reports whether dst already holds a value for any of the
+// fields an enricher set on src.
+func cdxHasValues(dst, src *cdx.Component) bool {
+	if (src.Description != "" && dst.Description != "") ||
+		(src.Author != "" && dst.Author != "") ||
+		(src.Supplier != nil && dst.Supplier != nil) ||
+		(src.Licenses != nil && dst.Licenses != nil && len(*dst.Licenses) > 0) {
+		return true
+	}
+	if src.ExternalReferences != nil && dst.ExternalReferences != nil {
+		for _, s := range *src.ExternalReferences {
+			if slices.ContainsFunc(*dst.ExternalReferences, func(d cdx.ExternalReference) bool { return d.Type == s.Type }) {
+				return true
+			}
+		}
+	}
+	if src.Properties != nil && dst.Properties != nil {
+		for _, s := range *src.Properties {
+			if slices.ContainsFunc(*dst.Properties, func(d cdx.Property) bool { return d.Name == s.Name }) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recordField records on the report whether a field was added or changed.
+func recordField(out *report.Outcome, field string, changed, existed bool) {
+	switch {
+	case !changed:
+	case existed:
+		out.Changed(field)
+	default:
+		out.Added(field)
+	}
+}
+
+// mergeCDXField runs enrich against an empty component and merges the result
+// into comp according to the merge policy of the field. If the component
+// changed and provenance is enabled, the source of the field is recorded too.
+func mergeCDXField(cfg *Config, field string, comp *cdx.Component, src *provenance, out *report.Outcome, enrich func(*cdx.Component)) {
+	scratch := &cdx.Component{}
+	enrich(scratch)
+	existed := cdxHasValues(comp, scratch)
+	changed := mergeCDXComponent(cfg.mergePolicy(field), comp, scratch)
+	recordField(out, field, changed, existed)
+	if !changed || !cfg.Provenance || src == nil {
+		return
+	}
+
+	mergeCDXSlice(MergeOverwrite, &comp.Properties,
+		&[]cdx.Property{{Name: provenancePropertyName(field), Value: src.String()}},
+		func(p cdx.Property) string { return p.Name },
+		cdxPropertyIdentity)
+}
+
+func spdxAnnotationName(a v2_3.Annotation) string {
+	name, _, _ := strings.Cut(a.AnnotationComment, "=")
+	return name
+}
+
+func spdxAnnotationIdentity(a v2_3.Annotation) string {
+	return a.AnnotationComment
+}
+
+// mergeSPDXPackage merges the fields an enricher set on src into dst. It
+// reports whether dst changed.
+func mergeSPDXPackage(policy MergePolicy, dst, src *v2_3.Package) bool {
+	changed := mergeValue(policy, &dst.PackageDescription, src.PackageDescription)
+	changed = mergeValue(policy, &dst.PackageSummary, src.PackageSummary) || changed
+	changed = mergeValue(policy, &dst.PackageHomePage, src.PackageHomePage) || changed
+	changed = mergeValue(policy, &dst.PackageSupplier, src.PackageSupplier) || changed
+	changed = mergeValue(policy, &dst.PackageOriginator, src.PackageOriginator) || changed
+	changed = mergeValue(policy, &dst.PackageLicenseConcluded, src.PackageLicenseConcluded) || changed
+	changed = mergeValue(policy, &dst.ReleaseDate, src.ReleaseDate) || changed
+
+	var refsChanged, annotationsChanged bool
+	dst.PackageExternalReferences, refsChanged = mergeValues(policy, dst.PackageExternalReferences, src.PackageExternalReferences,
+		func(r *v2_3.PackageExternalReference) string { return r.Category + "\x00" + r.RefType },
+		func(r *v2_3.PackageExternalReference) string {
+			return r.Category + "\x00" + r.RefType + "\x00" + r.Locator
+		})
+	dst.Annotations, annotationsChanged = mergeValues(policy, dst.Annotations, src.Annotations,
+		spdxAnnotationName,
+		spdxAnnotationIdentity)
+
+	return changed || refsChanged || annotationsChanged
+}
+
+// spdxHasValues reports whether dst already holds a value for any of the
+// fields an enricher set on src.
+func spdxHasValues(dst, src *v2_3.Package) bool {
+	if (src.PackageDescription != "" && dst.PackageDescription != "") ||
+		(src.PackageSummary != "" && dst.PackageSummary != "") ||
+		(src.PackageHomePage != "" && dst.PackageHomePage != "") ||
+		(src.PackageSupplier != nil && dst.PackageSupplier != nil) ||
+		(src.PackageOriginator != nil && dst.PackageOriginator != nil) ||
+		(src.PackageLicenseConcluded != "" && dst.PackageLicenseConcluded != "") ||
+		(src.ReleaseDate != "" && dst.ReleaseDate != "") {
+		return true
+	}
+	for _, s := range src.PackageExternalReferences {
+		if slices.ContainsFunc(dst.PackageExternalReferences, func(d *v2_3.PackageExternalReference) bool {
+			return d.Category == s.Category && d.RefType == s.RefType
+		}) {
+			return true
+		}
+	}
+	for _, s := range src.Annotations {
+		if slices.ContainsFunc(dst.Annotations, func(d v2_3.Annotation) bool {
+			return spdxAnnotationName(d) == spdxAnnotationName(s)
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSPDXField runs enrich against an empty package and merges the result
+// into pkg according to the merge policy of the field. If the package changed
+// and provenance is enabled, the source of the field is recorded too.
+func mergeSPDXField(cfg *Config, field string, pkg *v2_3.Package, src *provenance, out *report.Outcome, enrich func(*v2_3.Package)) {
+	scratch := &v2_3.Package{PackageSPDXIdentifier: pkg.PackageSPDXIdentifier}
+	enrich(scratch)
+	existed := spdxHasValues(pkg, scratch)
+	changed := mergeSPDXPackage(cfg.mergePolicy(field), pkg, scratch)
+	recordField(out, field, changed, existed)
+	if !changed || !cfg.Provenance || src == nil {
+		return
+	}
+
+	annotated := &v2_3.Package{PackageSPDXIdentifier: pkg.PackageSPDXIdentifier}
+	enrichSPDXAnnotation(annotated, provenancePropertyName(field), src.String())
+	pkg.Annotations, _ = mergeValues(MergeOverwrite, pkg.Annotations, annotated.Annotations,
+		spdxAnnotationName,
+		spdxAnnotationIdentity)
+}
@@ -0,0 +1,185 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ecosystems
+
+import (
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/spdx/v2/common"
+	"github.com/spdx/tools-golang/spdx/v2/v2_3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/snyk/parlay/ecosystems/packages"
+)
+
+func TestSetMergePolicy(t *testing.T) {
+	cfg := DefaultConfig()
+
+	require.NoError(t, cfg.SetMergePolicy("append"))
+	require.NoError(t, cfg.SetMergePolicy("license=overwrite"))
+
+	assert.Equal(t, MergeAppend, cfg.mergePolicy("description"))
+	assert.Equal(t, MergeOverwrite, cfg.mergePolicy("license"))
+
+	assert.Error(t, cfg.SetMergePolicy("replace"))
+	assert.Error(t, cfg.SetMergePolicy("colour=keep"))
+	assert.Error(t, cfg.SetMergePolicy("license=replace"))
+}
+
+func TestMergeCDXField(t *testing.T) {
+	data := &packages.Package{
+		Description: pointerToString(t, "from ecosyste.ms"),
+		Homepage:    pointerToString(t, "https://example.com"),
+		RepoMetadata: &map[string]interface{}{
+			"topics": []interface{}{"http", "cookies"},
+		},
+	}
+	versionData := &packages.VersionWithDependencies{
+		Licenses: pointerToString(t, "MIT"),
+	}
+
+	tests := []struct {
+		policy      MergePolicy
+		description string
+		licenses    *cdx.Licenses
+		refs        *[]cdx.ExternalReference
+		topics      []cdx.Property
+	}{
+		{
+			policy:      MergeKeep,
+			description: "from the build",
+			licenses:    &cdx.Licenses{{Expression: "Apache-2.0"}},
+			refs: &[]cdx.ExternalReference{
+				{URL: "https://example.org", Type: cdx.ERTypeWebsite},
+			},
+			topics: []cdx.Property{
+				{Name: "ecosystems:topic", Value: "http"},
+			},
+		},
+		{
+			policy:      MergeOverwrite,
+			description: "from ecosyste.ms",
+			licenses:    &cdx.Licenses{{Expression: "(MIT)"}},
+			refs: &[]cdx.ExternalReference{
+				{URL: "https://example.com", Type: cdx.ERTypeWebsite},
+			},
+			topics: []cdx.Property{
+				{Name: "ecosystems:topic", Value: "http"},
+				{Name: "ecosystems:topic", Value: "cookies"},
+			},
+		},
+		{
+			policy:      MergeAppend,
+			description: "from the build",
+			licenses:    &cdx.Licenses{{Expression: "Apache-2.0"}, {Expression: "(MIT)"}},
+			refs: &[]cdx.ExternalReference{
+				{URL: "https://example.org", Type: cdx.ERTypeWebsite},
+				{URL: "https://example.com", Type: cdx.ERTypeWebsite},
+			},
+			topics: []cdx.Property{
+				{Name: "ecosystems:topic", Value: "http"},
+				{Name: "ecosystems:topic", Value: "cookies"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			cfg := &Config{Merge: tc.policy}
+			comp := &cdx.Component{
+				Description: "from the build",
+				Licenses:    &cdx.Licenses{{Expression: "Apache-2.0"}},
+				ExternalReferences: &[]cdx.ExternalReference{
+					{URL: "https://example.org", Type: cdx.ERTypeWebsite},
+				},
+				Properties: &[]cdx.Property{
+					{Name: "ecosystems:topic", Value: "http"},
+				},
+			}
+
+			// Enriching twice must give the same result as enriching once.
+			for i := 0; i < 2; i++ {
+				for _, field := range packageFields {
+					mergeCDXField(cfg, field.name, comp, nil, nil, func(c *cdx.Component) {
+						field.cdx(c, data)
+					})
+				}
+				for _, field := range packageVersionFields {
+					mergeCDXField(cfg, field.name, comp, nil, nil, func(c *cdx.Component) {
+						field.cdx(c, versionData, data)
+					})
+				}
+			}
+
+			assert.Equal(t, tc.description, comp.Description)
+			assert.Equal(t, tc.licenses, comp.Licenses)
+			assert.Equal(t, tc.refs, comp.ExternalReferences)
+
+			var topics []cdx.Property
+			for _, p := range *comp.Properties {
+				if p.Name == "ecosystems:topic" {
+					topics = append(topics, p)
+				}
+			}
+			assert.Equal(t, tc.topics, topics)
+		})
+	}
+}
+
+func TestMergeSPDXField(t *testing.T) {
+	data := &packages.Package{
+		Description:   pointerToString(t, "from ecosyste.ms"),
+		RepositoryUrl: pointerToString(t, "https://github.com/example/pkg"),
+		RepoMetadata: &map[string]interface{}{
+			"owner_record": map[string]interface{}{
+				"name": "Example",
+			},
+		},
+	}
+	cfg := DefaultConfig()
+	require.NoError(t, cfg.SetMergePolicy("supplier=overwrite"))
+
+	pkg := &v2_3.Package{
+		PackageSPDXIdentifier: "SPDXRef-pkg",
+		PackageDescription:    "from the build",
+		PackageSupplier:       &common.Supplier{SupplierType: "Person", Supplier: "Jane"},
+	}
+
+	for i := 0; i < 2; i++ {
+		for _, field := range packageFields {
+			mergeSPDXField(cfg, field.name, pkg, nil, nil, func(p *v2_3.Package) {
+				field.spdx(p, data)
+			})
+		}
+	}
+
+	assert.Equal(t, "from the build", pkg.PackageDescription)
+	assert.Equal(t, "from ecosyste.ms", pkg.PackageSummary)
+	assert.Equal(t, &common.Supplier{SupplierType: "Organization", Supplier: "Example"}, pkg.PackageSupplier)
+	assert.Len(t, pkg.PackageExternalReferences, 1)
+
+	comments := make([]string, 0, len(pkg.Annotations))
+	for _, a := range pkg.Annotations {
+		comments = append(comments, a.AnnotationComment)
+	}
+	assert.Equal(t, []string{
+		"ecosystems:dependent_packages_count=0",
+		"ecosystems:dependent_repos_count=0",
+	}, comments)
+}
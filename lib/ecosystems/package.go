@@ -19,15 +19,18 @@ package ecosystems
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/package-url/packageurl-go"
 
 	"github.com/snyk/parlay/ecosystems/packages"
+	"github.com/snyk/parlay/internal/repourl"
 )
 
-const server = "https://packages.ecosyste.ms/api/v1"
+// purlTypeGithubActions is the purl type for GitHub Actions, which the
+// packageurl library does not define.
+const purlTypeGithubActions = "githubactions"
 
 // Version will be set by the build process
 var Version = "dev"
@@ -36,19 +39,21 @@ func getUserAgent() string {
 	return fmt.Sprintf("parlay (%s)", Version)
 }
 
-func GetPackageData(purl packageurl.PackageURL) (*packages.GetRegistryPackageResponse, error) {
-	client, err := packages.NewClientWithResponses(server,
-		packages.WithRequestEditorFn(func(ctx context.Context, req *http.Request) error {
-			req.Header.Set("User-Agent", getUserAgent())
-			return nil
-		}))
+func GetPackageData(ctx context.Context, purl packageurl.PackageURL) (*packages.GetRegistryPackageResponse, error) {
+	return getPackageData(ctx, purl, lookupRepositoryPackages)
+}
+
+func getPackageData(ctx context.Context, purl packageurl.PackageURL, lookup repositoryLookup) (*packages.GetRegistryPackageResponse, error) {
+	client, err := newPackagesClient()
 	if err != nil {
 		return nil, err
 	}
 
-	name := purlToEcosystemsName(purl)
-	registry := purlToEcosystemsRegistry(purl)
-	resp, err := client.GetRegistryPackageWithResponse(context.Background(), registry, name)
+	registry, name, err := resolveRegistryPackage(ctx, purl, lookup)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.GetRegistryPackageWithResponse(ctx, registry, name)
 
 	if err != nil {
 		return nil, err
@@ -56,26 +61,188 @@ func GetPackageData(purl packageurl.PackageURL) (*packages.GetRegistryPackageRes
 	return resp, nil
 }
 
-func GetPackageVersionData(purl packageurl.PackageURL) (*packages.GetRegistryPackageVersionResponse, error) {
-	client, err := packages.NewClientWithResponses(server,
-		packages.WithRequestEditorFn(func(ctx context.Context, req *http.Request) error {
-			req.Header.Set("User-Agent", getUserAgent())
-			return nil
-		}))
+func GetPackageVersionData(ctx context.Context, purl packageurl.PackageURL) (*packages.GetRegistryPackageVersionResponse, error) {
+	return getPackageVersionData(ctx, purl, lookupRepositoryPackages)
+}
+
+func getPackageVersionData(ctx context.Context, purl packageurl.PackageURL, lookup repositoryLookup) (*packages.GetRegistryPackageVersionResponse, error) {
+	client, err := newPackagesClient()
 	if err != nil {
 		return nil, err
 	}
 
-	name := purlToEcosystemsName(purl)
-	registry := purlToEcosystemsRegistry(purl)
-	resp, err := client.GetRegistryPackageVersionWithResponse(context.Background(), registry, name, purl.Version)
+	registry, name, err := resolveRegistryPackage(ctx, purl, lookup)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.GetRegistryPackageVersionWithResponse(ctx, registry, name, purlToEcosystemsVersion(purl))
+
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func LookupPackage(ctx context.Context, params *packages.LookupPackageParams) (*packages.LookupPackageResponse, error) {
+	client, err := newPackagesClient()
+	if err != nil {
+		return nil, err
+	}
 
+	resp, err := client.LookupPackageWithResponse(ctx, params)
 	if err != nil {
 		return nil, err
 	}
 	return resp, nil
 }
 
+// GetRegistries returns all registries known to ecosyste.ms.
+func GetRegistries(ctx context.Context) ([]packages.Registry, error) {
+	client, err := newPackagesClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var registries []packages.Registry
+	perPage := 100
+	for page := 1; ; page++ {
+		params := packages.GetRegistriesParams{Page: &page, PerPage: &perPage}
+		resp, err := client.GetRegistriesWithResponse(ctx, &params)
+		if err != nil {
+			return nil, err
+		}
+		if resp.JSON200 == nil {
+			return nil, fmt.Errorf("unexpected response from ecosyste.ms (%s)", resp.Status())
+		}
+		registries = append(registries, *resp.JSON200...)
+		if len(*resp.JSON200) < perPage {
+			return registries, nil
+		}
+	}
+}
+
+// repositoryLookup returns the packages published from a source repository.
+type repositoryLookup func(ctx context.Context, url string) (*packages.LookupPackageResponse, error)
+
+func lookupRepositoryPackages(ctx context.Context, url string) (*packages.LookupPackageResponse, error) {
+	return LookupPackage(ctx, &packages.LookupPackageParams{RepositoryUrl: &url})
+}
+
+// resolveRegistryPackage returns the ecosyste.ms registry and package name for
+// a purl. Purls which identify a source repository rather than a registry
+// package are resolved by looking up packages published from the repository
+// with lookup, picking the one named like the purl.
+func resolveRegistryPackage(ctx context.Context, purl packageurl.PackageURL, lookup repositoryLookup) (string, string, error) {
+	repoURL := purlToRepositoryURL(purl)
+	if repoURL == "" {
+		return purlToEcosystemsRegistry(purl), purlToEcosystemsName(purl), nil
+	}
+
+	resp, err := lookup(ctx, repoURL)
+	if err != nil {
+		return "", "", err
+	}
+	if resp.JSON200 == nil || len(*resp.JSON200) == 0 {
+		return "", "", fmt.Errorf("no package found for repository %s", repoURL)
+	}
+
+	pkg := matchLookupResult(*resp.JSON200, purl.Name)
+	if pkg == nil {
+		return "", "", fmt.Errorf("no unambiguous package found for repository %s", repoURL)
+	}
+	return pkg.Registry.Name, pkg.Name, nil
+}
+
+// isSupportedPurl reports whether ecosyste.ms can be queried for a purl.
+func isSupportedPurl(purl packageurl.PackageURL) bool {
+	return purlToEcosystemsRegistry(purl) != "" || purlToRepositoryURL(purl) != ""
+}
+
+// purlToRepositoryURL returns the source repository identified by purls of
+// the repository based types, or by the vcs_url qualifier of generic purls.
+func purlToRepositoryURL(purl packageurl.PackageURL) string {
+	if purl.Namespace == "" && purl.Type != packageurl.TypeGeneric {
+		return ""
+	}
+
+	switch purl.Type {
+	case packageurl.TypeGithub:
+		return fmt.Sprintf("https://github.com/%s/%s", purl.Namespace, purl.Name)
+	case packageurl.TypeGitlab:
+		return fmt.Sprintf("https://gitlab.com/%s/%s", purl.Namespace, purl.Name)
+	case packageurl.TypeBitbucket:
+		return fmt.Sprintf("https://bitbucket.org/%s/%s", purl.Namespace, purl.Name)
+	case packageurl.TypeGeneric:
+		return repourl.Normalize(purl.Qualifiers.Map()["vcs_url"])
+	}
+	return ""
+}
+
+// distroCodenames maps distribution release codenames to their versions,
+// which is how ecosyste.ms names distribution registries.
+var distroCodenames = map[string]map[string]string{
+	"debian": {
+		"buster":   "10",
+		"bullseye": "11",
+		"bookworm": "12",
+		"trixie":   "13",
+	},
+	"ubuntu": {
+		"focal":    "20.04",
+		"jammy":    "22.04",
+		"noble":    "24.04",
+		"oracular": "24.10",
+	},
+}
+
+// distroRegistry returns the registry of a distribution package from the
+// distro qualifier, e.g. pkg:deb/debian/curl?distro=bookworm is looked up in
+// the debian-12 registry.
+func distroRegistry(purl packageurl.PackageURL) string {
+	distro := strings.ToLower(purl.Qualifiers.Map()["distro"])
+	if distro == "" {
+		return ""
+	}
+
+	namespace := strings.ToLower(purl.Namespace)
+	if purl.Type == packageurl.TypeApk {
+		namespace = "alpine"
+	}
+	if namespace == "" {
+		return distro
+	}
+
+	distro = strings.TrimPrefix(distro, namespace+"-")
+	if version, ok := distroCodenames[namespace][distro]; ok {
+		distro = version
+	}
+
+	if purl.Type == packageurl.TypeApk && distro != "edge" {
+		return "alpine-v" + strings.TrimPrefix(distro, "v")
+	}
+	return namespace + "-" + distro
+}
+
+// ociRepository returns the host and path of an OCI purl's repository_url
+// qualifier, e.g. docker.io/library/debian.
+func ociRepository(purl packageurl.PackageURL) (string, string) {
+	repo := purl.Qualifiers.Map()["repository_url"]
+	if repo == "" {
+		return "", ""
+	}
+	host, path, _ := strings.Cut(repo, "/")
+	return host, path
+}
+
+func purlToEcosystemsVersion(purl packageurl.PackageURL) string {
+	if purl.Type == packageurl.TypeOCI {
+		if tag := purl.Qualifiers.Map()["tag"]; tag != "" {
+			return tag
+		}
+	}
+	return purl.Version
+}
+
 func repositoryURLfromPurl(purl packageurl.PackageURL) *url.URL {
 	if len(purl.Qualifiers) > 0 {
 		qualifiersMap := purl.Qualifiers.Map()
@@ -90,6 +257,18 @@ func repositoryURLfromPurl(purl packageurl.PackageURL) *url.URL {
 }
 
 func purlToEcosystemsRegistry(purl packageurl.PackageURL) string {
+	switch purl.Type {
+	case packageurl.TypeApk, packageurl.TypeDebian, packageurl.TypeRPM:
+		if registry := distroRegistry(purl); registry != "" {
+			return registry
+		}
+	case packageurl.TypeOCI:
+		switch host, _ := ociRepository(purl); host {
+		case "", "docker.io", "index.docker.io", "registry-1.docker.io":
+			return "hub.docker.com"
+		}
+		return ""
+	}
 	if repoURL := repositoryURLfromPurl(purl); repoURL != nil {
 		return repoURL.Host
 	}
@@ -108,6 +287,7 @@ func purlToEcosystemsRegistry(purl packageurl.PackageURL) string {
 		packageurl.TypeDocker:    "hub.docker.com",
 		packageurl.TypeElm:       "package.elm-lang.org",
 		packageurl.TypeGem:       "rubygems.org",
+		purlTypeGithubActions:    "github actions",
 		packageurl.TypeGolang:    "proxy.golang.org",
 		packageurl.TypeHackage:   "hackage.haskell.org",
 		packageurl.TypeHex:       "hex.pm",
@@ -125,6 +305,13 @@ func purlToEcosystemsRegistry(purl packageurl.PackageURL) string {
 func purlToEcosystemsName(purl packageurl.PackageURL) string {
 	name := purl.Name
 
+	if purl.Type == packageurl.TypeOCI {
+		if _, path := ociRepository(purl); path != "" {
+			return path
+		}
+		return "library/" + name
+	}
+
 	if purl.Namespace == "" {
 		return name
 	}
@@ -140,9 +327,10 @@ func purlToEcosystemsName(purl packageurl.PackageURL) string {
 	case packageurl.TypeMaven:
 		name = fmt.Sprintf("%s:%s", purl.Namespace, purl.Name)
 
-	// apk packages are only used by alpine, so the namespace isn't used in the
-	// package name for the ecosyste.ms API
-	case packageurl.TypeApk:
+	// apk, deb and rpm packages are looked up in the registry of their
+	// distribution, so the namespace isn't used in the package name for the
+	// ecosyste.ms API
+	case packageurl.TypeApk, packageurl.TypeDebian, packageurl.TypeRPM:
 		break
 	}
 	return name
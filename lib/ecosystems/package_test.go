@@ -17,6 +17,7 @@
 package ecosystems
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
@@ -40,7 +41,7 @@ func TestGetPackageData(t *testing.T) {
 	purl, err := packageurl.FromString("pkg:maven/org.springframework.boot/spring-boot-starter-jdb")
 	require.NoError(t, err)
 
-	_, err = GetPackageData(purl)
+	_, err = GetPackageData(context.Background(), purl)
 	require.NoError(t, err)
 
 	httpmock.GetTotalCallCount()
@@ -65,7 +66,7 @@ func TestGetPackageDataUserAgent(t *testing.T) {
 	purl, err := packageurl.FromString("pkg:npm/lodash@4.17.21")
 	require.NoError(t, err)
 
-	_, err = GetPackageData(purl)
+	_, err = GetPackageData(context.Background(), purl)
 	require.NoError(t, err)
 
 	expectedUserAgent := fmt.Sprintf("parlay (%s)", Version)
@@ -93,7 +94,7 @@ func TestGetPackageVersionDataUserAgent(t *testing.T) {
 	purl, err := packageurl.FromString("pkg:npm/lodash@4.17.21")
 	require.NoError(t, err)
 
-	_, err = GetPackageVersionData(purl)
+	_, err = GetPackageVersionData(context.Background(), purl)
 	require.NoError(t, err)
 
 	assert.Equal(t, fmt.Sprintf("parlay (%s)", Version), capturedUserAgent)
@@ -130,6 +131,19 @@ func TestPurlToEcosystemsRegistry(t *testing.T) {
 		{"pkg:julia/Example@0.5.3", "juliahub.com"},
 		{"pkg:pub/http@0.13.5", "pub.dev"},
 		{"pkg:puppet/puppetlabs/stdlib@8.5.0", "forge.puppet.com"},
+		{"pkg:apk/alpine/curl@8.5.0-r0?distro=3.19", "alpine-v3.19"},
+		{"pkg:apk/alpine/curl@8.5.0-r0?distro=alpine-3.19", "alpine-v3.19"},
+		{"pkg:deb/debian/curl@7.88.1-10?distro=bookworm", "debian-12"},
+		{"pkg:deb/debian/curl@7.88.1-10?distro=debian-12", "debian-12"},
+		{"pkg:deb/ubuntu/curl@7.81.0-1?distro=jammy", "ubuntu-22.04"},
+		{"pkg:deb/debian/curl@7.88.1-10", ""},
+		{"pkg:rpm/fedora/curl@8.2.1-3.fc39?distro=fedora-39", "fedora-39"},
+		{"pkg:githubactions/actions/checkout@v4", "github actions"},
+		{"pkg:oci/debian@sha256%3A244fd47e07d10?tag=bookworm", "hub.docker.com"},
+		{"pkg:oci/debian@sha256%3A244fd47e07d10?repository_url=docker.io/library/debian", "hub.docker.com"},
+		{"pkg:oci/app@sha256%3A244fd47e07d10?repository_url=ghcr.io/acme/app", ""},
+		{"pkg:github/snyk/parlay@v0.1.0", ""},
+		{"pkg:swid/Acme/example.com/Enterprise+Server@1.0.0?tag_id=75b8c285-fa7b-485b-b199-4745e3004d0d", ""},
 	}
 
 	for _, tc := range testCases {
@@ -201,6 +215,26 @@ func TestPurlToEcosystemsName(t *testing.T) {
 			purlStr:      "pkg:apk/alpine/lf@30-r3",
 			expectedName: "lf",
 		},
+		{
+			// Test case 9: deb packages are named without their distribution
+			purlStr:      "pkg:deb/debian/curl@7.88.1-10?distro=bookworm",
+			expectedName: "curl",
+		},
+		{
+			// Test case 10: GitHub Actions are named "<owner>/<repo>"
+			purlStr:      "pkg:githubactions/actions/checkout@v4",
+			expectedName: "actions/checkout",
+		},
+		{
+			// Test case 11: OCI images are named by their repository path
+			purlStr:      "pkg:oci/debian@sha256%3A244fd47e07d10?repository_url=docker.io/library/debian",
+			expectedName: "library/debian",
+		},
+		{
+			// Test case 12: OCI images without a repository are official images
+			purlStr:      "pkg:oci/debian@sha256%3A244fd47e07d10",
+			expectedName: "library/debian",
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -217,3 +251,119 @@ func TestPurlToEcosystemsName(t *testing.T) {
 		})
 	}
 }
+
+func TestPurlToRepositoryURL(t *testing.T) {
+	testCases := []struct {
+		purlStr  string
+		expected string
+	}{
+		{"pkg:github/snyk/parlay@v0.1.0", "https://github.com/snyk/parlay"},
+		{"pkg:gitlab/gitlab-org/gitlab-runner@v16.0.0", "https://gitlab.com/gitlab-org/gitlab-runner"},
+		{"pkg:bitbucket/birkenfeld/pygments-main@244fd47e07d10", "https://bitbucket.org/birkenfeld/pygments-main"},
+		{"pkg:generic/openssl@1.1.1?vcs_url=git%2Bhttps://github.com/openssl/openssl.git", "https://github.com/openssl/openssl"},
+		{"pkg:generic/openssl@1.1.1", ""},
+		{"pkg:npm/lodash@4.17.21", ""},
+	}
+
+	for _, tc := range testCases {
+		purl, err := packageurl.FromString(tc.purlStr)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, purlToRepositoryURL(purl), tc.purlStr)
+	}
+}
+
+func TestGetPackageData_RepositoryPurl(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://packages.ecosyste.ms/api/v1/packages/lookup",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "https://github.com/spf13/cobra", req.URL.Query().Get("repository_url"))
+			return httpmock.NewJsonResponse(200, []map[string]interface{}{
+				{"name": "github.com/spf13/cobra", "registry": map[string]interface{}{"name": "proxy.golang.org"}},
+			})
+		})
+	httpmock.RegisterResponder("GET", "https://packages.ecosyste.ms/api/v1/registries/proxy.golang.org/packages/github.com%2Fspf13%2Fcobra",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{"name": "github.com/spf13/cobra"}))
+
+	purl, err := packageurl.FromString("pkg:github/spf13/cobra@v1.7.0")
+	require.NoError(t, err)
+
+	resp, err := GetPackageData(context.Background(), purl)
+	require.NoError(t, err)
+	require.NotNil(t, resp.JSON200)
+	assert.Equal(t, "github.com/spf13/cobra", resp.JSON200.Name)
+}
+
+func TestGetPackageData_RepositoryPurlMatchesName(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://packages.ecosyste.ms/api/v1/packages/lookup",
+		httpmock.NewJsonResponderOrPanic(200, []map[string]interface{}{
+			{"name": "@acme/tools-core", "registry": map[string]interface{}{"name": "npmjs.org"}},
+			{"name": "github.com/acme/tools", "registry": map[string]interface{}{"name": "proxy.golang.org"}},
+		}))
+	httpmock.RegisterResponder("GET", "https://packages.ecosyste.ms/api/v1/registries/proxy.golang.org/packages/github.com%2Facme%2Ftools",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{"name": "github.com/acme/tools"}))
+	httpmock.RegisterResponder("GET", "https://packages.ecosyste.ms/api/v1/registries/proxy.golang.org/packages/github.com%2Facme%2Ftools/versions/v1.0.0",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{"number": "v1.0.0"}))
+
+	purl, err := packageurl.FromString("pkg:github/acme/tools@v1.0.0")
+	require.NoError(t, err)
+
+	cache := NewInMemoryCache()
+	resp, err := cache.GetPackageData(context.Background(), purl)
+	require.NoError(t, err)
+	require.NotNil(t, resp.JSON200)
+	assert.Equal(t, "github.com/acme/tools", resp.JSON200.Name)
+
+	versionResp, err := cache.GetPackageVersionData(context.Background(), purl)
+	require.NoError(t, err)
+	require.NotNil(t, versionResp.JSON200)
+
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 1, calls["GET https://packages.ecosyste.ms/api/v1/packages/lookup"], "repository lookup is shared")
+}
+
+func TestGetPackageData_RepositoryPurlAmbiguous(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://packages.ecosyste.ms/api/v1/packages/lookup",
+		httpmock.NewJsonResponderOrPanic(200, []map[string]interface{}{
+			{"name": "acme-cli", "registry": map[string]interface{}{"name": "npmjs.org"}},
+			{"name": "acme-sdk", "registry": map[string]interface{}{"name": "pypi.org"}},
+		}))
+
+	purl, err := packageurl.FromString("pkg:github/acme/tools@v1.0.0")
+	require.NoError(t, err)
+
+	_, err = GetPackageData(context.Background(), purl)
+	assert.ErrorContains(t, err, "no unambiguous package found")
+}
+
+func TestGetRegistries(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://packages.ecosyste.ms/api/v1/registries",
+		func(req *http.Request) (*http.Response, error) {
+			registries := []map[string]interface{}{}
+			if req.URL.Query().Get("page") == "1" {
+				for i := 0; i < 100; i++ {
+					registries = append(registries, map[string]interface{}{"name": fmt.Sprintf("registry-%d", i)})
+				}
+			} else {
+				registries = append(registries, map[string]interface{}{"name": "npmjs.org", "purl_type": "npm"})
+			}
+			return httpmock.NewJsonResponse(200, registries)
+		})
+
+	registries, err := GetRegistries(context.Background())
+	require.NoError(t, err)
+
+	assert.Len(t, registries, 101)
+	assert.Equal(t, "npmjs.org", registries[100].Name)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
@@ -0,0 +1,85 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ecosystems
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/snyk/parlay/ecosystems/packages"
+)
+
+type property struct {
+	name  string
+	value string
+}
+
+// popularityProperties returns the adoption signals ecosyste.ms reports for a
+// package, as namespaced name/value pairs.
+func popularityProperties(data *packages.Package) []property {
+	var props []property
+
+	if hasDownloadStats(data) {
+		props = append(props, property{"ecosystems:downloads", strconv.Itoa(data.Downloads)})
+		if data.DownloadsPeriod != nil && *data.DownloadsPeriod != "" {
+			props = append(props, property{"ecosystems:downloads_period", *data.DownloadsPeriod})
+		}
+	}
+
+	props = append(props,
+		property{"ecosystems:dependent_packages_count", strconv.Itoa(data.DependentPackagesCount)},
+		property{"ecosystems:dependent_repos_count", strconv.Itoa(data.DependentReposCount)},
+	)
+
+	keys := make([]string, 0, len(data.Rankings))
+	for k := range data.Rankings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if rank, ok := data.Rankings[k].(float64); ok {
+			props = append(props, property{"ecosystems:ranking:" + k, strconv.FormatFloat(rank, 'f', -1, 64)})
+		}
+	}
+
+	if data.Critical {
+		props = append(props, property{"ecosystems:critical", "true"})
+	}
+
+	return props
+}
+
+// lowAdoptionReasons returns the names of the signals for which the package
+// falls below the thresholds set in cfg. Download counts are only considered
+// for registries which report them.
+func lowAdoptionReasons(cfg *Config, data *packages.Package) []string {
+	var reasons []string
+	if cfg.MinDownloads > 0 && hasDownloadStats(data) && data.Downloads < cfg.MinDownloads {
+		reasons = append(reasons, "downloads")
+	}
+	if cfg.MinDependentPackages > 0 && data.DependentPackagesCount < cfg.MinDependentPackages {
+		reasons = append(reasons, "dependent_packages_count")
+	}
+	if cfg.MinDependentRepos > 0 && data.DependentReposCount < cfg.MinDependentRepos {
+		reasons = append(reasons, "dependent_repos_count")
+	}
+	return reasons
+}
+
+func hasDownloadStats(data *packages.Package) bool {
+	return data.Downloads > 0 || data.DownloadsPeriod != nil
+}
@@ -0,0 +1,61 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ecosystems
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// provenance describes where the data of an enriched field was taken from.
+type provenance struct {
+	Source    string `json:"source"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	FetchedAt string `json:"fetched_at"`
+}
+
+// responseProvenance describes the ecosyste.ms response data was taken from.
+// The fetch time is taken from the Date header of the response, if present.
+func responseProvenance(source string, resp *http.Response) *provenance {
+	p := &provenance{Source: source}
+
+	fetchedAt := time.Now()
+	if resp != nil {
+		if resp.Request != nil && resp.Request.URL != nil {
+			p.Endpoint = resp.Request.URL.String()
+		}
+		if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+			fetchedAt = date
+		}
+	}
+	p.FetchedAt = fetchedAt.UTC().Format(time.RFC3339)
+
+	return p
+}
+
+func provenancePropertyName(field string) string {
+	return "parlay:provenance:" + field
+}
+
+func (p *provenance) String() string {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return p.Source
+	}
+	return string(b)
+}
@@ -18,26 +18,89 @@ package ecosystems
 
 import (
 	"context"
-	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/snyk/parlay/ecosystems/packages"
 	"github.com/snyk/parlay/ecosystems/repos"
+	"github.com/snyk/parlay/internal/repourl"
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/report"
 )
 
-const repos_server = "https://repos.ecosyste.ms/api/v1"
-
-func GetRepoData(url string) (*repos.RepositoriesLookupResponse, error) {
-	client, err := repos.NewClientWithResponses(repos_server,
-		repos.WithRequestEditorFn(func(ctx context.Context, req *http.Request) error {
-			req.Header.Set("User-Agent", getUserAgent())
-			return nil
-		}))
+func GetRepoData(ctx context.Context, url string) (*repos.RepositoriesLookupResponse, error) {
+	client, err := newReposClient()
 	if err != nil {
 		return nil, err
 	}
 	params := repos.RepositoriesLookupParams{Url: &url}
-	resp, err := client.RepositoriesLookupWithResponse(context.Background(), &params)
+	resp, err := client.RepositoriesLookupWithResponse(ctx, &params)
 	if err != nil {
 		return nil, err
 	}
 	return resp, nil
 }
+
+// repositoryProperties returns the repository facts repos.ecosyste.ms reports
+// for a repository, as namespaced name/value pairs.
+func repositoryProperties(repo *repos.Repository) []property {
+	var props []property
+
+	addInt := func(name string, v *int) {
+		if v != nil {
+			props = append(props, property{name, strconv.Itoa(*v)})
+		}
+	}
+	addString := func(name string, v *string) {
+		if v != nil && *v != "" {
+			props = append(props, property{name, *v})
+		}
+	}
+	addTime := func(name string, v *time.Time) {
+		if v != nil {
+			props = append(props, property{name, v.UTC().Format(time.RFC3339)})
+		}
+	}
+	addFlag := func(name string, v *bool) {
+		if v != nil && *v {
+			props = append(props, property{name, "true"})
+		}
+	}
+
+	addInt("ecosystems:repository_stars", repo.StargazersCount)
+	addInt("ecosystems:repository_forks", repo.ForksCount)
+	addInt("ecosystems:repository_open_issues", repo.OpenIssuesCount)
+	addTime("ecosystems:repository_pushed_at", repo.PushedAt)
+	addString("ecosystems:repository_default_branch", repo.DefaultBranch)
+	addString("ecosystems:repository_license", repo.License)
+	addFlag("ecosystems:repository_fork", repo.Fork)
+	addFlag("ecosystems:repository_template", repo.Template)
+	addString("ecosystems:repository_latest_tag", repo.LatestTagName)
+	addTime("ecosystems:repository_latest_tag_published_at", repo.LatestTagPublishedAt)
+
+	return props
+}
+
+// lookupRepository resolves the repository of a package through
+// repos.ecosyste.ms. It returns nil when the package has no repository URL or
+// the repository is unknown.
+func lookupRepository(ctx context.Context, cfg *Config, cache Cache, data *packages.Package, out *report.Outcome) (*repos.Repository, *provenance, error) {
+	if data.RepositoryUrl == nil || *data.RepositoryUrl == "" {
+		return nil, nil, nil
+	}
+
+	ctx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+	defer cancel()
+
+	repoURL := *data.RepositoryUrl
+	if u := repourl.Normalize(repoURL); u != "" {
+		repoURL = u
+	}
+	resp, err := cache.GetRepoData(ctx, repoURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	out.Response(resp.HTTPResponse)
+
+	return resp.JSON200, responseProvenance(reposSource, resp.HTTPResponse), nil
+}
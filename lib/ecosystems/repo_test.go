@@ -17,6 +17,7 @@
 package ecosystems
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
@@ -36,7 +37,7 @@ func TestGetRepoData(t *testing.T) {
 		httpmock.NewBytesResponder(200, []byte{}),
 	)
 
-	_, err := GetRepoData("https://github.com/golang/go")
+	_, err := GetRepoData(context.Background(), "https://github.com/golang/go")
 	require.NoError(t, err)
 
 	httpmock.GetTotalCallCount()
@@ -58,7 +59,7 @@ func TestGetRepoDataUserAgent(t *testing.T) {
 		},
 	)
 
-	_, err := GetRepoData("https://github.com/golang/go")
+	_, err := GetRepoData(context.Background(), "https://github.com/golang/go")
 	require.NoError(t, err)
 
 	assert.Equal(t, fmt.Sprintf("parlay (%s)", Version), capturedUserAgent)
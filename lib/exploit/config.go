@@ -0,0 +1,58 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package exploit
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/snyk/parlay/lib/report"
+)
+
+// reportProvider names exploitability enrichment in reports.
+const reportProvider = "exploit"
+
+const (
+	// DefaultEPSSURL serves the latest EPSS scores of all CVEs.
+	DefaultEPSSURL = "https://epss.cyentia.com/epss_scores-current.csv.gz"
+
+	// DefaultKEVURL serves the CISA Known Exploited Vulnerabilities catalog.
+	DefaultKEVURL = "https://www.cisa.gov/sites/default/files/feeds/known_exploited_vulnerabilities.json"
+)
+
+// Config controls how SBOMs are enriched with exploitability data.
+type Config struct {
+	// EPSS, if set, holds the scores recorded for each vulnerability.
+	EPSS *EPSS
+
+	// KEV, if set, is the catalog each vulnerability is looked up in.
+	KEV *KEV
+
+	// Header is added to the requests made to download EPSS scores or the
+	// KEV catalog.
+	Header http.Header
+
+	// RequestTimeout bounds each download. A zero value leaves downloads
+	// bound only by their context.
+	RequestTimeout time.Duration
+
+	// Report, if set, collects the outcome of enriching each vulnerability.
+	Report *report.Report
+}
+
+func DefaultConfig() *Config {
+	return &Config{}
+}
@@ -0,0 +1,197 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package exploit
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+const (
+	EPSSPropertyPrefix = "epss:"
+	KEVPropertyPrefix  = "kev:"
+)
+
+const (
+	// PropertyEPSSProbability is the probability of exploitation in the
+	// next 30 days, between 0 and 1.
+	PropertyEPSSProbability = EPSSPropertyPrefix + "probability"
+
+	// PropertyEPSSPercentile ranks the probability among all scored CVEs,
+	// between 0 and 1.
+	PropertyEPSSPercentile = EPSSPropertyPrefix + "percentile"
+
+	// PropertyEPSSDate is the date the score was computed on.
+	PropertyEPSSDate = EPSSPropertyPrefix + "score_date"
+
+	// PropertyEPSSCVE is the CVE scored. Of several CVEs of a vulnerability,
+	// the one most likely to be exploited is used.
+	PropertyEPSSCVE = EPSSPropertyPrefix + "cve"
+
+	// PropertyKEVListed is true if the vulnerability is in the KEV catalog,
+	// that is known to be exploited, and false otherwise.
+	PropertyKEVListed = KEVPropertyPrefix + "listed"
+
+	// PropertyKEVDateAdded is the date the vulnerability was added to the
+	// catalog.
+	PropertyKEVDateAdded = KEVPropertyPrefix + "date_added"
+
+	// PropertyKEVDueDate is the date US federal agencies are required to
+	// remediate the vulnerability by.
+	PropertyKEVDueDate = KEVPropertyPrefix + "due_date"
+
+	// PropertyKEVRansomware is Known if the vulnerability is known to be used
+	// in ransomware campaigns, and Unknown otherwise.
+	PropertyKEVRansomware = KEVPropertyPrefix + "ransomware_use"
+
+	// PropertyKEVCVE is the CVE listed in the catalog.
+	PropertyKEVCVE = KEVPropertyPrefix + "cve"
+)
+
+// EnrichSBOM records the EPSS scores and KEV status of each vulnerability
+// of a CycloneDX SBOM which is, or refers to, a CVE. SPDX documents have no
+// vulnerabilities and are left as they are. If ctx is cancelled, the
+// remaining vulnerabilities are skipped.
+func EnrichSBOM(ctx context.Context, cfg *Config, doc *sbom.SBOMDocument) *sbom.SBOMDocument {
+	bom, ok := doc.BOM.(*cdx.BOM)
+	if !ok || bom.Vulnerabilities == nil {
+		return doc
+	}
+
+	for i := range *bom.Vulnerabilities {
+		vuln := &(*bom.Vulnerabilities)[i]
+		out := cfg.Report.Outcome(reportProvider, vuln.ID, "")
+		if err := ctx.Err(); err != nil {
+			out.Skip("enrichment interrupted: " + err.Error())
+			continue
+		}
+
+		cves := cveIDs(vuln)
+		if len(cves) == 0 {
+			out.Skip("no CVE reference")
+			continue
+		}
+		if cfg.EPSS != nil {
+			before, after := replaceProperties(vuln, EPSSPropertyPrefix, cfg.EPSS.properties(cves))
+			out.RecordChange("epss", before, after)
+		}
+		if cfg.KEV != nil {
+			before, after := replaceProperties(vuln, KEVPropertyPrefix, cfg.KEV.properties(cves))
+			out.RecordChange("kev", before, after)
+		}
+	}
+	return doc
+}
+
+// cveIDs returns the CVEs a vulnerability is identified by, either as its
+// ID or as the ID of one of its references.
+func cveIDs(vuln *cdx.Vulnerability) []string {
+	var ids []string
+	add := func(id string) {
+		id = strings.ToUpper(strings.TrimSpace(id))
+		if strings.HasPrefix(id, "CVE-") && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	add(vuln.ID)
+	if vuln.References != nil {
+		for _, ref := range *vuln.References {
+			add(ref.ID)
+		}
+	}
+	return ids
+}
+
+func (e *EPSS) properties(cves []string) []cdx.Property {
+	var best string
+	var score Score
+	for _, cve := range cves {
+		if s, ok := e.Lookup(cve); ok && (best == "" || s.Probability > score.Probability) {
+			best, score = cve, s
+		}
+	}
+	if best == "" {
+		return nil
+	}
+
+	props := []cdx.Property{
+		{Name: PropertyEPSSProbability, Value: formatScore(score.Probability)},
+		{Name: PropertyEPSSPercentile, Value: formatScore(score.Percentile)},
+	}
+	if e.ScoreDate != "" {
+		props = append(props, cdx.Property{Name: PropertyEPSSDate, Value: e.ScoreDate})
+	}
+	return append(props, cdx.Property{Name: PropertyEPSSCVE, Value: best})
+}
+
+func formatScore(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func (k *KEV) properties(cves []string) []cdx.Property {
+	for _, cve := range cves {
+		entry, ok := k.Lookup(cve)
+		if !ok {
+			continue
+		}
+		props := []cdx.Property{
+			{Name: PropertyKEVListed, Value: "true"},
+			{Name: PropertyKEVDateAdded, Value: entry.DateAdded},
+		}
+		if entry.DueDate != "" {
+			props = append(props, cdx.Property{Name: PropertyKEVDueDate, Value: entry.DueDate})
+		}
+		if entry.KnownRansomwareCampaignUse != "" {
+			props = append(props, cdx.Property{Name: PropertyKEVRansomware, Value: entry.KnownRansomwareCampaignUse})
+		}
+		return append(props, cdx.Property{Name: PropertyKEVCVE, Value: cve})
+	}
+	return []cdx.Property{{Name: PropertyKEVListed, Value: "false"}}
+}
+
+// replaceProperties replaces the properties of a vulnerability whose names
+// start with prefix, returning those it had before and has after. Existing
+// properties are kept if there are no new ones.
+func replaceProperties(vuln *cdx.Vulnerability, prefix string, props []cdx.Property) (before, after []string) {
+	if len(props) == 0 {
+		return nil, nil
+	}
+
+	var kept []cdx.Property
+	if vuln.Properties != nil {
+		for _, p := range *vuln.Properties {
+			if strings.HasPrefix(p.Name, prefix) {
+				before = append(before, p.Name+"="+p.Value)
+				continue
+			}
+			kept = append(kept, p)
+		}
+	}
+
+	for _, p := range props {
+		kept = append(kept, p)
+		after = append(after, p.Name+"="+p.Value)
+	}
+	vuln.Properties = &kept
+	return before, after
+}
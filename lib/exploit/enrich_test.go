@@ -0,0 +1,145 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package exploit
+
+import (
+	"context"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/snyk/parlay/lib/report"
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	var err error
+	cfg.EPSS, err = LoadEPSS(context.Background(), cfg, "testdata/epss.csv")
+	require.NoError(t, err)
+	cfg.KEV, err = LoadKEV(context.Background(), cfg, "testdata/kev.json")
+	require.NoError(t, err)
+	cfg.Report = report.New()
+	return cfg
+}
+
+func TestEnrichSBOM(t *testing.T) {
+	bom := &cdx.BOM{
+		Vulnerabilities: &[]cdx.Vulnerability{
+			{
+				ID: "SNYK-JAVA-ORGAPACHELOGGINGLOG4J-2314720",
+				References: &[]cdx.VulnerabilityReference{
+					{ID: "CVE-2021-44228", Source: &cdx.Source{Name: "CVE"}},
+				},
+				Properties: &[]cdx.Property{
+					{Name: "other", Value: "kept"},
+					{Name: PropertyKEVListed, Value: "false"},
+				},
+			},
+			{
+				ID: "GHSA-aaaa-bbbb-cccc",
+				References: &[]cdx.VulnerabilityReference{
+					{ID: "CVE-2024-0001"},
+					{ID: "CVE-2024-0002"},
+				},
+			},
+			{ID: "CVE-2000-0001"},
+			{ID: "SNYK-JS-EXAMPLE-1"},
+		},
+	}
+
+	cfg := testConfig(t)
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	vulns := *bom.Vulnerabilities
+	assert.Equal(t, []cdx.Property{
+		{Name: "other", Value: "kept"},
+		{Name: PropertyEPSSProbability, Value: "0.97565"},
+		{Name: PropertyEPSSPercentile, Value: "0.99982"},
+		{Name: PropertyEPSSDate, Value: "2024-05-20T00:00:00+0000"},
+		{Name: PropertyEPSSCVE, Value: "CVE-2021-44228"},
+		{Name: PropertyKEVListed, Value: "true"},
+		{Name: PropertyKEVDateAdded, Value: "2021-12-10"},
+		{Name: PropertyKEVDueDate, Value: "2021-12-24"},
+		{Name: PropertyKEVRansomware, Value: "Known"},
+		{Name: PropertyKEVCVE, Value: "CVE-2021-44228"},
+	}, *vulns[0].Properties)
+
+	// Of several CVEs, the one most likely to be exploited is scored.
+	assert.Equal(t, []cdx.Property{
+		{Name: PropertyEPSSProbability, Value: "0.0125"},
+		{Name: PropertyEPSSPercentile, Value: "0.8512"},
+		{Name: PropertyEPSSDate, Value: "2024-05-20T00:00:00+0000"},
+		{Name: PropertyEPSSCVE, Value: "CVE-2024-0002"},
+		{Name: PropertyKEVListed, Value: "false"},
+	}, *vulns[1].Properties)
+
+	assert.Equal(t, []cdx.Property{{Name: PropertyKEVListed, Value: "false"}}, *vulns[2].Properties)
+	assert.Nil(t, vulns[3].Properties)
+
+	outcome := cfg.Report.Outcome(reportProvider, "SNYK-JAVA-ORGAPACHELOGGINGLOG4J-2314720", "")
+	assert.Equal(t, []string{"epss"}, outcome.FieldsAdded)
+	assert.Equal(t, []string{"kev"}, outcome.FieldsChanged)
+	outcome = cfg.Report.Outcome(reportProvider, "SNYK-JS-EXAMPLE-1", "")
+	assert.Equal(t, report.StatusSkipped, outcome.Status)
+	assert.Equal(t, "no CVE reference", outcome.Reason)
+}
+
+func TestEnrichSBOM_Twice(t *testing.T) {
+	bom := &cdx.BOM{
+		Vulnerabilities: &[]cdx.Vulnerability{{ID: "CVE-2021-44228"}},
+	}
+
+	EnrichSBOM(context.Background(), testConfig(t), &sbom.SBOMDocument{BOM: bom})
+	cfg := testConfig(t)
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	assert.Len(t, *(*bom.Vulnerabilities)[0].Properties, 9)
+	assert.Equal(t, report.StatusUnchanged, cfg.Report.Outcome(reportProvider, "CVE-2021-44228", "").Status)
+}
+
+func TestEnrichSBOM_OnlyKEV(t *testing.T) {
+	bom := &cdx.BOM{
+		Vulnerabilities: &[]cdx.Vulnerability{{ID: "CVE-2024-0001"}},
+	}
+
+	cfg := testConfig(t)
+	cfg.EPSS = nil
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	assert.Equal(t, []cdx.Property{{Name: PropertyKEVListed, Value: "false"}}, *(*bom.Vulnerabilities)[0].Properties)
+}
+
+func TestEnrichSBOM_Cancelled(t *testing.T) {
+	bom := &cdx.BOM{
+		Vulnerabilities: &[]cdx.Vulnerability{{ID: "CVE-2021-44228"}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cfg := testConfig(t)
+	EnrichSBOM(ctx, cfg, &sbom.SBOMDocument{BOM: bom})
+
+	assert.Nil(t, (*bom.Vulnerabilities)[0].Properties)
+	out := cfg.Report.Outcome(reportProvider, "CVE-2021-44228", "")
+	assert.Equal(t, report.StatusSkipped, out.Status)
+	assert.Equal(t, "enrichment interrupted: context canceled", out.Reason)
+}
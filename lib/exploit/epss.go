@@ -0,0 +1,119 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package exploit
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EPSS holds the Exploit Prediction Scoring System scores of CVEs, as
+// published by FIRST. See https://www.first.org/epss/.
+type EPSS struct {
+	// ModelVersion and ScoreDate are read from the comment heading the CSV,
+	// if any.
+	ModelVersion string
+	ScoreDate    string
+
+	scores map[string]Score
+}
+
+// Score is the EPSS score of a CVE.
+type Score struct {
+	// Probability is the probability of exploitation in the next 30 days.
+	Probability float64
+
+	// Percentile ranks the probability among all scored CVEs.
+	Percentile float64
+}
+
+// ReadEPSS reads EPSS scores in the CSV format FIRST publishes them in.
+func ReadEPSS(r io.Reader) (*EPSS, error) {
+	e := &EPSS{scores: make(map[string]Score)}
+
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(1); err == nil && b[0] == '#' {
+		line, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		for _, field := range strings.Split(strings.TrimSpace(line[1:]), ",") {
+			key, value, _ := strings.Cut(field, ":")
+			switch key {
+			case "model_version":
+				e.ModelVersion = value
+			case "score_date":
+				e.ScoreDate = value
+			}
+		}
+	}
+
+	cr := csv.NewReader(br)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read EPSS header: %w", err)
+	}
+	cveCol, epssCol, percentileCol := -1, -1, -1
+	for i, name := range header {
+		switch strings.TrimSpace(name) {
+		case "cve":
+			cveCol = i
+		case "epss":
+			epssCol = i
+		case "percentile":
+			percentileCol = i
+		}
+	}
+	if cveCol < 0 || epssCol < 0 || percentileCol < 0 {
+		return nil, errors.New("EPSS data needs cve, epss and percentile columns")
+	}
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read EPSS data: %w", err)
+		}
+		probability, err := strconv.ParseFloat(record[epssCol], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EPSS score for %s: %w", record[cveCol], err)
+		}
+		percentile, err := strconv.ParseFloat(record[percentileCol], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EPSS percentile for %s: %w", record[cveCol], err)
+		}
+		e.scores[strings.ToUpper(record[cveCol])] = Score{Probability: probability, Percentile: percentile}
+	}
+	return e, nil
+}
+
+// Len returns the number of CVEs scored.
+func (e *EPSS) Len() int {
+	return len(e.scores)
+}
+
+// Lookup returns the score of a CVE.
+func (e *EPSS) Lookup(cve string) (Score, bool) {
+	s, ok := e.scores[strings.ToUpper(cve)]
+	return s, ok
+}
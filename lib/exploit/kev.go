@@ -0,0 +1,77 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package exploit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// KEV is the CISA Known Exploited Vulnerabilities catalog. See
+// https://www.cisa.gov/known-exploited-vulnerabilities-catalog.
+type KEV struct {
+	CatalogVersion string
+	DateReleased   string
+
+	entries map[string]KEVEntry
+}
+
+// KEVEntry is a vulnerability of the catalog. Only the fields parlay uses
+// are decoded.
+type KEVEntry struct {
+	CVEID                      string `json:"cveID"`
+	VendorProject              string `json:"vendorProject"`
+	Product                    string `json:"product"`
+	VulnerabilityName          string `json:"vulnerabilityName"`
+	DateAdded                  string `json:"dateAdded"`
+	DueDate                    string `json:"dueDate"`
+	KnownRansomwareCampaignUse string `json:"knownRansomwareCampaignUse"`
+}
+
+// ReadKEV reads the KEV catalog in the JSON format CISA publishes it in.
+func ReadKEV(r io.Reader) (*KEV, error) {
+	var catalog struct {
+		CatalogVersion  string     `json:"catalogVersion"`
+		DateReleased    string     `json:"dateReleased"`
+		Vulnerabilities []KEVEntry `json:"vulnerabilities"`
+	}
+	if err := json.NewDecoder(r).Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("failed to decode KEV catalog: %w", err)
+	}
+
+	k := &KEV{
+		CatalogVersion: catalog.CatalogVersion,
+		DateReleased:   catalog.DateReleased,
+		entries:        make(map[string]KEVEntry, len(catalog.Vulnerabilities)),
+	}
+	for _, entry := range catalog.Vulnerabilities {
+		k.entries[strings.ToUpper(entry.CVEID)] = entry
+	}
+	return k, nil
+}
+
+// Len returns the number of vulnerabilities in the catalog.
+func (k *KEV) Len() int {
+	return len(k.entries)
+}
+
+// Lookup returns the catalog entry of a CVE.
+func (k *KEV) Lookup(cve string) (KEVEntry, bool) {
+	entry, ok := k.entries[strings.ToUpper(cve)]
+	return entry, ok
+}
@@ -0,0 +1,93 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package exploit
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/snyk/parlay/internal/httpclient"
+	"github.com/snyk/parlay/internal/utils"
+)
+
+// LoadEPSS reads EPSS scores from a file or an http(s) URL.
+func LoadEPSS(ctx context.Context, cfg *Config, location string) (*EPSS, error) {
+	var e *EPSS
+	err := load(ctx, cfg, location, func(r io.Reader) (err error) {
+		e, err = ReadEPSS(r)
+		return err
+	})
+	return e, err
+}
+
+// LoadKEV reads the KEV catalog from a file or an http(s) URL.
+func LoadKEV(ctx context.Context, cfg *Config, location string) (*KEV, error) {
+	var k *KEV
+	err := load(ctx, cfg, location, func(r io.Reader) (err error) {
+		k, err = ReadKEV(r)
+		return err
+	})
+	return k, err
+}
+
+// load opens location and passes its content to read, decompressing it
+// first if it is gzipped.
+func load(ctx context.Context, cfg *Config, location string, read func(io.Reader) error) error {
+	var r io.ReadCloser
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		ctx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			return err
+		}
+		httpclient.SetHeaders(req, cfg.Header)
+		resp, err := httpclient.Default().Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("unexpected response from %s: %s", req.URL.Host, resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(location)
+		if err != nil {
+			return err
+		}
+		r = f
+	}
+	defer r.Close()
+
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return read(gz)
+	}
+	return read(br)
+}
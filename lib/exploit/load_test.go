@@ -0,0 +1,123 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package exploit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadEPSS(t *testing.T) {
+	f, err := os.Open("testdata/epss.csv")
+	require.NoError(t, err)
+	defer f.Close()
+
+	e, err := ReadEPSS(f)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v2023.03.01", e.ModelVersion)
+	assert.Equal(t, "2024-05-20T00:00:00+0000", e.ScoreDate)
+	assert.Equal(t, 3, e.Len())
+	score, ok := e.Lookup("cve-2021-44228")
+	assert.True(t, ok)
+	assert.Equal(t, Score{Probability: 0.97565, Percentile: 0.99982}, score)
+	_, ok = e.Lookup("CVE-2000-0001")
+	assert.False(t, ok)
+}
+
+func TestReadEPSS_NoComment(t *testing.T) {
+	e, err := ReadEPSS(strings.NewReader("cve,epss,percentile\nCVE-2024-0001,0.5,0.9\n"))
+	require.NoError(t, err)
+
+	assert.Empty(t, e.ScoreDate)
+	assert.Equal(t, 1, e.Len())
+}
+
+func TestReadEPSS_Invalid(t *testing.T) {
+	_, err := ReadEPSS(strings.NewReader("cve,score\nCVE-2024-0001,0.5\n"))
+	assert.EqualError(t, err, "EPSS data needs cve, epss and percentile columns")
+
+	_, err = ReadEPSS(strings.NewReader("cve,epss,percentile\nCVE-2024-0001,high,0.9\n"))
+	assert.ErrorContains(t, err, "invalid EPSS score for CVE-2024-0001")
+}
+
+func TestReadKEV(t *testing.T) {
+	f, err := os.Open("testdata/kev.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	k, err := ReadKEV(f)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2024.05.20", k.CatalogVersion)
+	assert.Equal(t, 1, k.Len())
+	entry, ok := k.Lookup("CVE-2021-44228")
+	assert.True(t, ok)
+	assert.Equal(t, "2021-12-10", entry.DateAdded)
+	assert.Equal(t, "Known", entry.KnownRansomwareCampaignUse)
+}
+
+func TestLoadEPSS_Gzipped(t *testing.T) {
+	b, err := os.ReadFile("testdata/epss.csv")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	path := filepath.Join(t.TempDir(), "epss_scores-current.csv.gz")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+	e, err := LoadEPSS(context.Background(), DefaultConfig(), path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, e.Len())
+}
+
+func TestLoadKEV_URL(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	b, err := os.ReadFile("testdata/kev.json")
+	require.NoError(t, err)
+	httpmock.RegisterResponder("GET", DefaultKEVURL, httpmock.NewBytesResponder(http.StatusOK, b))
+
+	k, err := LoadKEV(context.Background(), DefaultConfig(), DefaultKEVURL)
+	require.NoError(t, err)
+	assert.Equal(t, 1, k.Len())
+}
+
+func TestLoadEPSS_Errors(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", DefaultEPSSURL, httpmock.NewStringResponder(http.StatusNotFound, ""))
+
+	_, err := LoadEPSS(context.Background(), DefaultConfig(), DefaultEPSSURL)
+	assert.EqualError(t, err, "unexpected response from epss.cyentia.com: 404")
+
+	_, err = LoadEPSS(context.Background(), DefaultConfig(), filepath.Join(t.TempDir(), "missing.csv"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,147 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/snyk/parlay/internal/httpclient"
+	"github.com/snyk/parlay/internal/utils"
+)
+
+// maxBatchSize is the most queries the OSV API accepts in a batch.
+const maxBatchSize = 1000
+
+type query struct {
+	Package   Package `json:"package"`
+	Version   string  `json:"version"`
+	PageToken string  `json:"page_token,omitempty"`
+}
+
+type batchResponse struct {
+	Results []struct {
+		Vulns []struct {
+			ID string `json:"id"`
+		} `json:"vulns"`
+		NextPageToken string `json:"next_page_token"`
+	} `json:"results"`
+}
+
+// queryBatch returns the IDs of the vulnerabilities affecting each package
+// version, in the order of queries. The responses are passed to onResponse
+// as they are received.
+func queryBatch(ctx context.Context, cfg *Config, queries []query, onResponse func(*http.Response)) ([][]string, error) {
+	ids := make([][]string, len(queries))
+
+	// Queries with more results than fit a response are repeated with the
+	// token of the next page, until every page was read.
+	pending := make([]int, len(queries))
+	for i := range pending {
+		pending[i] = i
+	}
+	for len(pending) > 0 {
+		n := min(len(pending), maxBatchSize)
+		batch := make([]query, n)
+		for j, i := range pending[:n] {
+			batch[j] = queries[i]
+		}
+
+		resp, err := postBatch(ctx, cfg, batch, onResponse)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Results) != n {
+			return nil, fmt.Errorf("unexpected number of results from OSV: %d for %d queries", len(resp.Results), n)
+		}
+
+		next := pending[n:]
+		for j, i := range pending[:n] {
+			result := resp.Results[j]
+			for _, v := range result.Vulns {
+				ids[i] = append(ids[i], v.ID)
+			}
+			if result.NextPageToken != "" {
+				queries[i].PageToken = result.NextPageToken
+				next = append(next, i)
+			}
+		}
+		pending = next
+	}
+	return ids, nil
+}
+
+func postBatch(ctx context.Context, cfg *Config, batch []query, onResponse func(*http.Response)) (*batchResponse, error) {
+	body, err := json.Marshal(map[string][]query{"queries": batch})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.endpoint("/querybatch"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	httpclient.SetHeaders(req, cfg.Header)
+	resp, err := httpclient.Default().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	onResponse(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from OSV: %s", resp.Status)
+	}
+	var result batchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode OSV response: %w", err)
+	}
+	return &result, nil
+}
+
+// GetVulnerability fetches an OSV record from the OSV API.
+func GetVulnerability(ctx context.Context, cfg *Config, id string) (*Vulnerability, *http.Response, error) {
+	ctx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.endpoint("/vulns/"+url.PathEscape(id)), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	httpclient.SetHeaders(req, cfg.Header)
+	resp, err := httpclient.Default().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp, fmt.Errorf("unexpected response from OSV: %s", resp.Status)
+	}
+	var v Vulnerability
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return nil, resp, fmt.Errorf("failed to decode %s: %w", id, err)
+	}
+	return &v, resp, nil
+}
@@ -0,0 +1,78 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const querybatchURL = "https://api.osv.dev/v1/querybatch"
+
+func TestQueryBatch_Pages(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var requests []map[string][]query
+	httpmock.RegisterResponder("POST", querybatchURL, func(req *http.Request) (*http.Response, error) {
+		var body map[string][]query
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		requests = append(requests, body)
+		if len(requests) == 1 {
+			return httpmock.NewStringResponse(http.StatusOK, `{"results": [
+				{"vulns": [{"id": "GHSA-aaaa-bbbb-cccc"}], "next_page_token": "next"},
+				{}
+			]}`), nil
+		}
+		return httpmock.NewStringResponse(http.StatusOK, `{"results": [
+			{"vulns": [{"id": "GHSA-gggg-hhhh-iiii"}]}
+		]}`), nil
+	})
+
+	responses := 0
+	ids, err := queryBatch(context.Background(), DefaultConfig(), []query{
+		{Package: Package{Ecosystem: "npm", Name: "example"}, Version: "1.0.0"},
+		{Package: Package{Ecosystem: "npm", Name: "other"}, Version: "1.0.0"},
+	}, func(*http.Response) { responses++ })
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{{"GHSA-aaaa-bbbb-cccc", "GHSA-gggg-hhhh-iiii"}, nil}, ids)
+	assert.Equal(t, 2, responses)
+	require.Len(t, requests, 2)
+	assert.Equal(t, []query{
+		{Package: Package{Ecosystem: "npm", Name: "example"}, Version: "1.0.0", PageToken: "next"},
+	}, requests[1]["queries"])
+}
+
+func TestQueryBatch_Error(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", querybatchURL, httpmock.NewStringResponder(http.StatusBadRequest, `{"code": 3}`))
+
+	_, err := queryBatch(context.Background(), DefaultConfig(), []query{
+		{Package: Package{Ecosystem: "npm", Name: "example"}, Version: "1.0.0"},
+	}, func(*http.Response) {})
+
+	assert.EqualError(t, err, "unexpected response from OSV: 400")
+}
@@ -0,0 +1,135 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// DB is an in-memory index of OSV records by the packages they affect.
+type DB struct {
+	packages map[packageKey][]*Vulnerability
+	count    int
+}
+
+// LoadDB reads the OSV records under path, which may be a JSON record, a
+// zip archive of records such as those in the OSV dump at
+// https://osv-vulnerabilities.storage.googleapis.com/<ecosystem>/all.zip, or
+// a directory holding any number of both. Withdrawn records are left out.
+func LoadDB(path string) (*DB, error) {
+	db := &DB{packages: make(map[packageKey][]*Vulnerability)}
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".zip":
+			return db.loadArchive(p)
+		case ".json":
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return db.load(p, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *DB) loadArchive(path string) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !strings.EqualFold(filepath.Ext(f.Name), ".json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s in %s: %w", f.Name, path, err)
+		}
+		err = db.load(path+"/"+f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load adds the record read from r, which is named name in errors.
+func (db *DB) load(name string, r io.Reader) error {
+	var v Vulnerability
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	if v.Withdrawn != "" {
+		return nil
+	}
+
+	db.count++
+	for _, a := range v.Affected {
+		key := newPackageKey(a.Package.Ecosystem, a.Package.Name)
+		if vulns := db.packages[key]; len(vulns) > 0 && vulns[len(vulns)-1] == &v {
+			continue
+		}
+		db.packages[key] = append(db.packages[key], &v)
+	}
+	return nil
+}
+
+// Len returns the number of records in the database.
+func (db *DB) Len() int {
+	return db.count
+}
+
+// Query returns the vulnerabilities affecting the package version a purl
+// identifies.
+func (db *DB) Query(purl packageurl.PackageURL) ([]Match, error) {
+	pkg, err := purlPackage(purl)
+	if err != nil {
+		return nil, err
+	}
+	key := newPackageKey(pkg.Ecosystem, pkg.Name)
+
+	var matches []Match
+	for _, v := range db.packages[key] {
+		if v.affects(key, purl.Version) {
+			matches = append(matches, Match{
+				Vulnerability: v,
+				Fixed:         v.fixedVersions(key, purl.Version),
+			})
+		}
+	}
+	return matches, nil
+}
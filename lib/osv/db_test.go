@@ -0,0 +1,116 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/package-url/packageurl-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDB(t *testing.T) {
+	db, err := LoadDB("testdata")
+	require.NoError(t, err)
+
+	// The withdrawn record is left out.
+	assert.Equal(t, 3, db.Len())
+}
+
+func TestLoadDB_Archive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "all.zip")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w := zip.NewWriter(f)
+	for _, name := range []string{"GHSA-aaaa-bbbb-cccc.json", "GHSA-dddd-eeee-ffff.json"} {
+		b, err := os.ReadFile(filepath.Join("testdata", "npm", name))
+		require.NoError(t, err)
+		entry, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = entry.Write(b)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	db, err := LoadDB(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, db.Len())
+	matches, err := db.Query(packageurl.PackageURL{Type: "npm", Name: "example", Version: "1.0.0"})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "GHSA-aaaa-bbbb-cccc", matches[0].Vulnerability.ID)
+}
+
+func TestLoadDB_InvalidRecord(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.json"), []byte("{"), 0o600))
+
+	_, err := LoadDB(dir)
+	assert.ErrorContains(t, err, "failed to decode")
+}
+
+func TestDB_Query(t *testing.T) {
+	db, err := LoadDB("testdata")
+	require.NoError(t, err)
+
+	tc := []struct {
+		purl  string
+		id    string
+		fixed []string
+	}{
+		{purl: "pkg:npm/example@1.0.0", id: "GHSA-aaaa-bbbb-cccc", fixed: []string{"1.2.3", "2.0.1"}},
+		{purl: "pkg:npm/example@1.2.3"},
+		{purl: "pkg:npm/example@2.0.0", id: "GHSA-aaaa-bbbb-cccc", fixed: []string{"2.0.1"}},
+		{purl: "pkg:npm/%40scope/example@1.0.0"},
+		{purl: "pkg:pypi/example_package@1.0.1", id: "PYSEC-2024-1"},
+		{purl: "pkg:pypi/example.package@1.1rc1", id: "PYSEC-2024-1"},
+		{purl: "pkg:pypi/example-package@1.1"},
+		{purl: "pkg:golang/github.com/example/module@v1.3.0", id: "GO-2024-0001", fixed: []string{"v1.4.0", "v1.5.2"}},
+		{purl: "pkg:golang/github.com/example/module@v1.4.1"},
+		{purl: "pkg:golang/github.com/example/module@v1.5.1", id: "GO-2024-0001", fixed: []string{"v1.5.2"}},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.purl, func(t *testing.T) {
+			purl, err := packageurl.FromString(tt.purl)
+			require.NoError(t, err)
+
+			matches, err := db.Query(purl)
+			require.NoError(t, err)
+			if tt.id == "" {
+				assert.Empty(t, matches)
+				return
+			}
+			require.Len(t, matches, 1)
+			assert.Equal(t, tt.id, matches[0].Vulnerability.ID)
+			assert.Equal(t, tt.fixed, matches[0].Fixed)
+		})
+	}
+}
+
+func TestDB_Query_UnsupportedType(t *testing.T) {
+	db, err := LoadDB("testdata")
+	require.NoError(t, err)
+
+	_, err = db.Query(packageurl.PackageURL{Type: "generic", Name: "example", Version: "1.0.0"})
+	assert.EqualError(t, err, `unsupported purl type "generic"`)
+}
@@ -0,0 +1,121 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+
+	"github.com/snyk/parlay/internal/version"
+)
+
+// ecosystem describes how packages of a purl type are named in OSV.
+type ecosystem struct {
+	name string
+
+	// separator joins the namespace and name of a purl.
+	separator string
+
+	// normalize, if set, maps equivalent package names to the same name.
+	normalize func(string) string
+
+	// versionPrefix is left out of versions in OSV records, such as the v
+	// of Go module versions.
+	versionPrefix string
+
+	// compare orders versions as the ecosystem does. Ecosystems without
+	// one are compared on a best-effort basis.
+	compare func(a, b string) int
+}
+
+var ecosystems = map[string]ecosystem{
+	packageurl.TypeCargo:    {name: "crates.io", compare: compareSemVer},
+	packageurl.TypeComposer: {name: "Packagist", separator: "/", normalize: strings.ToLower},
+	packageurl.TypeGem:      {name: "RubyGems", compare: compareRubyGems},
+	packageurl.TypeGolang:   {name: "Go", separator: "/", versionPrefix: "v", compare: compareSemVer},
+	packageurl.TypeHex:      {name: "Hex", compare: compareSemVer},
+	packageurl.TypeMaven:    {name: "Maven", separator: ":", compare: compareMaven},
+	packageurl.TypeNPM:      {name: "npm", separator: "/", compare: compareSemVer},
+	packageurl.TypeNuget:    {name: "NuGet", normalize: strings.ToLower},
+	"pub":                   {name: "Pub", compare: compareSemVer},
+	packageurl.TypePyPi:     {name: "PyPI", normalize: normalizePyPIName, compare: comparePEP440},
+}
+
+var pypiSeparatorRe = regexp.MustCompile(`[-_.]+`)
+
+// normalizePyPIName normalizes a Python package name as PEP 503 does.
+func normalizePyPIName(name string) string {
+	return pypiSeparatorRe.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// packageKey identifies a package across the OSV records naming it.
+type packageKey struct {
+	ecosystem string
+	name      string
+}
+
+func newPackageKey(ecosystemName, name string) packageKey {
+	if e, ok := lookupEcosystem(ecosystemName); ok && e.normalize != nil {
+		name = e.normalize(name)
+	}
+	return packageKey{ecosystem: ecosystemName, name: name}
+}
+
+// formatVersion writes a version from an OSV record as the package's
+// ecosystem does.
+func (k packageKey) formatVersion(v string) string {
+	if e, ok := lookupEcosystem(k.ecosystem); ok && !strings.HasPrefix(v, e.versionPrefix) {
+		return e.versionPrefix + v
+	}
+	return v
+}
+
+// comparator returns the function ordering versions of the package.
+func (k packageKey) comparator() func(a, b string) int {
+	if e, ok := lookupEcosystem(k.ecosystem); ok && e.compare != nil {
+		return e.compare
+	}
+	return version.Compare
+}
+
+func lookupEcosystem(name string) (ecosystem, bool) {
+	for _, e := range ecosystems {
+		if e.name == name {
+			return e, true
+		}
+	}
+	return ecosystem{}, false
+}
+
+// purlPackage returns the OSV ecosystem and name of the package a purl
+// identifies.
+func purlPackage(purl packageurl.PackageURL) (Package, error) {
+	e, ok := ecosystems[purl.Type]
+	if !ok {
+		return Package{}, fmt.Errorf("unsupported purl type %q", purl.Type)
+	}
+	name := purl.Name
+	if purl.Namespace != "" {
+		if e.separator == "" {
+			return Package{}, fmt.Errorf("unexpected namespace on %s purl", purl.Type)
+		}
+		name = purl.Namespace + e.separator + purl.Name
+	}
+	return Package{Ecosystem: e.name, Name: name}, nil
+}
@@ -0,0 +1,166 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+	"github.com/spdx/tools-golang/spdx"
+
+	"github.com/snyk/parlay/internal/pool"
+	"github.com/snyk/parlay/lib/report"
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+// EnrichSBOM adds the vulnerabilities affecting each package version, found
+// in cfg.DB if set and otherwise with the OSV API. When ctx is done,
+// packages which weren't enriched yet are left as they are.
+func EnrichSBOM(ctx context.Context, cfg *Config, doc *sbom.SBOMDocument) *sbom.SBOMDocument {
+	switch bom := doc.BOM.(type) {
+	case *cdx.BOM:
+		enrichCDX(ctx, cfg, bom)
+	case *spdx.Document:
+		enrichSPDX(ctx, cfg, bom)
+	}
+
+	return doc
+}
+
+// target is a package version of an SBOM to look up.
+type target struct {
+	purl packageurl.PackageURL
+	key  packageKey
+	out  *report.Outcome
+
+	// matches is set once the package was looked up successfully.
+	matches []Match
+	found   bool
+}
+
+// newTarget returns the package version to look up for a purl. If it can't
+// be looked up, it records why on out and returns false.
+func newTarget(ctx context.Context, purl *packageurl.PackageURL, out *report.Outcome) (*target, bool) {
+	if err := ctx.Err(); err != nil {
+		out.Skip("enrichment interrupted: " + err.Error())
+		return nil, false
+	}
+	if purl == nil {
+		out.Skip("no usable PackageURL")
+		return nil, false
+	}
+	if purl.Version == "" {
+		out.Skip("no version on PackageURL")
+		return nil, false
+	}
+	pkg, err := purlPackage(*purl)
+	if err != nil {
+		out.Skip(err.Error())
+		return nil, false
+	}
+	return &target{
+		purl: *purl,
+		key:  newPackageKey(pkg.Ecosystem, pkg.Name),
+		out:  out,
+	}, true
+}
+
+// lookupTargets finds the vulnerabilities affecting each target, setting
+// found on those looked up successfully.
+func lookupTargets(ctx context.Context, cfg *Config, targets []*target) {
+	if cfg.DB != nil {
+		for _, t := range targets {
+			matches, err := cfg.DB.Query(t.purl)
+			if err != nil {
+				t.out.Fail("failed to query database: " + err.Error())
+				continue
+			}
+			t.matches, t.found = matches, true
+		}
+		return
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	queries := make([]query, len(targets))
+	for i, t := range targets {
+		pkg, _ := purlPackage(t.purl)
+		queries[i] = query{Package: pkg, Version: t.purl.Version}
+	}
+	ids, err := queryBatch(ctx, cfg, queries, func(resp *http.Response) {
+		for _, t := range targets {
+			t.out.Response(resp)
+		}
+	})
+	if err != nil {
+		for _, t := range targets {
+			t.out.Fail("failed to query OSV: " + err.Error())
+		}
+		return
+	}
+
+	vulns := fetchVulnerabilities(ctx, cfg, ids)
+	for i, t := range targets {
+		t.found = true
+		for _, id := range ids[i] {
+			f := vulns[id]
+			t.out.Response(f.resp)
+			if f.err != nil {
+				t.out.Fail("failed to get " + id + ": " + f.err.Error())
+				t.found = false
+				break
+			}
+			t.matches = append(t.matches, Match{
+				Vulnerability: f.vuln,
+				Fixed:         f.vuln.fixedVersions(t.key, t.purl.Version),
+			})
+		}
+	}
+}
+
+type fetched struct {
+	vuln *Vulnerability
+	resp *http.Response
+	err  error
+}
+
+// fetchVulnerabilities fetches each of the records named in ids once.
+func fetchVulnerabilities(ctx context.Context, cfg *Config, ids [][]string) map[string]fetched {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, list := range ids {
+		for _, id := range list {
+			if !seen[id] {
+				seen[id] = true
+				unique = append(unique, id)
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	vulns := make(map[string]fetched, len(unique))
+	pool.Run(cfg.Concurrency, unique, func(id string) {
+		v, resp, err := GetVulnerability(ctx, cfg, id)
+		mu.Lock()
+		vulns[id] = fetched{vuln: v, resp: resp, err: err}
+		mu.Unlock()
+	})
+	return vulns
+}
@@ -0,0 +1,213 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+
+	"github.com/snyk/parlay/internal/utils"
+)
+
+func enrichCDX(ctx context.Context, cfg *Config, bom *cdx.BOM) {
+	var targets []*target
+	var comps []*cdx.Component
+	for _, comp := range utils.DiscoverCDXComponents(bom) {
+		out := cfg.Report.Outcome(reportProvider, utils.CDXComponentID(comp), comp.PackageURL)
+		var purl *packageurl.PackageURL
+		if p, err := packageurl.FromString(comp.PackageURL); err == nil {
+			purl = &p
+		}
+		if t, ok := newTarget(ctx, purl, out); ok {
+			targets = append(targets, t)
+			comps = append(comps, comp)
+		}
+	}
+	lookupTargets(ctx, cfg, targets)
+
+	// Vulnerabilities already in the BOM are extended rather than repeated,
+	// so that enriching twice doesn't add them twice.
+	var vulns []cdx.Vulnerability
+	if bom.Vulnerabilities != nil {
+		vulns = *bom.Vulnerabilities
+	}
+	index := make(map[string]int, len(vulns))
+	for i, v := range vulns {
+		index[v.ID] = i
+	}
+
+	for i, t := range targets {
+		if !t.found {
+			continue
+		}
+		ref := comps[i].BOMRef
+		if ref == "" {
+			ref = comps[i].PackageURL
+		}
+
+		added := false
+		for _, m := range t.matches {
+			j, ok := index[m.Vulnerability.ID]
+			if !ok {
+				vulns = append(vulns, cdxVulnerability(m.Vulnerability))
+				j = len(vulns) - 1
+				index[m.Vulnerability.ID] = j
+			}
+			if cdxAddAffects(&vulns[j], ref, t.purl.Version, m.Fixed) {
+				added = true
+			}
+		}
+		if added {
+			t.out.Added("vulnerabilities")
+		}
+	}
+
+	if len(vulns) > 0 {
+		bom.Vulnerabilities = &vulns
+	}
+}
+
+// cdxAddAffects records that a vulnerability affects a version of the
+// component ref refers to, and which versions fix it. It returns false if
+// the vulnerability already lists the component.
+func cdxAddAffects(vuln *cdx.Vulnerability, ref, version string, fixed []string) bool {
+	if vuln.Affects == nil {
+		vuln.Affects = &[]cdx.Affects{}
+	}
+	for _, a := range *vuln.Affects {
+		if a.Ref == ref {
+			return false
+		}
+	}
+
+	versions := []cdx.AffectedVersions{{Version: version, Status: cdx.VulnerabilityStatusAffected}}
+	for _, f := range fixed {
+		versions = append(versions, cdx.AffectedVersions{Version: f, Status: cdx.VulnerabilityStatusNotAffected})
+	}
+	*vuln.Affects = append(*vuln.Affects, cdx.Affects{Ref: ref, Range: &versions})
+	return true
+}
+
+func cdxVulnerability(v *Vulnerability) cdx.Vulnerability {
+	vuln := cdx.Vulnerability{
+		ID: v.ID,
+		Source: &cdx.Source{
+			Name: "OSV",
+			URL:  v.URL(),
+		},
+		Description: v.Summary,
+		Detail:      v.Details,
+		Published:   v.Published,
+		Updated:     v.Modified,
+	}
+
+	var refs []cdx.VulnerabilityReference
+	for _, alias := range v.Aliases {
+		refs = append(refs, cdx.VulnerabilityReference{
+			ID: alias,
+			Source: &cdx.Source{
+				URL: vulnerabilityURL(alias),
+			},
+		})
+	}
+	if len(refs) > 0 {
+		vuln.References = &refs
+	}
+
+	if ratings := cdxRatings(v); len(ratings) > 0 {
+		vuln.Ratings = &ratings
+	}
+
+	var cwes []int
+	for _, id := range v.DatabaseSpecific.CWEIDs {
+		if cwe, err := strconv.Atoi(strings.TrimPrefix(id, "CWE-")); err == nil {
+			cwes = append(cwes, cwe)
+		}
+	}
+	if len(cwes) > 0 {
+		vuln.CWEs = &cwes
+	}
+
+	var advisories []cdx.Advisory
+	for _, ref := range v.References {
+		if ref.Type == "ADVISORY" {
+			advisories = append(advisories, cdx.Advisory{URL: ref.URL})
+		}
+	}
+	if len(advisories) > 0 {
+		vuln.Advisories = &advisories
+	}
+
+	return vuln
+}
+
+func cdxRatings(v *Vulnerability) []cdx.VulnerabilityRating {
+	source := &cdx.Source{Name: "OSV", URL: v.URL()}
+	severity := cdxSeverity(v.DatabaseSpecific.Severity)
+
+	var ratings []cdx.VulnerabilityRating
+	for _, s := range v.Severity {
+		ratings = append(ratings, cdx.VulnerabilityRating{
+			Source:   source,
+			Severity: severity,
+			Method:   cdxScoringMethod(s),
+			Vector:   s.Score,
+		})
+	}
+	if len(ratings) == 0 && severity != "" {
+		ratings = append(ratings, cdx.VulnerabilityRating{
+			Source:   source,
+			Severity: severity,
+			Method:   cdx.ScoringMethodOther,
+		})
+	}
+	return ratings
+}
+
+// cdxSeverity maps the severity levels of the GitHub Advisory Database and
+// others which use the same names.
+func cdxSeverity(level string) cdx.Severity {
+	switch strings.ToUpper(level) {
+	case "CRITICAL":
+		return cdx.SeverityCritical
+	case "HIGH":
+		return cdx.SeverityHigh
+	case "MODERATE", "MEDIUM":
+		return cdx.SeverityMedium
+	case "LOW":
+		return cdx.SeverityLow
+	}
+	return ""
+}
+
+func cdxScoringMethod(s Severity) cdx.ScoringMethod {
+	switch s.Type {
+	case "CVSS_V2":
+		return cdx.ScoringMethodCVSSv2
+	case "CVSS_V3":
+		if strings.HasPrefix(s.Score, "CVSS:3.0/") {
+			return cdx.ScoringMethodCVSSv3
+		}
+		return cdx.ScoringMethodCVSSv31
+	case "CVSS_V4":
+		return cdx.ScoringMethodCVSSv4
+	}
+	return cdx.ScoringMethodOther
+}
@@ -0,0 +1,91 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+import (
+	"context"
+	"strings"
+
+	"github.com/spdx/tools-golang/spdx"
+	spdx_2_3 "github.com/spdx/tools-golang/spdx/v2/v2_3"
+
+	"github.com/snyk/parlay/internal/utils"
+)
+
+func enrichSPDX(ctx context.Context, cfg *Config, bom *spdx.Document) {
+	var targets []*target
+	var pkgs []*spdx_2_3.Package
+	for _, pkg := range bom.Packages {
+		out := cfg.Report.Outcome(reportProvider, string(pkg.PackageSPDXIdentifier), "")
+		purl, err := utils.GetPurlFromSPDXPackage(pkg)
+		if err == nil {
+			out = cfg.Report.Outcome(reportProvider, string(pkg.PackageSPDXIdentifier), purl.String())
+		}
+		if t, ok := newTarget(ctx, purl, out); ok {
+			targets = append(targets, t)
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	lookupTargets(ctx, cfg, targets)
+
+	for i, t := range targets {
+		if !t.found {
+			continue
+		}
+		pkg := pkgs[i]
+		refs := len(pkg.PackageExternalReferences)
+		for _, m := range t.matches {
+			utils.AddSPDXExternalReference(pkg, &spdx_2_3.PackageExternalReference{
+				Category:           spdx.CategorySecurity,
+				RefType:            spdx.SecurityAdvisory,
+				Locator:            m.Vulnerability.URL(),
+				ExternalRefComment: spdxComment(m),
+			})
+		}
+		if len(pkg.PackageExternalReferences) > refs {
+			t.out.Added("vulnerabilities")
+		}
+	}
+}
+
+// spdxComment summarizes a vulnerability, as SPDX has no place for its
+// aliases, severities and fixes.
+func spdxComment(m Match) string {
+	v := m.Vulnerability
+	var parts []string
+	if v.Summary != "" {
+		parts = append(parts, v.Summary)
+	}
+	if len(v.Aliases) > 0 {
+		parts = append(parts, "aliases: "+strings.Join(v.Aliases, ", "))
+	}
+
+	var severities []string
+	if v.DatabaseSpecific.Severity != "" {
+		severities = append(severities, v.DatabaseSpecific.Severity)
+	}
+	for _, s := range v.Severity {
+		severities = append(severities, s.Score)
+	}
+	if len(severities) > 0 {
+		parts = append(parts, "severity: "+strings.Join(severities, ", "))
+	}
+
+	if len(m.Fixed) > 0 {
+		parts = append(parts, "fixed in: "+strings.Join(m.Fixed, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
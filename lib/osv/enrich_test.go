@@ -0,0 +1,250 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/jarcoal/httpmock"
+	"github.com/spdx/tools-golang/spdx"
+	spdx_2_3 "github.com/spdx/tools-golang/spdx/v2/v2_3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/snyk/parlay/lib/report"
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+func offlineConfig(t *testing.T) *Config {
+	t.Helper()
+
+	db, err := LoadDB("testdata")
+	require.NoError(t, err)
+	cfg := DefaultConfig()
+	cfg.DB = db
+	cfg.Report = report.New()
+	return cfg
+}
+
+func TestEnrichSBOM_CycloneDX(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "example@1.0.0", PackageURL: "pkg:npm/example@1.0.0"},
+			{BOMRef: "example@2.0.0", PackageURL: "pkg:npm/example@2.0.0"},
+			{BOMRef: "example@1.2.3", PackageURL: "pkg:npm/example@1.2.3"},
+		},
+	}
+
+	cfg := offlineConfig(t)
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	require.NotNil(t, bom.Vulnerabilities)
+	require.Len(t, *bom.Vulnerabilities, 1)
+	vuln := (*bom.Vulnerabilities)[0]
+	assert.Equal(t, "GHSA-aaaa-bbbb-cccc", vuln.ID)
+	assert.Equal(t, &cdx.Source{Name: "OSV", URL: "https://osv.dev/vulnerability/GHSA-aaaa-bbbb-cccc"}, vuln.Source)
+	assert.Equal(t, "Prototype pollution in example", vuln.Description)
+	assert.Equal(t, "2024-05-20T00:00:00Z", vuln.Published)
+	assert.Equal(t, &[]cdx.VulnerabilityReference{
+		{ID: "CVE-2024-0001", Source: &cdx.Source{URL: "https://osv.dev/vulnerability/CVE-2024-0001"}},
+	}, vuln.References)
+	require.NotNil(t, vuln.Ratings)
+	assert.Equal(t, cdx.SeverityCritical, (*vuln.Ratings)[0].Severity)
+	assert.Equal(t, cdx.ScoringMethodCVSSv31, (*vuln.Ratings)[0].Method)
+	assert.Equal(t, "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", (*vuln.Ratings)[0].Vector)
+	assert.Equal(t, &[]int{1321}, vuln.CWEs)
+	assert.Equal(t, &[]cdx.Advisory{{URL: "https://nvd.nist.gov/vuln/detail/CVE-2024-0001"}}, vuln.Advisories)
+	assert.Equal(t, &[]cdx.Affects{
+		{
+			Ref: "example@1.0.0",
+			Range: &[]cdx.AffectedVersions{
+				{Version: "1.0.0", Status: cdx.VulnerabilityStatusAffected},
+				{Version: "1.2.3", Status: cdx.VulnerabilityStatusNotAffected},
+				{Version: "2.0.1", Status: cdx.VulnerabilityStatusNotAffected},
+			},
+		},
+		{
+			Ref: "example@2.0.0",
+			Range: &[]cdx.AffectedVersions{
+				{Version: "2.0.0", Status: cdx.VulnerabilityStatusAffected},
+				{Version: "2.0.1", Status: cdx.VulnerabilityStatusNotAffected},
+			},
+		},
+	}, vuln.Affects)
+
+	assert.Equal(t, []string{"vulnerabilities"}, cfg.Report.Outcome(reportProvider, "example@1.0.0", "").FieldsAdded)
+	assert.Equal(t, report.StatusUnchanged, cfg.Report.Outcome(reportProvider, "example@1.2.3", "").Status)
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+}
+
+func TestEnrichSBOM_CycloneDX_Twice(t *testing.T) {
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "example", PackageURL: "pkg:npm/example@1.0.0"},
+		},
+		Vulnerabilities: &[]cdx.Vulnerability{
+			{ID: "SNYK-JS-EXAMPLE-1"},
+		},
+	}
+
+	EnrichSBOM(context.Background(), offlineConfig(t), &sbom.SBOMDocument{BOM: bom})
+	cfg := offlineConfig(t)
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	require.Len(t, *bom.Vulnerabilities, 2)
+	assert.Equal(t, "SNYK-JS-EXAMPLE-1", (*bom.Vulnerabilities)[0].ID)
+	assert.Len(t, *(*bom.Vulnerabilities)[1].Affects, 1)
+	assert.Equal(t, report.StatusUnchanged, cfg.Report.Outcome(reportProvider, "example", "").Status)
+}
+
+func TestEnrichSBOM_SPDX(t *testing.T) {
+	doc := &spdx.Document{
+		Packages: []*spdx_2_3.Package{
+			{
+				PackageSPDXIdentifier: "pkg-example",
+				PackageName:           "Example-Package",
+				PackageExternalReferences: []*spdx_2_3.PackageExternalReference{
+					{
+						Category: spdx.CategoryPackageManager,
+						RefType:  "purl",
+						Locator:  "pkg:pypi/example-package@1.0",
+					},
+				},
+			},
+		},
+	}
+
+	cfg := offlineConfig(t)
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: doc})
+
+	refs := doc.Packages[0].PackageExternalReferences
+	require.Len(t, refs, 2)
+	assert.Equal(t, &spdx_2_3.PackageExternalReference{
+		Category:           spdx.CategorySecurity,
+		RefType:            spdx.SecurityAdvisory,
+		Locator:            "https://osv.dev/vulnerability/PYSEC-2024-1",
+		ExternalRefComment: "aliases: CVE-2024-0002",
+	}, refs[1])
+	assert.Equal(t, []string{"vulnerabilities"}, cfg.Report.Outcome(reportProvider, "pkg-example", "").FieldsAdded)
+}
+
+func TestSPDXComment(t *testing.T) {
+	v := &Vulnerability{
+		ID:               "GHSA-aaaa-bbbb-cccc",
+		Summary:          "Prototype pollution in example",
+		Aliases:          []string{"CVE-2024-0001"},
+		Severity:         []Severity{{Type: "CVSS_V3", Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}},
+		DatabaseSpecific: DatabaseSpecific{Severity: "CRITICAL"},
+	}
+
+	assert.Equal(t,
+		"Prototype pollution in example; aliases: CVE-2024-0001; severity: CRITICAL, CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H; fixed in: 1.2.3, 2.0.1",
+		spdxComment(Match{Vulnerability: v, Fixed: []string{"1.2.3", "2.0.1"}}))
+}
+
+func TestEnrichSBOM_API(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	record, err := os.ReadFile("testdata/npm/GHSA-aaaa-bbbb-cccc.json")
+	require.NoError(t, err)
+	httpmock.RegisterResponder("POST", querybatchURL, httpmock.NewStringResponder(http.StatusOK, `{"results": [
+		{"vulns": [{"id": "GHSA-aaaa-bbbb-cccc", "modified": "2024-06-01T00:00:00Z"}]},
+		{"vulns": [{"id": "GHSA-aaaa-bbbb-cccc", "modified": "2024-06-01T00:00:00Z"}]},
+		{}
+	]}`))
+	httpmock.RegisterResponder("GET", "https://api.osv.dev/v1/vulns/GHSA-aaaa-bbbb-cccc", httpmock.NewBytesResponder(http.StatusOK, record))
+	httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("unexpected HTTP request: " + req.URL.String())
+	})
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "a", PackageURL: "pkg:npm/example@1.0.0"},
+			{BOMRef: "b", PackageURL: "pkg:npm/example@2.0.0"},
+			{BOMRef: "c", PackageURL: "pkg:npm/example@1.2.3"},
+		},
+	}
+
+	cfg := DefaultConfig()
+	cfg.Report = report.New()
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	require.Len(t, *bom.Vulnerabilities, 1)
+	affects := *(*bom.Vulnerabilities)[0].Affects
+	require.Len(t, affects, 2)
+	assert.Equal(t, "b", affects[1].Ref)
+	assert.Equal(t, "2.0.1", (*affects[1].Range)[1].Version)
+
+	assert.Equal(t, report.StatusEnriched, cfg.Report.Outcome(reportProvider, "a", "").Status)
+	assert.Equal(t, report.StatusUnchanged, cfg.Report.Outcome(reportProvider, "c", "").Status)
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 1, calls["POST "+querybatchURL])
+	assert.Equal(t, 1, calls["GET https://api.osv.dev/v1/vulns/GHSA-aaaa-bbbb-cccc"])
+}
+
+func TestEnrichSBOM_API_Error(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", querybatchURL, httpmock.NewStringResponder(http.StatusBadRequest, `{"code": 3}`))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "example", PackageURL: "pkg:npm/example@1.0.0"},
+		},
+	}
+
+	cfg := DefaultConfig()
+	cfg.Report = report.New()
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	assert.Nil(t, bom.Vulnerabilities)
+	outcome := cfg.Report.Outcome(reportProvider, "example", "")
+	assert.Equal(t, report.StatusFailed, outcome.Status)
+	assert.Equal(t, "failed to query OSV: unexpected response from OSV: 400", outcome.Reason)
+}
+
+func TestEnrichSBOM_Skipped(t *testing.T) {
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "no-purl", Name: "example"},
+			{BOMRef: "no-version", PackageURL: "pkg:npm/example"},
+			{BOMRef: "unsupported", PackageURL: "pkg:generic/example@1.0.0"},
+		},
+	}
+
+	cfg := offlineConfig(t)
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	for id, reason := range map[string]string{
+		"no-purl":     "no usable PackageURL",
+		"no-version":  "no version on PackageURL",
+		"unsupported": `unsupported purl type "generic"`,
+	} {
+		outcome := cfg.Report.Outcome(reportProvider, id, "")
+		assert.Equal(t, report.StatusSkipped, outcome.Status, id)
+		assert.Equal(t, reason, outcome.Reason, id)
+	}
+	assert.Nil(t, bom.Vulnerabilities)
+}
@@ -0,0 +1,143 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+import "slices"
+
+// Match is a vulnerability affecting a package version.
+type Match struct {
+	Vulnerability *Vulnerability
+
+	// Fixed lists the versions later than the affected version which fix
+	// the vulnerability, lowest first.
+	Fixed []string
+}
+
+// affectedPackages returns the entries of a vulnerability for a package.
+func (v *Vulnerability) affectedPackages(pkg packageKey) []Affected {
+	var affected []Affected
+	for _, a := range v.Affected {
+		if newPackageKey(a.Package.Ecosystem, a.Package.Name) == pkg {
+			affected = append(affected, a)
+		}
+	}
+	return affected
+}
+
+// affects reports whether a vulnerability affects a version of a package.
+func (v *Vulnerability) affects(pkg packageKey, ver string) bool {
+	compare := pkg.comparator()
+	for _, a := range v.affectedPackages(pkg) {
+		if slices.ContainsFunc(a.Versions, func(affected string) bool {
+			return compare(affected, ver) == 0
+		}) {
+			return true
+		}
+		for _, r := range a.Ranges {
+			if r.contains(ver, compare) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fixedVersions returns the versions which fix a vulnerability in a version
+// of a package.
+func (v *Vulnerability) fixedVersions(pkg packageKey, ver string) []string {
+	compare := pkg.comparator()
+	var fixed []string
+	for _, a := range v.affectedPackages(pkg) {
+		for _, r := range a.Ranges {
+			if !r.comparable() {
+				continue
+			}
+			rangeCompare := r.comparator(compare)
+			for _, e := range r.Events {
+				if e.Fixed == "" || rangeCompare(e.Fixed, ver) <= 0 {
+					continue
+				}
+				if f := pkg.formatVersion(e.Fixed); !slices.Contains(fixed, f) {
+					fixed = append(fixed, f)
+				}
+			}
+		}
+	}
+	slices.SortFunc(fixed, compare)
+	return fixed
+}
+
+// comparable reports whether the versions of a range can be compared.
+// GIT ranges name commits, which can only be ordered with the repository.
+func (r Range) comparable() bool {
+	return r.Type == "SEMVER" || r.Type == "ECOSYSTEM"
+}
+
+// comparator returns the function ordering the versions of a range. SEMVER
+// ranges are always ordered as SemVer, and ECOSYSTEM ranges as the package's
+// ecosystem orders them.
+func (r Range) comparator(ecosystem func(a, b string) int) func(a, b string) int {
+	if r.Type == "SEMVER" {
+		return compareSemVer
+	}
+	return ecosystem
+}
+
+// contains reports whether a range includes a version, evaluating its
+// events in version order as the OSV schema describes. compare orders the
+// versions of the package's ecosystem.
+func (r Range) contains(ver string, compare func(a, b string) int) bool {
+	if !r.comparable() {
+		return false
+	}
+	compare = r.comparator(compare)
+
+	events := slices.Clone(r.Events)
+	slices.SortStableFunc(events, func(a, b Event) int {
+		return compare(a.version(), b.version())
+	})
+
+	affected := false
+	for _, e := range events {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || compare(ver, e.Introduced) >= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if compare(ver, e.Fixed) >= 0 {
+				affected = false
+			}
+		case e.LastAffected != "":
+			if compare(ver, e.LastAffected) > 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
+
+func (e Event) version() string {
+	switch {
+	case e.Introduced != "":
+		return e.Introduced
+	case e.Fixed != "":
+		return e.Fixed
+	case e.LastAffected != "":
+		return e.LastAffected
+	}
+	return e.Limit
+}
@@ -0,0 +1,139 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRange_Contains(t *testing.T) {
+	r := Range{
+		Type: "ECOSYSTEM",
+		Events: []Event{
+			{Introduced: "2.0.0"},
+			{Fixed: "2.0.1"},
+			{Introduced: "0"},
+			{LastAffected: "1.2.3"},
+		},
+	}
+
+	tc := []struct {
+		version  string
+		expected bool
+	}{
+		{"0.1.0", true},
+		{"1.2.3", true},
+		{"1.2.4", false},
+		{"2.0.0-rc.1", false},
+		{"2.0.0", true},
+		{"2.0.1", false},
+		{"3.0.0", false},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.version, func(t *testing.T) {
+			assert.Equal(t, tt.expected, r.contains(tt.version, compareSemVer))
+		})
+	}
+}
+
+func TestRange_Contains_Git(t *testing.T) {
+	r := Range{
+		Type:   "GIT",
+		Events: []Event{{Introduced: "0"}, {Fixed: "4b2c1a"}},
+	}
+
+	assert.False(t, r.contains("1.0.0", compareSemVer))
+}
+
+func TestRange_Contains_Ecosystem(t *testing.T) {
+	tc := []struct {
+		ecosystem string
+		events    []Event
+		version   string
+		expected  bool
+	}{
+		{"PyPI", []Event{{Introduced: "0"}, {Fixed: "1!1.0"}}, "2.0", true},
+		{"PyPI", []Event{{Introduced: "0"}, {Fixed: "1!1.0"}}, "1!1.0", false},
+		{"PyPI", []Event{{Introduced: "1.0"}, {Fixed: "1.0.post1"}}, "1.0", true},
+		{"PyPI", []Event{{Introduced: "1.0"}, {Fixed: "1.1"}}, "1.1rc1", true},
+		{"PyPI", []Event{{Introduced: "1.0"}, {Fixed: "1.1"}}, "1.0.dev1", false},
+		{"Maven", []Event{{Introduced: "0"}, {Fixed: "2.0"}}, "2.0-rc1", true},
+		{"Maven", []Event{{Introduced: "0"}, {Fixed: "2.0"}}, "2.0.Final", false},
+		{"Maven", []Event{{Introduced: "0"}, {Fixed: "2.0"}}, "2.0-sp1", false},
+		{"RubyGems", []Event{{Introduced: "0"}, {Fixed: "2.0"}}, "2.0.a", true},
+		{"RubyGems", []Event{{Introduced: "0"}, {Fixed: "2.0"}}, "2.0.0.1", false},
+		{"npm", []Event{{Introduced: "0"}, {Fixed: "1.0.0"}}, "1.0.0-beta.11", true},
+		{"npm", []Event{{Introduced: "0"}, {Fixed: "1.0.0"}}, "1.0.0+build.1", false},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.ecosystem+"/"+tt.version, func(t *testing.T) {
+			r := Range{Type: "ECOSYSTEM", Events: tt.events}
+			compare := newPackageKey(tt.ecosystem, "example").comparator()
+			assert.Equal(t, tt.expected, r.contains(tt.version, compare))
+		})
+	}
+}
+
+func TestRange_Contains_SemVer(t *testing.T) {
+	r := Range{Type: "SEMVER", Events: []Event{{Introduced: "0"}, {Fixed: "1.0.0"}}}
+
+	// SEMVER ranges are ordered as SemVer whatever the ecosystem, so the
+	// pre-release isn't taken for a PEP 440 post-release.
+	assert.True(t, r.contains("1.0.0-post.1", comparePEP440))
+}
+
+func TestVulnerability_FixedVersions(t *testing.T) {
+	v := &Vulnerability{
+		Affected: []Affected{
+			{
+				Package: Package{Ecosystem: "PyPI", Name: "Example_Package"},
+				Ranges: []Range{
+					{Type: "ECOSYSTEM", Events: []Event{{Introduced: "0"}, {Fixed: "1.10"}, {Introduced: "2.0"}, {Fixed: "2.0.1"}}},
+					{Type: "ECOSYSTEM", Events: []Event{{Introduced: "0"}, {Fixed: "1.9"}}},
+					{Type: "GIT", Events: []Event{{Introduced: "0"}, {Fixed: "4b2c1a"}}},
+				},
+			},
+			{
+				Package: Package{Ecosystem: "PyPI", Name: "other"},
+				Ranges:  []Range{{Type: "ECOSYSTEM", Events: []Event{{Introduced: "0"}, {Fixed: "1.5"}}}},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"1.9", "1.10", "2.0.1"}, v.fixedVersions(newPackageKey("PyPI", "example-package"), "1.0"))
+	assert.Equal(t, []string{"2.0.1"}, v.fixedVersions(newPackageKey("PyPI", "example-package"), "2.0"))
+}
+
+func TestVulnerability_FixedVersions_Epoch(t *testing.T) {
+	v := &Vulnerability{
+		Affected: []Affected{
+			{
+				Package: Package{Ecosystem: "PyPI", Name: "example"},
+				Ranges: []Range{
+					{Type: "ECOSYSTEM", Events: []Event{{Introduced: "0"}, {Fixed: "1!1.0"}}},
+					{Type: "ECOSYSTEM", Events: []Event{{Introduced: "0"}, {Fixed: "3.0"}}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"3.0", "1!1.0"}, v.fixedVersions(newPackageKey("PyPI", "example"), "2.0"))
+	assert.Equal(t, []string{"1!1.0"}, v.fixedVersions(newPackageKey("PyPI", "example"), "4.0"))
+}
@@ -0,0 +1,88 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+// Vulnerability is a record in the OSV format. Only the fields parlay uses
+// are decoded. See https://ossf.github.io/osv-schema/.
+type Vulnerability struct {
+	ID               string           `json:"id"`
+	Modified         string           `json:"modified"`
+	Published        string           `json:"published"`
+	Withdrawn        string           `json:"withdrawn"`
+	Aliases          []string         `json:"aliases"`
+	Summary          string           `json:"summary"`
+	Details          string           `json:"details"`
+	Severity         []Severity       `json:"severity"`
+	Affected         []Affected       `json:"affected"`
+	References       []Reference      `json:"references"`
+	DatabaseSpecific DatabaseSpecific `json:"database_specific"`
+}
+
+// Severity is a severity score of a vulnerability, such as a CVSS vector.
+type Severity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// Affected lists the versions of a package a vulnerability affects.
+type Affected struct {
+	Package  Package  `json:"package"`
+	Ranges   []Range  `json:"ranges"`
+	Versions []string `json:"versions"`
+}
+
+type Package struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	Purl      string `json:"purl,omitempty"`
+}
+
+// Range is a range of affected versions, given as the versions which
+// introduced or fixed the vulnerability.
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+// Event is a change to whether a vulnerability affects a package. Exactly
+// one of its fields is set.
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+type Reference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// DatabaseSpecific holds the fields some databases, such as the GitHub
+// Advisory Database, add to their records.
+type DatabaseSpecific struct {
+	Severity string   `json:"severity"`
+	CWEIDs   []string `json:"cwe_ids"`
+}
+
+// URL returns the page of the vulnerability on osv.dev.
+func (v *Vulnerability) URL() string {
+	return vulnerabilityURL(v.ID)
+}
+
+func vulnerabilityURL(id string) string {
+	return "https://osv.dev/vulnerability/" + id
+}
@@ -0,0 +1,487 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/snyk/parlay/internal/version"
+)
+
+// compareNumeric compares two strings of digits by their value, however
+// long they are.
+func compareNumeric(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return sign(len(a) - len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// compareSemVer orders versions as Semantic Versioning 2.0.0 does: build
+// metadata is ignored, and a pre-release sorts before its release. A leading
+// v, as in Go module versions, is ignored.
+func compareSemVer(a, b string) int {
+	ra, pa := splitSemVer(a)
+	rb, pb := splitSemVer(b)
+
+	for i := 0; i < len(ra) || i < len(rb); i++ {
+		x, y := "0", "0"
+		if i < len(ra) {
+			x = ra[i]
+		}
+		if i < len(rb) {
+			y = rb[i]
+		}
+		if c := compareNumeric(x, y); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case pa == nil && pb == nil:
+		return 0
+	case pa == nil:
+		return 1
+	case pb == nil:
+		return -1
+	}
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		x, y := pa[i], pb[i]
+		var c int
+		switch nx, ny := isNumeric(x), isNumeric(y); {
+		case nx && ny:
+			c = compareNumeric(x, y)
+		case nx:
+			c = -1
+		case ny:
+			c = 1
+		default:
+			c = strings.Compare(x, y)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return sign(len(pa) - len(pb))
+}
+
+// splitSemVer returns the release numbers and pre-release identifiers of a
+// version. The pre-release identifiers are nil for a release.
+func splitSemVer(v string) (release, prerelease []string) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "+")
+	v, pre, ok := strings.Cut(v, "-")
+	release = strings.Split(v, ".")
+	if ok {
+		prerelease = strings.Split(pre, ".")
+	}
+	return release, prerelease
+}
+
+var pep440Re = regexp.MustCompile(`^v?` +
+	`(?:([0-9]+)!)?` +
+	`([0-9]+(?:\.[0-9]+)*)` +
+	`(?:[-_.]?(alpha|a|beta|b|preview|pre|c|rc)[-_.]?([0-9]+)?)?` +
+	`(?:-([0-9]+)|[-_.]?(post|rev|r)[-_.]?([0-9]+)?)?` +
+	`(?:[-_.]?(dev)[-_.]?([0-9]+)?)?` +
+	`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+// pep440Version is a parsed PEP 440 version. Missing numbers are empty.
+type pep440Version struct {
+	epoch   string
+	release []string
+	// pre ranks the pre-release phase: -1 for a development release
+	// without one, 0 to 2 for alpha, beta and release candidates, and 3
+	// for no pre-release.
+	pre     int
+	preNum  string
+	post    bool
+	postNum string
+	dev     bool
+	devNum  string
+	local   []string
+}
+
+func parsePEP440(v string) (pep440Version, bool) {
+	m := pep440Re.FindStringSubmatch(strings.ToLower(strings.TrimSpace(v)))
+	if m == nil {
+		return pep440Version{}, false
+	}
+
+	p := pep440Version{
+		epoch:   m[1],
+		release: strings.Split(m[2], "."),
+		preNum:  m[4],
+		post:    m[5] != "" || m[6] != "",
+		postNum: m[5] + m[7],
+		dev:     m[8] != "",
+		devNum:  m[9],
+	}
+	switch m[3] {
+	case "alpha", "a":
+		p.pre = 0
+	case "beta", "b":
+		p.pre = 1
+	case "preview", "pre", "c", "rc":
+		p.pre = 2
+	default:
+		p.pre = 3
+		if p.dev && !p.post {
+			p.pre = -1
+		}
+	}
+	if m[10] != "" {
+		p.local = strings.FieldsFunc(m[10], func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})
+	}
+	return p, true
+}
+
+// comparePEP440 orders Python package versions as PEP 440 does, including
+// epochs such as 1!1.0, and post-, pre- and development releases. Versions
+// which aren't valid PEP 440 are compared on a best-effort basis.
+func comparePEP440(a, b string) int {
+	pa, okA := parsePEP440(a)
+	pb, okB := parsePEP440(b)
+	if !okA || !okB {
+		return version.Compare(a, b)
+	}
+
+	if c := compareNumeric(pa.epoch, pb.epoch); c != 0 {
+		return c
+	}
+	for i := 0; i < len(pa.release) || i < len(pb.release); i++ {
+		x, y := "0", "0"
+		if i < len(pa.release) {
+			x = pa.release[i]
+		}
+		if i < len(pb.release) {
+			y = pb.release[i]
+		}
+		if c := compareNumeric(x, y); c != 0 {
+			return c
+		}
+	}
+	if c := sign(pa.pre - pb.pre); c != 0 {
+		return c
+	}
+	if c := compareNumeric(pa.preNum, pb.preNum); c != 0 {
+		return c
+	}
+	if pa.post != pb.post {
+		if pa.post {
+			return 1
+		}
+		return -1
+	}
+	if c := compareNumeric(pa.postNum, pb.postNum); c != 0 {
+		return c
+	}
+	if pa.dev != pb.dev {
+		if pa.dev {
+			return -1
+		}
+		return 1
+	}
+	if c := compareNumeric(pa.devNum, pb.devNum); c != 0 {
+		return c
+	}
+
+	for i := 0; i < len(pa.local) && i < len(pb.local); i++ {
+		x, y := pa.local[i], pb.local[i]
+		var c int
+		switch nx, ny := isNumeric(x), isNumeric(y); {
+		case nx && ny:
+			c = compareNumeric(x, y)
+		case nx:
+			c = 1
+		case ny:
+			c = -1
+		default:
+			c = strings.Compare(x, y)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return sign(len(pa.local) - len(pb.local))
+}
+
+// mavenQualifiers lists the well-known Maven qualifiers in order. The empty
+// qualifier stands for a release.
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+var mavenAliases = map[string]string{
+	"ga":      "",
+	"final":   "",
+	"release": "",
+	"cr":      "rc",
+}
+
+// mavenItem is an item of a Maven version: a number, a qualifier or a list
+// of items started by a hyphen or a change between digits and letters.
+type mavenItem struct {
+	kind  int
+	value string
+	items []mavenItem
+}
+
+const (
+	mavenNumber = iota
+	mavenQualifier
+	mavenList
+)
+
+// mavenKindRank orders items of different kinds: numbers sort after lists,
+// which sort after qualifiers.
+var mavenKindRank = map[int]int{mavenQualifier: 0, mavenList: 1, mavenNumber: 2}
+
+func (i mavenItem) isNull() bool {
+	switch i.kind {
+	case mavenNumber:
+		return strings.TrimLeft(i.value, "0") == ""
+	case mavenQualifier:
+		return i.value == ""
+	}
+	return len(i.items) == 0
+}
+
+// compare compares an item with another, or with a missing item if other is
+// nil.
+func (i mavenItem) compare(other *mavenItem) int {
+	if other == nil {
+		switch i.kind {
+		case mavenNumber:
+			if i.isNull() {
+				return 0
+			}
+			return 1
+		case mavenQualifier:
+			return strings.Compare(mavenQualifierKey(i.value), mavenQualifierKey(""))
+		}
+		if len(i.items) == 0 {
+			return 0
+		}
+		return i.items[0].compare(nil)
+	}
+
+	if i.kind != other.kind {
+		return sign(mavenKindRank[i.kind] - mavenKindRank[other.kind])
+	}
+	switch i.kind {
+	case mavenNumber:
+		return compareNumeric(i.value, other.value)
+	case mavenQualifier:
+		return strings.Compare(mavenQualifierKey(i.value), mavenQualifierKey(other.value))
+	}
+	for n := 0; n < len(i.items) || n < len(other.items); n++ {
+		var c int
+		switch {
+		case n >= len(i.items):
+			c = -other.items[n].compare(nil)
+		case n >= len(other.items):
+			c = i.items[n].compare(nil)
+		default:
+			c = i.items[n].compare(&other.items[n])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// mavenQualifierKey returns a key ordering qualifiers as Maven does: the
+// well-known ones in their order, followed by any others alphabetically.
+func mavenQualifierKey(q string) string {
+	for i, known := range mavenQualifiers {
+		if q == known {
+			return string(rune('0' + i))
+		}
+	}
+	return string(rune('0'+len(mavenQualifiers))) + "-" + q
+}
+
+func newMavenItem(s string, numeric, followedByDigit bool) mavenItem {
+	if numeric {
+		return mavenItem{kind: mavenNumber, value: s}
+	}
+	if followedByDigit && len(s) == 1 {
+		switch s {
+		case "a":
+			s = "alpha"
+		case "b":
+			s = "beta"
+		case "m":
+			s = "milestone"
+		}
+	}
+	if alias, ok := mavenAliases[s]; ok {
+		s = alias
+	}
+	return mavenItem{kind: mavenQualifier, value: s}
+}
+
+// parseMaven parses a version as Maven's ComparableVersion does.
+func parseMaven(v string) mavenItem {
+	v = strings.ToLower(strings.TrimSpace(v))
+
+	root := &mavenItem{kind: mavenList}
+	stack := []*mavenItem{root}
+	list := root
+	// push starts a list nested in the current one.
+	push := func() {
+		list.items = append(list.items, mavenItem{kind: mavenList})
+		list = &list.items[len(list.items)-1]
+		stack = append(stack, list)
+	}
+
+	numeric := false
+	start := 0
+	for i, r := range v {
+		switch {
+		case r == '.' || r == '-':
+			if i == start {
+				list.items = append(list.items, mavenItem{kind: mavenNumber, value: "0"})
+			} else {
+				list.items = append(list.items, newMavenItem(v[start:i], numeric, false))
+			}
+			start = i + 1
+			if r == '-' {
+				push()
+			}
+		case r >= '0' && r <= '9':
+			if !numeric && i > start {
+				list.items = append(list.items, newMavenItem(v[start:i], false, true))
+				start = i
+				push()
+			}
+			numeric = true
+		default:
+			if numeric && i > start {
+				list.items = append(list.items, newMavenItem(v[start:i], true, false))
+				start = i
+				push()
+			}
+			numeric = false
+		}
+	}
+	if len(v) > start {
+		list.items = append(list.items, newMavenItem(v[start:], numeric, false))
+	}
+
+	// Trailing null items are dropped, innermost lists first so that lists
+	// left empty are dropped from their parent too.
+	for i := len(stack) - 1; i >= 0; i-- {
+		l := stack[i]
+		for n := len(l.items) - 1; n >= 0; n-- {
+			if l.items[n].isNull() {
+				l.items = append(l.items[:n], l.items[n+1:]...)
+			} else if l.items[n].kind != mavenList {
+				break
+			}
+		}
+	}
+	return *root
+}
+
+// compareMaven orders versions as Maven's ComparableVersion does, so that
+// for example 1.0-alpha-1 < 1.0-rc1 < 1.0 = 1.0.0 = 1.0-ga < 1.0-sp1.
+func compareMaven(a, b string) int {
+	pb := parseMaven(b)
+	return parseMaven(a).compare(&pb)
+}
+
+var gemSegmentRe = regexp.MustCompile(`[0-9]+|[a-z]+`)
+
+// gemSegments returns the canonical segments of a RubyGems version: its
+// release segments and its pre-release segments, each without trailing
+// zeros.
+func gemSegments(v string) []string {
+	v = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(v)), "-", ".pre.")
+	segments := gemSegmentRe.FindAllString(v, -1)
+
+	release := len(segments)
+	for i, s := range segments {
+		if !isNumeric(s) {
+			release = i
+			break
+		}
+	}
+	trim := func(s []string) []string {
+		for len(s) > 0 && isNumeric(s[len(s)-1]) && strings.TrimLeft(s[len(s)-1], "0") == "" {
+			s = s[:len(s)-1]
+		}
+		return s
+	}
+	return append(trim(segments[:release:release]), trim(segments[release:])...)
+}
+
+// compareRubyGems orders versions as Gem::Version does: a version with a
+// letter in it is a pre-release, and sorts before its release.
+func compareRubyGems(a, b string) int {
+	sa, sb := gemSegments(a), gemSegments(b)
+	for i := 0; i < len(sa) || i < len(sb); i++ {
+		x, y := "0", "0"
+		if i < len(sa) {
+			x = sa[i]
+		}
+		if i < len(sb) {
+			y = sb[i]
+		}
+		if x == y {
+			continue
+		}
+		switch nx, ny := isNumeric(x), isNumeric(y); {
+		case nx && ny:
+			if c := compareNumeric(x, y); c != 0 {
+				return c
+			}
+		case nx:
+			return 1
+		case ny:
+			return -1
+		default:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
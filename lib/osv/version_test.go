@@ -0,0 +1,108 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package osv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// versionOrder lists versions in increasing order. Versions in the same
+// inner slice are equal.
+type versionOrder [][]string
+
+func assertOrder(t *testing.T, compare func(a, b string) int, order versionOrder) {
+	t.Helper()
+	for i, equal := range order {
+		for _, a := range equal {
+			for j, other := range order {
+				for _, b := range other {
+					assert.Equal(t, sign(i-j), compare(a, b), "compare(%q, %q)", a, b)
+				}
+			}
+		}
+	}
+}
+
+func TestCompareSemVer(t *testing.T) {
+	assertOrder(t, compareSemVer, versionOrder{
+		{"0.9.9"},
+		{"1.0.0-alpha", "v1.0.0-alpha"},
+		{"1.0.0-alpha.1"},
+		{"1.0.0-alpha.beta"},
+		{"1.0.0-beta"},
+		{"1.0.0-beta.2"},
+		{"1.0.0-beta.11"},
+		{"1.0.0-rc.1"},
+		{"1.0.0", "v1.0.0", "1.0.0+build.5"},
+		{"1.2.0"},
+		{"1.10.0"},
+		{"18446744073709551616.0.0"},
+	})
+}
+
+func TestComparePEP440(t *testing.T) {
+	assertOrder(t, comparePEP440, versionOrder{
+		{"1.0.dev0", "1.0.dev"},
+		{"1.0a1", "1.0alpha1", "1.0.a.1"},
+		{"1.0a2.dev1"},
+		{"1.0a2"},
+		{"1.0b1", "1.0-beta-1"},
+		{"1.0rc1", "1.0c1", "1.0pre1"},
+		{"1.0", "1.0.0", "v1.0"},
+		{"1.0+local.1"},
+		{"1.0+local.2"},
+		{"1.0.post1.dev1"},
+		{"1.0.post1", "1.0-1", "1.0.rev1"},
+		{"1.1"},
+		{"1.10"},
+		{"1!0.5", "1!0.5.0"},
+		{"1!1.0"},
+		{"2!0.1"},
+	})
+}
+
+func TestCompareMaven(t *testing.T) {
+	assertOrder(t, compareMaven, versionOrder{
+		{"1.0-alpha-1", "1.0-a1", "1.0-alpha1"},
+		{"1.0-beta-1", "1.0-b1"},
+		{"1.0-m1", "1.0-milestone-1"},
+		{"1.0-rc1", "1.0-cr1"},
+		{"1.0-SNAPSHOT"},
+		{"1.0", "1", "1.0.0", "1.0-ga", "1.0.Final", "1.0-release"},
+		{"1.0-sp1"},
+		{"1.0-foo"},
+		{"1.0-1"},
+		{"1.0.1"},
+		{"1.1"},
+		{"1.10"},
+	})
+}
+
+func TestCompareRubyGems(t *testing.T) {
+	assertOrder(t, compareRubyGems, versionOrder{
+		{"1.0.a"},
+		{"1.0.a.1", "1.0.a1"},
+		{"1.0.b1"},
+		{"1.0-rc1", "1.0.pre.rc1"},
+		{"1.0.rc1"},
+		{"1.0", "1", "1.0.0"},
+		{"1.0.0.1"},
+		{"1.2"},
+		{"1.10"},
+	})
+}
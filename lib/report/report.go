@@ -0,0 +1,295 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package report collects the outcome of enriching each component of an
+// SBOM, so that users can tell how well a provider covered their SBOM.
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+type Status string
+
+const (
+	// StatusEnriched means at least one field of the component was added or
+	// changed.
+	StatusEnriched Status = "enriched"
+	// StatusUnchanged means the provider was queried, but had nothing to add.
+	StatusUnchanged Status = "unchanged"
+	// StatusSkipped means the provider couldn't be queried for the
+	// component, for instance as it has no purl.
+	StatusSkipped Status = "skipped"
+	// StatusFailed means querying the provider failed.
+	StatusFailed Status = "failed"
+)
+
+// Report holds the outcomes of all components. The zero value is not usable,
+// use New. All methods are safe to call on a nil Report, which records
+// nothing.
+type Report struct {
+	mu         sync.Mutex
+	components map[string]*Component
+}
+
+// Component is a single component of the SBOM, identified by its bom-ref or
+// SPDX identifier.
+type Component struct {
+	ID        string              `json:"id"`
+	Purl      string              `json:"purl,omitempty"`
+	Providers map[string]*Outcome `json:"providers"`
+}
+
+// Outcome is the result of enriching a component with a single provider.
+type Outcome struct {
+	mu sync.Mutex
+
+	Status        Status    `json:"status"`
+	Reason        string    `json:"reason,omitempty"`
+	Requests      []Request `json:"requests,omitempty"`
+	FieldsAdded   []string  `json:"fields_added,omitempty"`
+	FieldsChanged []string  `json:"fields_changed,omitempty"`
+}
+
+// Request is a request made to a provider on behalf of a component.
+type Request struct {
+	Endpoint   string `json:"endpoint"`
+	StatusCode int    `json:"status_code,omitempty"`
+}
+
+// Summary counts the outcomes of a provider.
+type Summary struct {
+	Total     int     `json:"total"`
+	Enriched  int     `json:"enriched"`
+	Unchanged int     `json:"unchanged"`
+	Skipped   int     `json:"skipped"`
+	Failed    int     `json:"failed"`
+	Coverage  float64 `json:"coverage"`
+}
+
+func New() *Report {
+	return &Report{components: make(map[string]*Component)}
+}
+
+// Outcome returns the outcome of enriching the identified component with the
+// named provider, creating it if needed. The purl is recorded if given.
+func (r *Report) Outcome(provider, id, purl string) *Outcome {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comp, ok := r.components[id]
+	if !ok {
+		comp = &Component{ID: id, Providers: make(map[string]*Outcome)}
+		r.components[id] = comp
+	}
+	if purl != "" {
+		comp.Purl = purl
+	}
+
+	outcome, ok := comp.Providers[provider]
+	if !ok {
+		outcome = &Outcome{Status: StatusUnchanged}
+		comp.Providers[provider] = outcome
+	}
+	return outcome
+}
+
+// Request records a request made to the provider. A status code of zero
+// means no response was received.
+func (o *Outcome) Request(endpoint string, statusCode int) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.Requests = append(o.Requests, Request{Endpoint: endpoint, StatusCode: statusCode})
+}
+
+// Response records the request behind a response. Nil responses, as returned
+// when no response was received, are ignored.
+func (o *Outcome) Response(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	var endpoint string
+	if resp.Request != nil && resp.Request.URL != nil {
+		endpoint = resp.Request.URL.String()
+	}
+	o.Request(endpoint, resp.StatusCode)
+}
+
+// Added records a field which the component had no value for.
+func (o *Outcome) Added(field string) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.FieldsAdded = append(o.FieldsAdded, field)
+	o.Status = StatusEnriched
+}
+
+// Changed records a field whose existing value was changed.
+func (o *Outcome) Changed(field string) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.FieldsChanged = append(o.FieldsChanged, field)
+	o.Status = StatusEnriched
+}
+
+// RecordChange records field as added or changed by comparing the values it
+// had before and after enrichment. A field left without values, or with the
+// values it had, isn't recorded.
+func (o *Outcome) RecordChange(field string, before, after []string) {
+	switch {
+	case len(after) == 0 || slices.Equal(before, after):
+	case len(before) == 0:
+		o.Added(field)
+	default:
+		o.Changed(field)
+	}
+}
+
+// Skip records why the provider wasn't queried for the component.
+func (o *Outcome) Skip(reason string) {
+	o.fail(StatusSkipped, reason)
+}
+
+// Fail records why querying the provider failed. Fields enriched before the
+// failure are kept, and so is the enriched status.
+func (o *Outcome) Fail(reason string) {
+	o.fail(StatusFailed, reason)
+}
+
+func (o *Outcome) fail(status Status, reason string) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.Reason = reason
+	if o.Status != StatusEnriched {
+		o.Status = status
+	}
+}
+
+// Summary counts the outcomes of each provider.
+func (r *Report) Summary() map[string]*Summary {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	summaries := make(map[string]*Summary)
+	for _, comp := range r.components {
+		for provider, outcome := range comp.Providers {
+			s, ok := summaries[provider]
+			if !ok {
+				s = &Summary{}
+				summaries[provider] = s
+			}
+			s.Total++
+			switch outcome.Status {
+			case StatusEnriched:
+				s.Enriched++
+			case StatusUnchanged:
+				s.Unchanged++
+			case StatusSkipped:
+				s.Skipped++
+			case StatusFailed:
+				s.Failed++
+			}
+		}
+	}
+	for _, s := range summaries {
+		s.Coverage = float64(s.Enriched) / float64(s.Total) * 100
+	}
+	return summaries
+}
+
+// Write writes the report as JSON, with components sorted by identifier.
+func (r *Report) Write(w io.Writer) error {
+	summary := r.Summary()
+
+	var components []*Component
+	if r != nil {
+		r.mu.Lock()
+		for _, comp := range r.components {
+			components = append(components, comp)
+		}
+		r.mu.Unlock()
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].ID < components[j].ID
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Summary    map[string]*Summary `json:"summary"`
+		Components []*Component        `json:"components"`
+	}{summary, components})
+}
+
+// WriteFile writes the report as JSON to the named file.
+func (r *Report) WriteFile(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LogSummary logs a line per provider with its coverage of the SBOM.
+func (r *Report) LogSummary(logger *zerolog.Logger) {
+	summaries := r.Summary()
+
+	providers := make([]string, 0, len(summaries))
+	for provider := range summaries {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	for _, provider := range providers {
+		s := summaries[provider]
+		logger.Info().
+			Int("unchanged", s.Unchanged).
+			Int("skipped", s.Skipped).
+			Int("failed", s.Failed).
+			Msgf("%s enriched %d of %d components (%.1f%%)", provider, s.Enriched, s.Total, s.Coverage)
+	}
+}
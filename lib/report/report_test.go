@@ -0,0 +1,136 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNilReport(t *testing.T) {
+	var r *Report
+
+	out := r.Outcome("ecosystems", "pkg", "pkg:npm/pkg@1.0.0")
+	assert.Nil(t, out)
+
+	out.Request("https://example.com", 200)
+	out.Added("description")
+	out.Skip("no usable PackageURL")
+
+	assert.Nil(t, r.Summary())
+}
+
+func TestOutcomeStatus(t *testing.T) {
+	r := New()
+
+	r.Outcome("ecosystems", "unchanged", "")
+
+	enriched := r.Outcome("ecosystems", "enriched", "")
+	enriched.Added("license")
+	enriched.Fail("failed to get repository data")
+
+	r.Outcome("ecosystems", "skipped", "").Skip("no usable PackageURL")
+	r.Outcome("ecosystems", "failed", "").Fail("failed to get package data")
+
+	assert.Equal(t, StatusUnchanged, r.Outcome("ecosystems", "unchanged", "").Status)
+	assert.Equal(t, StatusEnriched, enriched.Status)
+	assert.Equal(t, "failed to get repository data", enriched.Reason)
+	assert.Equal(t, StatusSkipped, r.Outcome("ecosystems", "skipped", "").Status)
+	assert.Equal(t, StatusFailed, r.Outcome("ecosystems", "failed", "").Status)
+}
+
+func TestRecordChange(t *testing.T) {
+	out := New().Outcome("scorecard", "pkg", "")
+
+	out.RecordChange("unset", nil, nil)
+	out.RecordChange("same", []string{"a"}, []string{"a"})
+	out.RecordChange("added", nil, []string{"a"})
+	out.RecordChange("changed", []string{"a"}, []string{"a", "b"})
+
+	assert.Equal(t, []string{"added"}, out.FieldsAdded)
+	assert.Equal(t, []string{"changed"}, out.FieldsChanged)
+	assert.Equal(t, StatusEnriched, out.Status)
+
+	var nilOutcome *Outcome
+	nilOutcome.RecordChange("added", nil, []string{"a"})
+}
+
+func TestSummary(t *testing.T) {
+	r := New()
+	r.Outcome("ecosystems", "a", "pkg:npm/a@1.0.0").Added("description")
+	r.Outcome("ecosystems", "b", "pkg:npm/b@1.0.0").Skip("unsupported purl type")
+	r.Outcome("snyk", "a", "").Changed("advisor")
+	r.Outcome("snyk", "b", "").Fail("failed to fetch vulnerabilities")
+
+	summary := r.Summary()
+	assert.Equal(t, &Summary{Total: 2, Enriched: 1, Skipped: 1, Coverage: 50}, summary["ecosystems"])
+	assert.Equal(t, &Summary{Total: 2, Enriched: 1, Failed: 1, Coverage: 50}, summary["snyk"])
+}
+
+func TestWrite(t *testing.T) {
+	r := New()
+	r.Outcome("ecosystems", "b", "pkg:npm/b@1.0.0").Skip("unsupported purl type")
+	out := r.Outcome("ecosystems", "a", "pkg:npm/a@1.0.0")
+	out.Request("https://packages.ecosyste.ms/api/v1/registries/npmjs.org/packages/a", 200)
+	out.Added("description")
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Write(&buf))
+
+	assert.JSONEq(t, `{
+		"summary": {
+			"ecosystems": {"total": 2, "enriched": 1, "unchanged": 0, "skipped": 1, "failed": 0, "coverage": 50}
+		},
+		"components": [
+			{
+				"id": "a",
+				"purl": "pkg:npm/a@1.0.0",
+				"providers": {
+					"ecosystems": {
+						"status": "enriched",
+						"requests": [{"endpoint": "https://packages.ecosyste.ms/api/v1/registries/npmjs.org/packages/a", "status_code": 200}],
+						"fields_added": ["description"]
+					}
+				}
+			},
+			{
+				"id": "b",
+				"purl": "pkg:npm/b@1.0.0",
+				"providers": {
+					"ecosystems": {"status": "skipped", "reason": "unsupported purl type"}
+				}
+			}
+		]
+	}`, buf.String())
+}
+
+func TestLogSummary(t *testing.T) {
+	r := New()
+	r.Outcome("scorecard", "a", "").Added("scorecard")
+	r.Outcome("scorecard", "b", "")
+	r.Outcome("scorecard", "c", "")
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	r.LogSummary(&logger)
+
+	assert.Contains(t, buf.String(), "scorecard enriched 1 of 3 components (33.3%)")
+}
@@ -0,0 +1,138 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sbom
+
+import (
+	"fmt"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/spdx/v2/common"
+	"github.com/spdx/tools-golang/spdx/v2/v2_3"
+)
+
+const toolVendor = "Snyk"
+
+// RecordTool records that the document was modified by the named tool. For
+// CycloneDX the tool is added to the metadata tools and the BOM version is
+// incremented, for SPDX the tool is added to the creators.
+func (d *SBOMDocument) RecordTool(name, version string) {
+	switch bom := d.BOM.(type) {
+	case *cdx.BOM:
+		recordCDXTool(bom, name, version)
+	case *spdx.Document:
+		recordSPDXTool(bom, name, version)
+	}
+}
+
+func recordCDXTool(bom *cdx.BOM, name, version string) {
+	AddCDXTool(bom, name, version)
+
+	// The version of a BOM defaults to 1, and is incremented whenever the BOM
+	// is modified. It only identifies a revision together with a serial
+	// number.
+	if bom.Version < 1 {
+		bom.Version = 1
+	}
+	bom.Version++
+	if bom.SerialNumber == "" {
+		bom.SerialNumber = uuid.New().URN()
+	}
+}
+
+// AddCDXTool adds the named tool to the metadata tools of a CycloneDX BOM,
+// unless it's listed already. Unlike RecordTool, it doesn't count as a
+// revision of the BOM, so it suits tools creating one.
+func AddCDXTool(bom *cdx.BOM, name, version string) {
+	if bom.Metadata == nil {
+		bom.Metadata = &cdx.Metadata{}
+	}
+	if bom.Metadata.Tools == nil {
+		bom.Metadata.Tools = &cdx.ToolsChoice{}
+	}
+	tools := bom.Metadata.Tools
+
+	// The components form of tools was introduced with CycloneDX 1.5, and
+	// can't be mixed with the legacy list of tools.
+	legacy := tools.Tools != nil ||
+		(bom.SpecVersion < cdx.SpecVersion1_5 && tools.Components == nil && tools.Services == nil)
+
+	if legacy {
+		if tools.Tools == nil {
+			tools.Tools = &[]cdx.Tool{}
+		}
+		if !hasCDXLegacyTool(*tools.Tools, name, version) {
+			*tools.Tools = append(*tools.Tools, cdx.Tool{
+				Vendor:  toolVendor,
+				Name:    name,
+				Version: version,
+			})
+		}
+	} else {
+		if tools.Components == nil {
+			tools.Components = &[]cdx.Component{}
+		}
+		if !hasCDXToolComponent(*tools.Components, name, version) {
+			*tools.Components = append(*tools.Components, cdx.Component{
+				Type:      cdx.ComponentTypeApplication,
+				Publisher: toolVendor,
+				Name:      name,
+				Version:   version,
+			})
+		}
+	}
+}
+
+func hasCDXLegacyTool(tools []cdx.Tool, name, version string) bool {
+	for _, tool := range tools {
+		if tool.Name == name && tool.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func hasCDXToolComponent(components []cdx.Component, name, version string) bool {
+	for _, comp := range components {
+		if comp.Name == name && comp.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func recordSPDXTool(doc *spdx.Document, name, version string) {
+	if doc.CreationInfo == nil {
+		doc.CreationInfo = &v2_3.CreationInfo{}
+	}
+
+	creator := name
+	if version != "" {
+		creator = fmt.Sprintf("%s-%s", name, version)
+	}
+
+	for _, c := range doc.CreationInfo.Creators {
+		if c.CreatorType == "Tool" && c.Creator == creator {
+			return
+		}
+	}
+	doc.CreationInfo.Creators = append(doc.CreationInfo.Creators, common.Creator{
+		CreatorType: "Tool",
+		Creator:     creator,
+	})
+}
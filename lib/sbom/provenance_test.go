@@ -0,0 +1,96 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sbom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/spdx/v2/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordTool_CycloneDX1_5(t *testing.T) {
+	doc, err := DecodeSBOMDocument([]byte(`{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"version": 3,
+		"serialNumber": "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79",
+		"metadata": {"tools": {"components": [{"type": "application", "name": "syft"}]}}
+	}`))
+	require.NoError(t, err)
+
+	doc.RecordTool("parlay", "0.5.0")
+	doc.RecordTool("parlay", "0.5.0")
+
+	bom, ok := doc.BOM.(*cyclonedx.BOM)
+	require.True(t, ok)
+
+	assert.Equal(t, 5, bom.Version)
+	assert.Equal(t, "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79", bom.SerialNumber)
+	assert.Nil(t, bom.Metadata.Tools.Tools)
+	require.Len(t, *bom.Metadata.Tools.Components, 2)
+	assert.Equal(t, cyclonedx.Component{
+		Type:      cyclonedx.ComponentTypeApplication,
+		Publisher: "Snyk",
+		Name:      "parlay",
+		Version:   "0.5.0",
+	}, (*bom.Metadata.Tools.Components)[1])
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, doc.Encode(buf))
+	assert.Contains(t, buf.String(), `"components"`)
+}
+
+func TestRecordTool_CycloneDX1_4(t *testing.T) {
+	doc, err := DecodeSBOMDocument(fixedCycloneDX1_4JSON)
+	require.NoError(t, err)
+
+	doc.RecordTool("parlay", "0.5.0")
+
+	bom, ok := doc.BOM.(*cyclonedx.BOM)
+	require.True(t, ok)
+
+	assert.Equal(t, 2, bom.Version)
+	assert.True(t, strings.HasPrefix(bom.SerialNumber, "urn:uuid:"))
+	assert.Nil(t, bom.Metadata.Tools.Components)
+	assert.Equal(t, &[]cyclonedx.Tool{
+		{Vendor: "Snyk", Name: "parlay", Version: "0.5.0"},
+	}, bom.Metadata.Tools.Tools)
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, doc.Encode(buf))
+}
+
+func TestRecordTool_SPDX(t *testing.T) {
+	doc, err := DecodeSBOMDocument(fixedSPDX2_3JSON)
+	require.NoError(t, err)
+
+	doc.RecordTool("parlay", "0.5.0")
+	doc.RecordTool("parlay", "0.5.0")
+
+	bom, ok := doc.BOM.(*spdx.Document)
+	require.True(t, ok)
+
+	assert.Equal(t, []common.Creator{
+		{CreatorType: "Tool", Creator: "parlay-0.5.0"},
+	}, bom.CreationInfo.Creators)
+}
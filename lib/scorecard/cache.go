@@ -0,0 +1,74 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package scorecard
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// resultCache holds the scorecards fetched while enriching an SBOM, so that
+// packages from the same repository, such as those of a monorepo, only fetch
+// its scorecard once.
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	done      chan struct{}
+	scorecard *Scorecard
+	resp      *http.Response
+	err       error
+}
+
+func newResultCache() *resultCache {
+	return &resultCache{entries: make(map[string]*cacheEntry)}
+}
+
+// get returns the scorecard at url, fetching it unless it was fetched
+// before or is being fetched for another package, in which case it waits for
+// that fetch. fetched reports whether this call made the request. Failed
+// requests aren't cached, so that they're retried for the next package.
+func (c *resultCache) get(ctx context.Context, url string, fetch func() (*Scorecard, *http.Response, error)) (sc *Scorecard, resp *http.Response, fetched bool, err error) {
+	c.mu.Lock()
+	if e, ok := c.entries[url]; ok {
+		c.mu.Unlock()
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, nil, false, ctx.Err()
+		}
+		if e.err == nil {
+			return e.scorecard, e.resp, false, nil
+		}
+		// The request failed for another package, so try again.
+		return c.get(ctx, url, fetch)
+	}
+	e := &cacheEntry{done: make(chan struct{})}
+	c.entries[url] = e
+	c.mu.Unlock()
+
+	e.scorecard, e.resp, e.err = fetch()
+	if e.err != nil {
+		c.mu.Lock()
+		delete(c.entries, url)
+		c.mu.Unlock()
+	}
+	close(e.done)
+	return e.scorecard, e.resp, true, e.err
+}
@@ -0,0 +1,86 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scorecard
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/snyk/parlay/internal/fields"
+	"github.com/snyk/parlay/internal/pool"
+	"github.com/snyk/parlay/internal/repourl"
+	"github.com/snyk/parlay/lib/report"
+)
+
+// reportProvider names OpenSSF Scorecard in enrichment reports.
+const reportProvider = "scorecard"
+
+// Config controls how SBOMs are enriched with OpenSSF Scorecard data.
+type Config struct {
+	// APIURL is the base URL of the Scorecard API, which may be a
+	// self-hosted mirror.
+	APIURL string
+
+	// Header is added to every request to the Scorecard API, e.g. to
+	// authenticate with a private mirror.
+	Header http.Header
+
+	// Concurrency is the number of packages enriched in parallel.
+	Concurrency int
+
+	// RequestTimeout bounds each request to ecosyste.ms and the Scorecard
+	// API. A zero value leaves requests bound only by the context enrichment
+	// runs under.
+	RequestTimeout time.Duration
+
+	// Fields selects the fields to enrich. All fields are enriched if nil.
+	Fields *fields.Selection
+
+	// Report, if set, collects the outcome of enriching each package.
+	Report *report.Report
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		APIURL:      "https://api.securityscorecards.dev",
+		Concurrency: pool.DefaultConcurrency,
+	}
+}
+
+var knownFields = []string{"scorecard", "scorecard_results"}
+
+var fieldAliases = map[string][]string{
+	"externalRefs": {"scorecard"},
+	"properties":   {"scorecard_results"},
+}
+
+// SelectFields restricts enrichment to the included fields, or to all fields
+// if none are included, minus the excluded fields.
+func (cfg *Config) SelectFields(include, exclude []string) error {
+	selection, err := fields.NewSelection(knownFields, fieldAliases, include, exclude)
+	if err != nil {
+		return err
+	}
+	cfg.Fields = selection
+	return nil
+}
+
+// projectURL returns the URL of the scorecard of a source repository.
+func (cfg *Config) projectURL(repo *repourl.Repository) string {
+	return strings.TrimSuffix(cfg.APIURL, "/") + "/projects/" + repo.String()
+}
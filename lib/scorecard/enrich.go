@@ -17,19 +17,145 @@
 package scorecard
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
 	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
 	"github.com/spdx/tools-golang/spdx"
 
+	"github.com/snyk/parlay/ecosystems/packages"
+	"github.com/snyk/parlay/internal/httpclient"
+	"github.com/snyk/parlay/internal/repourl"
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/ecosystems"
+	"github.com/snyk/parlay/lib/report"
 	"github.com/snyk/parlay/lib/sbom"
 )
 
-func EnrichSBOM(doc *sbom.SBOMDocument) *sbom.SBOMDocument {
+// EnrichSBOM adds links to the OpenSSF Scorecard of each package's source
+// repository. When ctx is done, packages which weren't enriched yet are left
+// as they are. If no fields are selected, nothing is looked up.
+func EnrichSBOM(ctx context.Context, cfg *Config, doc *sbom.SBOMDocument) *sbom.SBOMDocument {
+	if !cfg.Fields.Any(knownFields...) {
+		return doc
+	}
+
+	cache := newResultCache()
 	switch bom := doc.BOM.(type) {
 	case *cdx.BOM:
-		enrichCDX(bom)
+		enrichCDX(ctx, cfg, bom, cache)
 	case *spdx.Document:
-		enrichSPDX(bom)
+		enrichSPDX(ctx, cfg, bom, cache)
 	}
 
 	return doc
 }
+
+// fetchScorecard requests the scorecard at url. The scorecard is only read
+// from successful responses, and the body of the response is closed before
+// returning.
+func fetchScorecard(ctx context.Context, cfg *Config, url string) (*Scorecard, *http.Response, error) {
+	ctx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	httpclient.SetHeaders(req, cfg.Header)
+	resp, err := httpclient.Default().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp, err
+	}
+	var result Result
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, resp, fmt.Errorf("failed to decode scorecard: %w", err)
+	}
+	return &Scorecard{URL: url, Body: body, Result: &result}, resp, nil
+}
+
+// lookupRepository finds the source repository of a package. Repository
+// URLs already in the SBOM are used if any identifies a repository OpenSSF
+// Scorecard scores, and otherwise the package is looked up on ecosyste.ms.
+// If the package has no repository OpenSSF Scorecard can score, it records
+// why on out and returns false.
+func lookupRepository(ctx context.Context, cfg *Config, purl *packageurl.PackageURL, known []string, out *report.Outcome) (*repourl.Repository, bool) {
+	for _, u := range known {
+		if repo, err := parseRepository(u); err == nil {
+			return repo, true
+		}
+	}
+
+	if purl == nil {
+		out.Skip("no usable PackageURL")
+		return nil, false
+	}
+	resp, err := fetchPackageData(ctx, cfg, *purl)
+	if err != nil {
+		out.Fail("failed to get package data: " + err.Error())
+		return nil, false
+	}
+	out.Response(resp.HTTPResponse)
+
+	if resp.JSON200 == nil || resp.JSON200.RepositoryUrl == nil || *resp.JSON200.RepositoryUrl == "" {
+		out.Skip("no repository URL on ecosyste.ms")
+		return nil, false
+	}
+	return scoredRepository(*resp.JSON200.RepositoryUrl, out)
+}
+
+// scoredRepository parses a repository URL, returning false if OpenSSF
+// Scorecard doesn't score the repository.
+func scoredRepository(repoURL string, out *report.Outcome) (*repourl.Repository, bool) {
+	repo, err := parseRepository(repoURL)
+	if err != nil {
+		out.Skip(err.Error())
+		return nil, false
+	}
+	return repo, true
+}
+
+// lookupScorecard fetches the scorecard of a repository, unless it was
+// fetched for another package already, returning it along with its URL. If
+// there is no scorecard, it records why on out and returns false.
+func lookupScorecard(ctx context.Context, cfg *Config, cache *resultCache, repo *repourl.Repository, out *report.Outcome) (*Result, string, bool) {
+	url := cfg.projectURL(repo)
+	sc, resp, fetched, err := cache.get(ctx, url, func() (*Scorecard, *http.Response, error) {
+		return fetchScorecard(ctx, cfg, url)
+	})
+	if fetched {
+		out.Response(resp)
+	}
+	if err != nil {
+		out.Fail("failed to get scorecard: " + err.Error())
+		return nil, "", false
+	}
+	if sc == nil {
+		out.Fail("no scorecard for " + repo.URL())
+		return nil, "", false
+	}
+	return sc.Result, url, true
+}
+
+// fetchPackageData looks up a package on ecosyste.ms, bounded by the request
+// timeout. Lookups go through the cache shared with ecosystems enrichment, so
+// packages enriched by both are only looked up once.
+func fetchPackageData(ctx context.Context, cfg *Config, purl packageurl.PackageURL) (*packages.GetRegistryPackageResponse, error) {
+	ctx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+	defer cancel()
+
+	return ecosystems.GetGlobalCache().GetPackageData(ctx, purl)
+}
@@ -17,70 +17,113 @@
 package scorecard
 
 import (
-	"net/http"
-	"regexp"
+	"context"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/package-url/packageurl-go"
-	"github.com/remeh/sizedwaitgroup"
 
+	"github.com/snyk/parlay/internal/pool"
 	"github.com/snyk/parlay/internal/utils"
-	"github.com/snyk/parlay/lib/ecosystems"
+	"github.com/snyk/parlay/lib/report"
 )
 
-var httpProtocolsRe = regexp.MustCompile(`^https?:\/\/`)
-
 func cdxEnrichExternalReference(comp *cdx.Component, url, comment string, refType cdx.ExternalReferenceType) {
-	ext := cdx.ExternalReference{
+	utils.AddCDXExternalReference(comp, cdx.ExternalReference{
 		URL:     url,
 		Comment: comment,
 		Type:    refType,
-	}
-
-	if comp.ExternalReferences == nil {
-		comp.ExternalReferences = &[]cdx.ExternalReference{ext}
-	} else {
-		*comp.ExternalReferences = append(*comp.ExternalReferences, ext)
-	}
+	})
 }
 
-func enrichCDX(bom *cdx.BOM) {
+func enrichCDX(ctx context.Context, cfg *Config, bom *cdx.BOM, cache *resultCache) {
 	comps := utils.DiscoverCDXComponents(bom)
 
-	wg := sizedwaitgroup.New(20)
-
-	for i := range comps {
-		wg.Add()
-		go func(component *cdx.Component) {
-			defer wg.Done()
-
-			purl, err := packageurl.FromString(component.PackageURL)
-			if err != nil {
-				return
+	pool.Run(cfg.Concurrency, comps, func(component *cdx.Component) {
+		out := cfg.Report.Outcome(reportProvider, utils.CDXComponentID(component), component.PackageURL)
+		if err := ctx.Err(); err != nil {
+			out.Skip("enrichment interrupted: " + err.Error())
+			return
+		}
+
+		var purl *packageurl.PackageURL
+		if p, err := packageurl.FromString(component.PackageURL); err == nil {
+			purl = &p
+		}
+
+		repo, ok := lookupRepository(ctx, cfg, purl, cdxVCSURLs(component), out)
+		if !ok {
+			return
+		}
+		result, scorecardURL, ok := lookupScorecard(ctx, cfg, cache, repo, out)
+		if !ok {
+			return
+		}
+
+		if cfg.Fields.Selected("scorecard") {
+			refs := 0
+			if component.ExternalReferences != nil {
+				refs = len(*component.ExternalReferences)
 			}
-
-			resp, err := ecosystems.GetPackageData(purl)
-			if err != nil {
-				return
+			cdxEnrichExternalReference(component, scorecardURL, "OpenSSF Scorecard", cdx.ERTypeOther)
+			if len(*component.ExternalReferences) > refs {
+				out.Added("scorecard")
 			}
+		}
+		if cfg.Fields.Selected("scorecard_results") {
+			result.RecordCDX(component, out)
+		}
+	})
+}
 
-			if resp.JSON200 == nil || resp.JSON200.RepositoryUrl == nil || *resp.JSON200.RepositoryUrl == "" {
-				return
-			}
+// cdxVCSURLs returns the URLs of the component's VCS references.
+func cdxVCSURLs(comp *cdx.Component) []string {
+	if comp.ExternalReferences == nil {
+		return nil
+	}
+	var urls []string
+	for _, ref := range *comp.ExternalReferences {
+		if ref.Type == cdx.ERTypeVCS {
+			urls = append(urls, ref.URL)
+		}
+	}
+	return urls
+}
 
-			scorecardUrl := httpProtocolsRe.ReplaceAllString(*resp.JSON200.RepositoryUrl, "https://api.securityscorecards.dev/projects/")
-			response, err := http.Get(scorecardUrl)
-			if err != nil {
-				return
-			}
-			defer response.Body.Close()
-			if response.StatusCode != http.StatusOK {
-				return
-			}
+// RecordCDX records the scores of the scorecard as properties of a
+// component, replacing those of any earlier scorecard, and notes the change
+// on out. It lets other sources of scorecards, such as deps.dev, record them
+// in the same form.
+func (r *Result) RecordCDX(comp *cdx.Component, out *report.Outcome) {
+	before, after := cdxEnrichResults(comp, r)
+	out.RecordChange("scorecard_results", before, after)
+}
 
-			cdxEnrichExternalReference(component, scorecardUrl, "OpenSSF Scorecard", cdx.ERTypeOther)
-		}(comps[i])
+// cdxEnrichResults records the scores of a scorecard as properties, replacing
+// those of any earlier scorecard. It returns the scorecard properties the
+// component had before and has after.
+func cdxEnrichResults(comp *cdx.Component, result *Result) (before, after []string) {
+	props := result.properties()
+	if len(props) == 0 {
+		return nil, nil
 	}
 
-	wg.Wait()
+	var kept []cdx.Property
+	if comp.Properties != nil {
+		for _, p := range *comp.Properties {
+			if isScorecardProperty(p.Name) {
+				before = append(before, p.Name+"="+p.Value)
+				continue
+			}
+			kept = append(kept, p)
+		}
+	}
+
+	for _, p := range props {
+		kept = append(kept, cdx.Property{Name: p.name, Value: p.value})
+		after = append(after, p.name+"="+p.value)
+	}
+	if len(kept) > 0 {
+		comp.Properties = &kept
+	}
+	return before, after
 }
@@ -17,50 +17,114 @@
 package scorecard
 
 import (
-	"net/http"
-	"strings"
+	"context"
+	"time"
 
-	"github.com/remeh/sizedwaitgroup"
 	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/spdx/v2/common"
 	spdx_2_3 "github.com/spdx/tools-golang/spdx/v2/v2_3"
 
+	"github.com/snyk/parlay/internal/pool"
 	"github.com/snyk/parlay/internal/utils"
-	"github.com/snyk/parlay/lib/ecosystems"
+	"github.com/snyk/parlay/lib/report"
 )
 
-func enrichSPDX(bom *spdx.Document) {
-	wg := sizedwaitgroup.New(20)
+func enrichSPDX(ctx context.Context, cfg *Config, bom *spdx.Document, cache *resultCache) {
+	pool.Run(cfg.Concurrency, bom.Packages, func(pkg *spdx_2_3.Package) {
+		out := cfg.Report.Outcome(reportProvider, string(pkg.PackageSPDXIdentifier), "")
+		if err := ctx.Err(); err != nil {
+			out.Skip("enrichment interrupted: " + err.Error())
+			return
+		}
 
-	for i, pkg := range bom.Packages {
-		wg.Add()
+		// Packages without a PackageURL can still be scored if the SBOM names
+		// their repository.
+		purl, err := utils.GetPurlFromSPDXPackage(pkg)
+		if err == nil {
+			out = cfg.Report.Outcome(reportProvider, string(pkg.PackageSPDXIdentifier), purl.String())
+		}
+		repo, ok := lookupRepository(ctx, cfg, purl, spdxVCSURLs(pkg), out)
+		if !ok {
+			return
+		}
+		result, scorecardURL, ok := lookupScorecard(ctx, cfg, cache, repo, out)
+		if !ok {
+			return
+		}
 
-		go func(pkg *spdx_2_3.Package, i int) {
-			defer wg.Done()
-
-			purl, err := utils.GetPurlFromSPDXPackage(pkg)
-			if err != nil {
-				return
+		if cfg.Fields.Selected("scorecard") {
+			refs := len(pkg.PackageExternalReferences)
+			utils.AddSPDXExternalReference(pkg, &spdx_2_3.PackageExternalReference{
+				Category: spdx.CategoryOther,
+				RefType:  "openssfscorecard",
+				Locator:  scorecardURL,
+			})
+			if len(pkg.PackageExternalReferences) > refs {
+				out.Added("scorecard")
 			}
+		}
+		if cfg.Fields.Selected("scorecard_results") {
+			result.RecordSPDX(pkg, out)
+		}
+	})
+}
 
-			resp, err := ecosystems.GetPackageData(*purl)
-			if err != nil || resp.JSON200 == nil || resp.JSON200.RepositoryUrl == nil {
-				return
-			}
+// spdxVCSURLs returns the URLs of the package's VCS references, followed by
+// its download location, which is often the repository it was built from.
+func spdxVCSURLs(pkg *spdx_2_3.Package) []string {
+	var urls []string
+	for _, ref := range pkg.PackageExternalReferences {
+		if ref.RefType == "vcs" {
+			urls = append(urls, ref.Locator)
+		}
+	}
+	if pkg.PackageDownloadLocation != "" {
+		urls = append(urls, pkg.PackageDownloadLocation)
+	}
+	return urls
+}
 
-			scURL := strings.ReplaceAll(*resp.JSON200.RepositoryUrl, "https://", "https://api.securityscorecards.dev/projects/")
+// RecordSPDX records the scores of the scorecard as annotations of a
+// package, replacing those of any earlier scorecard, and notes the change on
+// out.
+func (r *Result) RecordSPDX(pkg *spdx_2_3.Package, out *report.Outcome) {
+	before, after := spdxEnrichResults(pkg, r)
+	out.RecordChange("scorecard_results", before, after)
+}
 
-			response, err := http.Get(scURL)
-			if err != nil || response.StatusCode != http.StatusOK {
-				return
-			}
+// spdxEnrichResults records the scores of a scorecard as annotations,
+// replacing those of any earlier scorecard. It returns the scorecard
+// annotations the package had before and has after.
+func spdxEnrichResults(pkg *spdx_2_3.Package, result *Result) (before, after []string) {
+	props := result.properties()
+	if len(props) == 0 {
+		return nil, nil
+	}
 
-			pkg.PackageExternalReferences = append(pkg.PackageExternalReferences, &spdx_2_3.PackageExternalReference{
-				Category: spdx.CategoryOther,
-				RefType:  "openssfscorecard",
-				Locator:  scURL,
-			})
-		}(pkg, i)
+	var kept []spdx_2_3.Annotation
+	for _, a := range pkg.Annotations {
+		if isScorecardProperty(a.AnnotationComment) {
+			before = append(before, a.AnnotationComment)
+			continue
+		}
+		kept = append(kept, a)
 	}
 
-	wg.Wait()
+	date := time.Now().UTC().Format(time.RFC3339)
+	for _, p := range props {
+		comment := p.name + "=" + p.value
+		kept = append(kept, spdx_2_3.Annotation{
+			Annotator: common.Annotator{
+				Annotator:     "parlay",
+				AnnotatorType: "Tool",
+			},
+			AnnotationDate:           date,
+			AnnotationType:           "OTHER",
+			AnnotationSPDXIdentifier: common.MakeDocElementID("", string(pkg.PackageSPDXIdentifier)),
+			AnnotationComment:        comment,
+		})
+		after = append(after, comment)
+	}
+	pkg.Annotations = kept
+	return before, after
 }
@@ -17,6 +17,7 @@
 package scorecard
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"testing"
@@ -26,11 +27,14 @@ import (
 	"github.com/spdx/tools-golang/spdx"
 	spdx_2_3 "github.com/spdx/tools-golang/spdx/v2/v2_3"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/snyk/parlay/lib/ecosystems"
+	"github.com/snyk/parlay/lib/report"
 	"github.com/snyk/parlay/lib/sbom"
 )
 
-const scorecardURL = "https://api.securityscorecards.dev/projects/example.com/repository"
+const scorecardURL = "https://api.securityscorecards.dev/projects/github.com/example/repository"
 
 func TestEnrichSBOM_CycloneDX(t *testing.T) {
 	teardown := setupEcosystemsAPIMock(t)
@@ -45,7 +49,7 @@ func TestEnrichSBOM_CycloneDX(t *testing.T) {
 	}
 	doc := &sbom.SBOMDocument{BOM: bom}
 
-	EnrichSBOM(doc)
+	EnrichSBOM(context.Background(), DefaultConfig(), doc)
 
 	assert.NotNil(t, bom.Components)
 	assert.Len(t, *bom.Components, 1)
@@ -81,7 +85,7 @@ func TestEnrichSBOM_CycloneDX_NestedComponents(t *testing.T) {
 	}
 	doc := &sbom.SBOMDocument{BOM: bom}
 
-	EnrichSBOM(doc)
+	EnrichSBOM(context.Background(), DefaultConfig(), doc)
 
 	assert.NotNil(t, bom.Components)
 	assert.Len(t, *bom.Components, 1)
@@ -96,13 +100,17 @@ func TestEnrichSBOM_CycloneDX_NestedComponents(t *testing.T) {
 	}
 
 	total := httpmock.GetTotalCallCount()
-	assert.Equal(t, 4, total)
+	assert.Equal(t, 3, total)
 	calls := httpmock.GetCallCountInfo()
 	assert.Equal(t, 2, calls[`GET =~^https://packages.ecosyste.ms/api/v1/registries`])
+	// Both components resolve to the same repository, so its scorecard is
+	// only fetched once.
+	assert.Equal(t, 1, calls["GET "+scorecardURL])
 }
 
 func TestEnrichSBOM_ErrorFetchingPackageData(t *testing.T) {
 	httpmock.Activate()
+	ecosystems.ResetGlobalCache()
 	defer httpmock.DeactivateAndReset()
 
 	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
@@ -121,7 +129,7 @@ func TestEnrichSBOM_ErrorFetchingPackageData(t *testing.T) {
 	}
 	doc := &sbom.SBOMDocument{BOM: bom}
 
-	EnrichSBOM(doc)
+	EnrichSBOM(context.Background(), DefaultConfig(), doc)
 
 	assert.NotNil(t, bom.Components)
 	assert.Len(t, *bom.Components, 1)
@@ -132,13 +140,14 @@ func TestEnrichSBOM_ErrorFetchingPackageData(t *testing.T) {
 
 func TestEnrichSBOM_ErrorFetchingScorecard(t *testing.T) {
 	httpmock.Activate()
+	ecosystems.ResetGlobalCache()
 	defer httpmock.DeactivateAndReset()
 
-	mockPackageData := `{"JSON200": {"RepositoryUrl": "https://example.com/repository"}}`
+	mockPackageData := `{"JSON200": {"RepositoryUrl": "https://github.com/example/repository"}}`
 	httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
 		httpmock.NewStringResponder(http.StatusOK, mockPackageData))
 
-	httpmock.RegisterResponder("GET", "https://api.securityscorecards.dev/projects/example.com/repository",
+	httpmock.RegisterResponder("GET", "https://api.securityscorecards.dev/projects/github.com/example/repository",
 		httpmock.NewErrorResponder(assert.AnError))
 
 	httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
@@ -154,7 +163,7 @@ func TestEnrichSBOM_ErrorFetchingScorecard(t *testing.T) {
 	}
 	doc := &sbom.SBOMDocument{BOM: bom}
 
-	EnrichSBOM(doc)
+	EnrichSBOM(context.Background(), DefaultConfig(), doc)
 
 	assert.NotNil(t, bom.Components)
 	assert.Len(t, *bom.Components, 1)
@@ -163,6 +172,56 @@ func TestEnrichSBOM_ErrorFetchingScorecard(t *testing.T) {
 	assert.Nil(t, enrichedComponent.ExternalReferences)
 }
 
+func TestEnrichSBOM_Mirrors(t *testing.T) {
+	httpmock.Activate()
+	ecosystems.ResetGlobalCache()
+	defer httpmock.DeactivateAndReset()
+
+	ecosystems.SetEndpoints(&ecosystems.Endpoints{
+		PackagesURL: "https://ecosystems.example.com/api/v1",
+		ReposURL:    "https://ecosystems.example.com/repos/api/v1",
+		Header:      http.Header{"Authorization": {"Bearer packages-token"}},
+	})
+	defer ecosystems.SetEndpoints(nil)
+
+	httpmock.RegisterResponder("GET", `=~^https://ecosystems.example.com/api/v1/registries`,
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "Bearer packages-token", req.Header.Get("Authorization"))
+			return httpmock.NewJsonResponse(200, map[string]interface{}{
+				"repository_url": "https://github.com/example/repository",
+			})
+		},
+	)
+	httpmock.RegisterResponder("GET", "https://scorecard.example.com/projects/github.com/example/repository",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "Bearer scorecard-token", req.Header.Get("Authorization"))
+			return httpmock.NewStringResponse(http.StatusOK, "{}"), nil
+		},
+	)
+	httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("unexpected HTTP request: " + req.URL.String())
+	})
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{
+				PackageURL: "pkg:type/example",
+			},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+
+	cfg := DefaultConfig()
+	cfg.APIURL = "https://scorecard.example.com/"
+	cfg.Header = http.Header{"Authorization": {"Bearer scorecard-token"}}
+	EnrichSBOM(context.Background(), cfg, doc)
+
+	refs := (*bom.Components)[0].ExternalReferences
+	require.NotNil(t, refs)
+	assert.Equal(t, "https://scorecard.example.com/projects/github.com/example/repository", (*refs)[0].URL)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
 func TestEnrichSBOM_SPDX(t *testing.T) {
 	teardown := setupEcosystemsAPIMock(t)
 	defer teardown()
@@ -182,7 +241,7 @@ func TestEnrichSBOM_SPDX(t *testing.T) {
 	}
 	doc := &sbom.SBOMDocument{BOM: bom}
 
-	EnrichSBOM(doc)
+	EnrichSBOM(context.Background(), DefaultConfig(), doc)
 
 	pkg := bom.Packages[0]
 	assert.NotNil(t, pkg.PackageExternalReferences)
@@ -198,12 +257,13 @@ func setupEcosystemsAPIMock(t *testing.T) func() {
 	t.Helper()
 
 	httpmock.Activate()
+	ecosystems.ResetGlobalCache()
 	httpmock.RegisterResponder(
 		"GET",
 		"=~^https://packages.ecosyste.ms/api/v1/registries",
 		func(req *http.Request) (*http.Response, error) {
 			return httpmock.NewJsonResponse(200, map[string]interface{}{
-				"repository_url": "https://example.com/repository",
+				"repository_url": "https://github.com/example/repository",
 			})
 		},
 	)
@@ -218,3 +278,241 @@ func setupEcosystemsAPIMock(t *testing.T) func() {
 
 	return httpmock.DeactivateAndReset
 }
+
+const scorecardResult = `{
+  "date": "2024-05-20T00:00:00Z",
+  "repo": {"name": "github.com/example/repository", "commit": "4b2c1a"},
+  "score": 6.4,
+  "checks": [
+    {"name": "Maintained", "score": 0, "reason": "0 commit(s) out of 30 and 0 issue activity out of 30 found in the last 90 days"},
+    {"name": "Branch-Protection", "score": 3, "reason": "branch protection is not maximal on development and all release branches"},
+    {"name": "Fuzzing", "score": -1, "reason": "internal error"}
+  ]
+}`
+
+func setupScorecardResultMock(t *testing.T) func() {
+	t.Helper()
+
+	teardown := setupEcosystemsAPIMock(t)
+	httpmock.RegisterResponder("GET", scorecardURL,
+		httpmock.NewStringResponder(http.StatusOK, scorecardResult))
+	return teardown
+}
+
+func TestEnrichSBOM_CycloneDX_Results(t *testing.T) {
+	teardown := setupScorecardResultMock(t)
+	defer teardown()
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{
+				PackageURL: "pkg:type/example",
+				Properties: &[]cdx.Property{
+					{Name: "scorecard:score", Value: "9"},
+					{Name: "scorecard:check:Pinned-Dependencies", Value: "10"},
+					{Name: "ecosystems:downloads", Value: "100"},
+				},
+			},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+
+	EnrichSBOM(context.Background(), DefaultConfig(), doc)
+
+	assert.Equal(t, []cdx.Property{
+		{Name: "ecosystems:downloads", Value: "100"},
+		{Name: "scorecard:score", Value: "6.4"},
+		{Name: "scorecard:date", Value: "2024-05-20T00:00:00Z"},
+		{Name: "scorecard:commit", Value: "4b2c1a"},
+		{Name: "scorecard:check:Maintained", Value: "0"},
+		{Name: "scorecard:check:Branch-Protection", Value: "3"},
+		{Name: "scorecard:check:Fuzzing", Value: "-1"},
+	}, *(*bom.Components)[0].Properties, "replaces the results of earlier scorecards")
+}
+
+func TestEnrichSBOM_SPDX_Results(t *testing.T) {
+	teardown := setupScorecardResultMock(t)
+	defer teardown()
+
+	pkg := &spdx_2_3.Package{
+		PackageSPDXIdentifier: "pkg-1",
+		PackageExternalReferences: []*spdx_2_3.PackageExternalReference{
+			{
+				Category: spdx.CategoryPackageManager,
+				RefType:  "purl",
+				Locator:  "pkg:golang/snyk/parlay",
+			},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: &spdx.Document{Packages: []*spdx_2_3.Package{pkg}}}
+
+	EnrichSBOM(context.Background(), DefaultConfig(), doc)
+
+	var comments []string
+	for _, a := range pkg.Annotations {
+		assert.Equal(t, "parlay", a.Annotator.Annotator)
+		assert.Equal(t, "pkg-1", string(a.AnnotationSPDXIdentifier.ElementRefID))
+		comments = append(comments, a.AnnotationComment)
+	}
+	assert.Equal(t, []string{
+		"scorecard:score=6.4",
+		"scorecard:date=2024-05-20T00:00:00Z",
+		"scorecard:commit=4b2c1a",
+		"scorecard:check:Maintained=0",
+		"scorecard:check:Branch-Protection=3",
+		"scorecard:check:Fuzzing=-1",
+	}, comments)
+}
+
+func TestEnrichSBOM_RepositoryURLs(t *testing.T) {
+	tests := []struct {
+		repositoryURL string
+		scorecardURL  string
+		reason        string
+	}{
+		{"git+https://github.com/example/repository.git", "https://api.securityscorecards.dev/projects/github.com/example/repository", ""},
+		{"git@github.com:example/repository", "https://api.securityscorecards.dev/projects/github.com/example/repository", ""},
+		{"http://github.com/example/repository/tree/main", "https://api.securityscorecards.dev/projects/github.com/example/repository", ""},
+		{"https://gitlab.com/example/group/repository/-/tree/main", "https://api.securityscorecards.dev/projects/gitlab.com/example/group/repository", ""},
+		{"https://bitbucket.org/example/repository", "", "no scorecards for repositories on bitbucket.org"},
+		{"https://example.com/repository", "", `unrecognized repository URL "https://example.com/repository"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.repositoryURL, func(t *testing.T) {
+			httpmock.Activate()
+			ecosystems.ResetGlobalCache()
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder("GET", `=~^https://packages.ecosyste.ms/api/v1/registries`,
+				httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{"repository_url": tt.repositoryURL}))
+			if tt.scorecardURL != "" {
+				httpmock.RegisterResponder("GET", tt.scorecardURL, httpmock.NewStringResponder(http.StatusOK, "{}"))
+			}
+
+			for _, doc := range []*sbom.SBOMDocument{
+				{BOM: &cdx.BOM{Components: &[]cdx.Component{{BOMRef: "pkg", PackageURL: "pkg:npm/example"}}}},
+				{BOM: &spdx.Document{Packages: []*spdx_2_3.Package{{
+					PackageSPDXIdentifier: "pkg",
+					PackageExternalReferences: []*spdx_2_3.PackageExternalReference{
+						{Category: spdx.CategoryPackageManager, RefType: "purl", Locator: "pkg:npm/example"},
+					},
+				}}}},
+			} {
+				cfg := DefaultConfig()
+				cfg.Report = report.New()
+				EnrichSBOM(context.Background(), cfg, doc)
+
+				outcome := cfg.Report.Outcome(reportProvider, "pkg", "")
+				if tt.scorecardURL == "" {
+					assert.Equal(t, report.StatusSkipped, outcome.Status)
+					assert.Equal(t, tt.reason, outcome.Reason)
+					continue
+				}
+				assert.Equal(t, report.StatusEnriched, outcome.Status)
+				assert.Equal(t, tt.scorecardURL, outcome.Requests[len(outcome.Requests)-1].Endpoint)
+			}
+		})
+	}
+}
+
+func TestEnrichSBOM_KnownRepository(t *testing.T) {
+	httpmock.Activate()
+	ecosystems.ResetGlobalCache()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", scorecardURL, httpmock.NewStringResponder(http.StatusOK, "{}"))
+	httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("unexpected HTTP request: " + req.URL.String())
+	})
+
+	cdxBOM := &cdx.BOM{Components: &[]cdx.Component{
+		{
+			PackageURL: "pkg:npm/example@1.0.0",
+			ExternalReferences: &[]cdx.ExternalReference{
+				{Type: cdx.ERTypeWebsite, URL: "https://example.com"},
+				{Type: cdx.ERTypeVCS, URL: "git+https://github.com/example/repository.git"},
+			},
+		},
+		{
+			// Without a PackageURL the repository can still be scored.
+			Name: "example",
+			ExternalReferences: &[]cdx.ExternalReference{
+				{Type: cdx.ERTypeVCS, URL: "git@github.com:example/repository.git"},
+			},
+		},
+	}}
+	spdxBOM := &spdx.Document{Packages: []*spdx_2_3.Package{
+		{PackageDownloadLocation: "git+https://github.com/example/repository@v1.0.0"},
+	}}
+
+	for _, doc := range []*sbom.SBOMDocument{{BOM: cdxBOM}, {BOM: spdxBOM}} {
+		EnrichSBOM(context.Background(), DefaultConfig(), doc)
+	}
+
+	for _, comp := range *cdxBOM.Components {
+		refs := *comp.ExternalReferences
+		assert.Equal(t, scorecardURL, refs[len(refs)-1].URL)
+	}
+	pkg := spdxBOM.Packages[0]
+	require.Len(t, pkg.PackageExternalReferences, 1)
+	assert.Equal(t, scorecardURL, pkg.PackageExternalReferences[0].Locator)
+
+	// The scorecard is fetched once per SBOM, and ecosyste.ms isn't asked.
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestEnrichSBOM_UnscoredKnownRepository(t *testing.T) {
+	teardown := setupEcosystemsAPIMock(t)
+	defer teardown()
+
+	bom := &cdx.BOM{Components: &[]cdx.Component{
+		{
+			BOMRef:     "example",
+			PackageURL: "pkg:npm/example@1.0.0",
+			ExternalReferences: &[]cdx.ExternalReference{
+				{Type: cdx.ERTypeVCS, URL: "https://bitbucket.org/example/repository"},
+			},
+		},
+	}}
+
+	cfg := DefaultConfig()
+	cfg.Report = report.New()
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	// Scorecard doesn't score Bitbucket, so the repository is looked up on
+	// ecosyste.ms instead.
+	refs := *(*bom.Components)[0].ExternalReferences
+	assert.Equal(t, scorecardURL, refs[len(refs)-1].URL)
+	assert.Equal(t, report.StatusEnriched, cfg.Report.Outcome(reportProvider, "example", "").Status)
+}
+
+func TestEnrichSBOM_SelectedFields(t *testing.T) {
+	teardown := setupScorecardResultMock(t)
+	defer teardown()
+
+	bom := &cdx.BOM{Components: &[]cdx.Component{{PackageURL: "pkg:type/example"}}}
+
+	cfg := DefaultConfig()
+	require.NoError(t, cfg.SelectFields([]string{"externalRefs"}, nil))
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	comp := (*bom.Components)[0]
+	require.NotNil(t, comp.ExternalReferences)
+	assert.Equal(t, scorecardURL, (*comp.ExternalReferences)[0].URL)
+	assert.Nil(t, comp.Properties)
+}
+
+func TestEnrichSBOM_NoSelectedFields(t *testing.T) {
+	teardown := setupEcosystemsAPIMock(t)
+	defer teardown()
+
+	bom := &cdx.BOM{Components: &[]cdx.Component{{PackageURL: "pkg:type/example"}}}
+
+	cfg := DefaultConfig()
+	require.NoError(t, cfg.SelectFields(nil, []string{"scorecard", "scorecard_results"}))
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	assert.Nil(t, (*bom.Components)[0].ExternalReferences)
+	assert.Zero(t, httpmock.GetTotalCallCount())
+}
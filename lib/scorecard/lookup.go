@@ -0,0 +1,83 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package scorecard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/package-url/packageurl-go"
+
+	"github.com/snyk/parlay/internal/repourl"
+)
+
+// Scorecard is the scorecard of a repository, along with the JSON response of
+// the Scorecard API it was read from.
+type Scorecard struct {
+	// URL is the URL of the scorecard on the Scorecard API.
+	URL string
+
+	// Body is the JSON document returned by the Scorecard API, including
+	// any fields Result doesn't capture.
+	Body []byte
+
+	Result *Result
+}
+
+// GetRepoScorecard fetches the scorecard of a source repository, given its
+// URL in any of the forms used by package managers and SBOMs.
+func GetRepoScorecard(ctx context.Context, cfg *Config, repoURL string) (*Scorecard, error) {
+	repo, err := parseRepository(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	url := cfg.projectURL(repo)
+	sc, resp, err := fetchScorecard(ctx, cfg, url)
+	if err != nil {
+		return nil, err
+	}
+	if sc == nil {
+		return nil, fmt.Errorf("no scorecard for %s: %s", repo.URL(), resp.Status)
+	}
+	return sc, nil
+}
+
+// GetPackageScorecard fetches the scorecard of the source repository of a
+// package, which is looked up on ecosyste.ms.
+func GetPackageScorecard(ctx context.Context, cfg *Config, purl packageurl.PackageURL) (*Scorecard, error) {
+	resp, err := fetchPackageData(ctx, cfg, purl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package data: %w", err)
+	}
+	if resp.JSON200 == nil || resp.JSON200.RepositoryUrl == nil || *resp.JSON200.RepositoryUrl == "" {
+		return nil, errors.New("no repository URL on ecosyste.ms")
+	}
+	return GetRepoScorecard(ctx, cfg, *resp.JSON200.RepositoryUrl)
+}
+
+// parseRepository parses a repository URL, returning an error if OpenSSF
+// Scorecard doesn't score the repository.
+func parseRepository(repoURL string) (*repourl.Repository, error) {
+	repo, ok := repourl.Parse(repoURL)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized repository URL %q", repoURL)
+	}
+	if repo.Host != repourl.GitHub && repo.Host != repourl.GitLab {
+		return nil, errors.New("no scorecards for repositories on " + repo.Host)
+	}
+	return repo, nil
+}
@@ -0,0 +1,91 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package scorecard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/package-url/packageurl-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRepoScorecard(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", scorecardURL, httpmock.NewStringResponder(http.StatusOK, scorecardResult))
+
+	sc, err := GetRepoScorecard(context.Background(), DefaultConfig(), "git@github.com:example/repository.git")
+	require.NoError(t, err)
+
+	assert.Equal(t, scorecardURL, sc.URL)
+	assert.JSONEq(t, scorecardResult, string(sc.Body))
+	require.NotNil(t, sc.Result.Score)
+	assert.Equal(t, 6.4, *sc.Result.Score)
+}
+
+func TestGetRepoScorecard_Errors(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", scorecardURL, httpmock.NewStringResponder(http.StatusNotFound, ""))
+
+	_, err := GetRepoScorecard(context.Background(), DefaultConfig(), "https://github.com/example/repository")
+	assert.EqualError(t, err, "no scorecard for https://github.com/example/repository: 404")
+
+	_, err = GetRepoScorecard(context.Background(), DefaultConfig(), "https://bitbucket.org/example/repository")
+	assert.EqualError(t, err, "no scorecards for repositories on bitbucket.org")
+
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func TestGetPackageScorecard(t *testing.T) {
+	teardown := setupEcosystemsAPIMock(t)
+	defer teardown()
+
+	purl, err := packageurl.FromString("pkg:npm/example@1.0.0")
+	require.NoError(t, err)
+
+	sc, err := GetPackageScorecard(context.Background(), DefaultConfig(), purl)
+	require.NoError(t, err)
+
+	assert.Equal(t, scorecardURL, sc.URL)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestResult_WriteTable(t *testing.T) {
+	var result Result
+	require.NoError(t, json.Unmarshal([]byte(scorecardResult), &result))
+
+	var buf bytes.Buffer
+	require.NoError(t, result.WriteTable(&buf))
+
+	expected := `Repository: github.com/example/repository
+Commit:     4b2c1a
+Date:       2024-05-20T00:00:00Z
+Score:      6.4
+
+CHECK              SCORE  REASON
+Maintained         0      0 commit(s) out of 30 and 0 issue activity out of 30 found in the last 90 days
+Branch-Protection  3      branch protection is not maximal on development and all release branches
+Fuzzing            ?      internal error
+`
+	assert.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,258 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package scorecard
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+	"github.com/spdx/tools-golang/spdx"
+	"gopkg.in/yaml.v3"
+
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+// Policy sets the scorecard results the packages of an SBOM must meet.
+type Policy struct {
+	// MinScore is the lowest aggregate score allowed. Nil allows any score.
+	MinScore *float64 `yaml:"min-score" json:"min_score,omitempty"`
+
+	// Checks maps check names, e.g. "Maintained", to the lowest score
+	// allowed for that check. Checks which couldn't be scored don't fail the
+	// policy.
+	Checks map[string]int `yaml:"checks" json:"checks,omitempty"`
+
+	// Allow lists patterns of purls which pass regardless of their scores,
+	// e.g. "pkg:npm/@example/*". Patterns are matched against the purl both
+	// with and without its version, and unescaped, as with path.Match.
+	Allow []string `yaml:"allow" json:"allow,omitempty"`
+
+	// RequireScorecard fails packages which have no scorecard results.
+	RequireScorecard bool `yaml:"require-scorecard" json:"require_scorecard,omitempty"`
+}
+
+// LoadPolicy reads a policy from a YAML file.
+func LoadPolicy(name string) (*Policy, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	var p Policy
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", name, err)
+	}
+	for _, pattern := range p.Allow {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid allow pattern %q: %w", pattern, err)
+		}
+	}
+	return &p, nil
+}
+
+// CheckStatus is the outcome of checking a package against a policy.
+type CheckStatus string
+
+const (
+	CheckPassed   CheckStatus = "passed"
+	CheckFailed   CheckStatus = "failed"
+	CheckAllowed  CheckStatus = "allowed"
+	CheckUnscored CheckStatus = "unscored"
+)
+
+// Violation is a score below the minimum a policy allows.
+type Violation struct {
+	// Check is the name of the failed check, or empty for the aggregate
+	// score.
+	Check   string  `json:"check,omitempty"`
+	Score   float64 `json:"score"`
+	Minimum float64 `json:"minimum"`
+}
+
+func (v Violation) String() string {
+	name := v.Check
+	if name == "" {
+		name = "score"
+	}
+	return fmt.Sprintf("%s %s < %s", name, formatScore(v.Score), formatScore(v.Minimum))
+}
+
+// PackageResult is the outcome of checking a single package.
+type PackageResult struct {
+	ID         string      `json:"id"`
+	Purl       string      `json:"purl,omitempty"`
+	Status     CheckStatus `json:"status"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// PolicyReport is the outcome of checking an SBOM against a policy.
+type PolicyReport struct {
+	Passed   bool            `json:"passed"`
+	Packages []PackageResult `json:"packages"`
+}
+
+// Count returns the number of packages with the given status.
+func (r *PolicyReport) Count(status CheckStatus) int {
+	n := 0
+	for _, p := range r.Packages {
+		if p.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Check checks the scorecard results recorded in an SBOM, as added by
+// EnrichSBOM, against the policy.
+func (p *Policy) Check(doc *sbom.SBOMDocument) *PolicyReport {
+	var results []PackageResult
+	switch bom := doc.BOM.(type) {
+	case *cdx.BOM:
+		for _, comp := range utils.DiscoverCDXComponents(bom) {
+			var props []property
+			if comp.Properties != nil {
+				for _, prop := range *comp.Properties {
+					props = append(props, property{prop.Name, prop.Value})
+				}
+			}
+			results = append(results, p.checkPackage(utils.CDXComponentID(comp), comp.PackageURL, props))
+		}
+	case *spdx.Document:
+		for _, pkg := range bom.Packages {
+			var purl string
+			if u, err := utils.GetPurlFromSPDXPackage(pkg); err == nil && u != nil {
+				purl = u.String()
+			}
+			var props []property
+			for _, a := range pkg.Annotations {
+				if name, value, ok := strings.Cut(a.AnnotationComment, "="); ok {
+					props = append(props, property{name, value})
+				}
+			}
+			results = append(results, p.checkPackage(string(pkg.PackageSPDXIdentifier), purl, props))
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+	report := &PolicyReport{Passed: true, Packages: results}
+	for _, r := range results {
+		if r.Status == CheckFailed {
+			report.Passed = false
+		}
+	}
+	return report
+}
+
+func (p *Policy) checkPackage(id, purl string, props []property) PackageResult {
+	result := PackageResult{ID: id, Purl: purl}
+	if p.allowed(purl) {
+		result.Status = CheckAllowed
+		return result
+	}
+
+	var score *float64
+	checks := make(map[string]float64)
+	for _, prop := range props {
+		if !isScorecardProperty(prop.name) {
+			continue
+		}
+		value, err := strconv.ParseFloat(prop.value, 64)
+		if err != nil {
+			continue
+		}
+		if prop.name == PropertyScore {
+			score = &value
+		} else if name, ok := strings.CutPrefix(prop.name, PropertyCheckPrefix); ok {
+			checks[name] = value
+		}
+	}
+
+	if score == nil && len(checks) == 0 {
+		result.Status = CheckUnscored
+		if p.RequireScorecard {
+			result.Status = CheckFailed
+		}
+		return result
+	}
+
+	if p.MinScore != nil && score != nil && *score < *p.MinScore {
+		result.Violations = append(result.Violations, Violation{Score: *score, Minimum: *p.MinScore})
+	}
+	names := make([]string, 0, len(p.Checks))
+	for name := range p.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		got, ok := checks[name]
+		if !ok || got < 0 {
+			continue
+		}
+		if minimum := float64(p.Checks[name]); got < minimum {
+			result.Violations = append(result.Violations, Violation{Check: name, Score: got, Minimum: minimum})
+		}
+	}
+
+	result.Status = CheckPassed
+	if len(result.Violations) > 0 {
+		result.Status = CheckFailed
+	}
+	return result
+}
+
+// allowed reports whether a purl matches one of the allowed patterns.
+func (p *Policy) allowed(purl string) bool {
+	if purl == "" || len(p.Allow) == 0 {
+		return false
+	}
+	candidates := []string{purl}
+	if u, err := packageurl.FromString(purl); err == nil && u.Version != "" {
+		u.Version = ""
+		u.Qualifiers = nil
+		u.Subpath = ""
+		candidates = append(candidates, u.ToString())
+	}
+	// Purls escape characters such as the @ of npm scopes, which patterns
+	// are more naturally written without.
+	for _, c := range candidates {
+		if unescaped, err := url.PathUnescape(c); err == nil && unescaped != c {
+			candidates = append(candidates, unescaped)
+		}
+	}
+	for _, pattern := range p.Allow {
+		for _, c := range candidates {
+			if ok, _ := path.Match(pattern, c); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func formatScore(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,148 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package scorecard
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/spdx"
+	spdx_2_3 "github.com/spdx/tools-golang/spdx/v2/v2_3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+	return name
+}
+
+func TestLoadPolicy(t *testing.T) {
+	p, err := LoadPolicy(writePolicy(t, `
+min-score: 5.5
+checks:
+  Maintained: 5
+  Dangerous-Workflow: 10
+allow:
+  - pkg:npm/@example/*
+require-scorecard: true
+`))
+	require.NoError(t, err)
+
+	require.NotNil(t, p.MinScore)
+	assert.Equal(t, 5.5, *p.MinScore)
+	assert.Equal(t, map[string]int{"Maintained": 5, "Dangerous-Workflow": 10}, p.Checks)
+	assert.Equal(t, []string{"pkg:npm/@example/*"}, p.Allow)
+	assert.True(t, p.RequireScorecard)
+}
+
+func TestLoadPolicy_Invalid(t *testing.T) {
+	_, err := LoadPolicy(writePolicy(t, "min-scor: 5"))
+	assert.ErrorContains(t, err, "failed to parse policy")
+
+	_, err = LoadPolicy(writePolicy(t, "allow: ['pkg:npm/[']"))
+	assert.ErrorContains(t, err, "invalid allow pattern")
+
+	_, err = LoadPolicy(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read policy")
+}
+
+func scoredComponent(purl string, props ...cdx.Property) cdx.Component {
+	return cdx.Component{BOMRef: purl, PackageURL: purl, Properties: &props}
+}
+
+func TestPolicy_Check_CycloneDX(t *testing.T) {
+	minScore := 5.0
+	policy := &Policy{
+		MinScore: &minScore,
+		Checks:   map[string]int{"Maintained": 5, "Dangerous-Workflow": 10},
+		Allow:    []string{"pkg:npm/@example/*"},
+	}
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			scoredComponent("pkg:npm/passes@1.0.0",
+				cdx.Property{Name: "scorecard:score", Value: "7.5"},
+				cdx.Property{Name: "scorecard:check:Maintained", Value: "10"},
+				cdx.Property{Name: "scorecard:check:Dangerous-Workflow", Value: "-1"},
+			),
+			scoredComponent("pkg:npm/fails@1.0.0",
+				cdx.Property{Name: "scorecard:score", Value: "4.2"},
+				cdx.Property{Name: "scorecard:check:Maintained", Value: "0"},
+				cdx.Property{Name: "scorecard:check:Dangerous-Workflow", Value: "10"},
+			),
+			scoredComponent("pkg:npm/%40example/allowed@2.0.0",
+				cdx.Property{Name: "scorecard:score", Value: "1"},
+			),
+			{BOMRef: "unscored", PackageURL: "pkg:npm/unscored@1.0.0"},
+		},
+	}
+
+	report := policy.Check(&sbom.SBOMDocument{BOM: bom})
+
+	assert.False(t, report.Passed)
+	assert.Equal(t, []PackageResult{
+		{ID: "pkg:npm/%40example/allowed@2.0.0", Purl: "pkg:npm/%40example/allowed@2.0.0", Status: CheckAllowed},
+		{ID: "pkg:npm/fails@1.0.0", Purl: "pkg:npm/fails@1.0.0", Status: CheckFailed, Violations: []Violation{
+			{Score: 4.2, Minimum: 5},
+			{Check: "Maintained", Score: 0, Minimum: 5},
+		}},
+		{ID: "pkg:npm/passes@1.0.0", Purl: "pkg:npm/passes@1.0.0", Status: CheckPassed},
+		{ID: "unscored", Purl: "pkg:npm/unscored@1.0.0", Status: CheckUnscored},
+	}, report.Packages)
+	assert.Equal(t, 1, report.Count(CheckFailed))
+	assert.Equal(t, "Maintained 0 < 5", report.Packages[1].Violations[1].String())
+}
+
+func TestPolicy_Check_RequireScorecard(t *testing.T) {
+	policy := &Policy{RequireScorecard: true}
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "unscored", PackageURL: "pkg:npm/unscored@1.0.0"},
+		},
+	}
+
+	report := policy.Check(&sbom.SBOMDocument{BOM: bom})
+
+	assert.False(t, report.Passed)
+	assert.Equal(t, CheckFailed, report.Packages[0].Status)
+}
+
+func TestPolicy_Check_SPDX(t *testing.T) {
+	policy := &Policy{Checks: map[string]int{"Branch-Protection": 3}}
+
+	pkg := &spdx_2_3.Package{
+		PackageSPDXIdentifier: "pkg-1",
+		Annotations: []spdx_2_3.Annotation{
+			{AnnotationComment: "scorecard:score=8"},
+			{AnnotationComment: "scorecard:check:Branch-Protection=2"},
+			{AnnotationComment: "ecosystems:topic=http"},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: &spdx.Document{Packages: []*spdx_2_3.Package{pkg}}}
+
+	report := policy.Check(doc)
+
+	assert.False(t, report.Passed)
+	assert.Equal(t, []Violation{{Check: "Branch-Protection", Score: 2, Minimum: 3}}, report.Packages[0].Violations)
+}
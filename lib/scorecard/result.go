@@ -0,0 +1,118 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package scorecard
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// PropertyPrefix starts the names of the properties, or SPDX annotations,
+// which scorecard results are recorded as.
+const PropertyPrefix = "scorecard:"
+
+const (
+	// PropertyScore is the aggregate score of the repository, from 0 to 10.
+	PropertyScore = PropertyPrefix + "score"
+
+	// PropertyDate is the date the scorecard was computed on.
+	PropertyDate = PropertyPrefix + "date"
+
+	// PropertyCommit is the commit of the repository which was scored.
+	PropertyCommit = PropertyPrefix + "commit"
+
+	// PropertyCheckPrefix is followed by the name of a check, e.g.
+	// "scorecard:check:Maintained", for the score of that check. Checks
+	// which couldn't be scored have a score of -1.
+	PropertyCheckPrefix = PropertyPrefix + "check:"
+)
+
+// Result is the scorecard of a repository, as returned by the Scorecard API.
+type Result struct {
+	Date string `json:"date"`
+	Repo struct {
+		Name   string `json:"name"`
+		Commit string `json:"commit"`
+	} `json:"repo"`
+	Score  *float64 `json:"score"`
+	Checks []Check  `json:"checks"`
+}
+
+// Check is the outcome of a single scorecard check.
+type Check struct {
+	Name   string `json:"name"`
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+type property struct {
+	name  string
+	value string
+}
+
+// properties returns the scores of a scorecard as namespaced name/value
+// pairs.
+func (r *Result) properties() []property {
+	var props []property
+	if r.Score != nil {
+		props = append(props, property{PropertyScore, strconv.FormatFloat(*r.Score, 'f', -1, 64)})
+	}
+	if r.Date != "" {
+		props = append(props, property{PropertyDate, r.Date})
+	}
+	if r.Repo.Commit != "" {
+		props = append(props, property{PropertyCommit, r.Repo.Commit})
+	}
+	for _, c := range r.Checks {
+		if c.Name != "" {
+			props = append(props, property{PropertyCheckPrefix + c.Name, strconv.Itoa(c.Score)})
+		}
+	}
+	return props
+}
+
+// WriteTable writes the scorecard as a table of its checks, for reading in a
+// terminal. Scores which couldn't be computed are shown as "?".
+func (r *Result) WriteTable(w io.Writer) error {
+	score := "?"
+	if r.Score != nil {
+		score = strconv.FormatFloat(*r.Score, 'f', 1, 64)
+	}
+	fmt.Fprintf(w, "Repository: %s\n", r.Repo.Name)
+	fmt.Fprintf(w, "Commit:     %s\n", r.Repo.Commit)
+	fmt.Fprintf(w, "Date:       %s\n", r.Date)
+	fmt.Fprintf(w, "Score:      %s\n\n", score)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSCORE\tREASON")
+	for _, c := range r.Checks {
+		score := "?"
+		if c.Score >= 0 {
+			score = strconv.Itoa(c.Score)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Name, score, c.Reason)
+	}
+	return tw.Flush()
+}
+
+// isScorecardProperty reports whether a property, or SPDX annotation,
+// records a scorecard result.
+func isScorecardProperty(name string) bool {
+	return strings.HasPrefix(name, PropertyPrefix)
+}
@@ -0,0 +1,66 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package slsa
+
+import (
+	"strings"
+	"time"
+
+	"github.com/snyk/parlay/internal/pool"
+	"github.com/snyk/parlay/lib/deps"
+	"github.com/snyk/parlay/lib/report"
+)
+
+// reportProvider names provenance discovery in enrichment reports.
+const reportProvider = "slsa"
+
+// Config controls how SBOMs are enriched with build provenance.
+type Config struct {
+	// Deps configures access to deps.dev, which reports the provenance and
+	// attestations it verified for package versions of any ecosystem.
+	Deps *deps.Config
+
+	// NPMRegistryURL is the base URL of the npm registry, which serves the
+	// provenance attestations of npm packages.
+	NPMRegistryURL string
+
+	// PyPIURL is the base URL of PyPI, whose integrity API serves the
+	// attestations of packages uploaded by trusted publishers.
+	PyPIURL string
+
+	// Concurrency is the number of packages enriched in parallel.
+	Concurrency int
+
+	// RequestTimeout bounds each request. A zero value leaves requests bound
+	// only by the context enrichment runs under.
+	RequestTimeout time.Duration
+
+	// Report, if set, collects the outcome of enriching each package.
+	Report *report.Report
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Deps:           deps.DefaultConfig(),
+		NPMRegistryURL: "https://registry.npmjs.org",
+		PyPIURL:        "https://pypi.org",
+		Concurrency:    pool.DefaultConcurrency,
+	}
+}
+
+func joinURL(base, path string) string {
+	return strings.TrimSuffix(base, "/") + path
+}
@@ -0,0 +1,86 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package slsa
+
+import (
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/spdx"
+
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+// Coverage counts the packages of an SBOM by the provenance recorded for
+// them. Packages which weren't enriched count only towards Components.
+type Coverage struct {
+	Components int
+	Verified   int
+	Unverified int
+	None       int
+
+	// Mismatched counts the packages whose provenance names a different
+	// source repository than their VCS references.
+	Mismatched int
+}
+
+// MeasureCoverage reads the provenance recorded in an SBOM.
+func MeasureCoverage(doc *sbom.SBOMDocument) Coverage {
+	var c Coverage
+	switch bom := doc.BOM.(type) {
+	case *cdx.BOM:
+		for _, comp := range utils.DiscoverCDXComponents(bom) {
+			var props []string
+			if comp.Properties != nil {
+				for _, p := range *comp.Properties {
+					props = append(props, p.Name+"="+p.Value)
+				}
+			}
+			c.count(props)
+		}
+	case *spdx.Document:
+		for _, pkg := range bom.Packages {
+			var props []string
+			for _, a := range pkg.Annotations {
+				props = append(props, a.AnnotationComment)
+			}
+			c.count(props)
+		}
+	}
+	return c
+}
+
+// count adds a package, given its properties as name=value pairs.
+func (c *Coverage) count(props []string) {
+	c.Components++
+	for _, p := range props {
+		name, value, _ := strings.Cut(p, "=")
+		switch name {
+		case PropertyProvenance:
+			switch Status(value) {
+			case StatusVerified:
+				c.Verified++
+			case StatusUnverified:
+				c.Unverified++
+			case StatusNone:
+				c.None++
+			}
+		case PropertySourceMismatch:
+			c.Mismatched++
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package slsa
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+	"github.com/spdx/tools-golang/spdx"
+
+	"github.com/snyk/parlay/lib/deps"
+	"github.com/snyk/parlay/lib/report"
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+// EnrichSBOM records whether each package version has build provenance,
+// which builder produced it and which repository it was built from. When
+// ctx is done, packages which weren't enriched yet are left as they are.
+func EnrichSBOM(ctx context.Context, cfg *Config, doc *sbom.SBOMDocument) *sbom.SBOMDocument {
+	switch bom := doc.BOM.(type) {
+	case *cdx.BOM:
+		enrichCDX(ctx, cfg, bom)
+	case *spdx.Document:
+		enrichSPDX(ctx, cfg, bom)
+	}
+
+	return doc
+}
+
+// source looks up the provenance of a package version in one place.
+type source struct {
+	name   string
+	lookup func(context.Context, *Config, packageurl.PackageURL, *report.Outcome) ([]attestation, error)
+}
+
+// sources returns the places which may have provenance for a package.
+func sources(purl packageurl.PackageURL) []source {
+	var s []source
+	if deps.SupportsPurl(purl) {
+		s = append(s, source{"deps.dev", depsAttestations})
+	}
+	switch purl.Type {
+	case packageurl.TypeNPM:
+		s = append(s, source{"npm", npmAttestations})
+	case packageurl.TypePyPi:
+		s = append(s, source{"PyPI", pypiAttestations})
+	}
+	return s
+}
+
+// lookupProvenance asks every source which may have provenance for a
+// package version, and combines what they have. If the provenance can't be
+// determined, it records why on out and returns false.
+func lookupProvenance(ctx context.Context, cfg *Config, purl packageurl.PackageURL, out *report.Outcome) (*Provenance, bool) {
+	if purl.Version == "" {
+		out.Skip("no version on PackageURL")
+		return nil, false
+	}
+	srcs := sources(purl)
+	if len(srcs) == 0 {
+		out.Skip(fmt.Sprintf("unsupported purl type %q", purl.Type))
+		return nil, false
+	}
+
+	var attestations []attestation
+	var failed []string
+	for _, src := range srcs {
+		found, err := src.lookup(ctx, cfg, purl, out)
+		if err != nil {
+			failed = append(failed, src.name+": "+err.Error())
+			continue
+		}
+		attestations = append(attestations, found...)
+	}
+
+	// A failed source may have had provenance, so a package is only
+	// recorded as having none if every source answered.
+	provenance := combine(attestations)
+	if provenance.Status == StatusNone && len(failed) > 0 {
+		out.Fail("failed to get provenance: " + strings.Join(failed, "; "))
+		return nil, false
+	}
+	return provenance, true
+}
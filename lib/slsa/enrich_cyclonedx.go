@@ -0,0 +1,101 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package slsa
+
+import (
+	"context"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+
+	"github.com/snyk/parlay/internal/pool"
+	"github.com/snyk/parlay/internal/utils"
+)
+
+func enrichCDX(ctx context.Context, cfg *Config, bom *cdx.BOM) {
+	comps := utils.DiscoverCDXComponents(bom)
+
+	pool.Run(cfg.Concurrency, comps, func(component *cdx.Component) {
+		out := cfg.Report.Outcome(reportProvider, utils.CDXComponentID(component), component.PackageURL)
+		if err := ctx.Err(); err != nil {
+			out.Skip("enrichment interrupted: " + err.Error())
+			return
+		}
+
+		purl, err := packageurl.FromString(component.PackageURL)
+		if err != nil {
+			out.Skip("no usable PackageURL")
+			return
+		}
+		provenance, ok := lookupProvenance(ctx, cfg, purl, out)
+		if !ok {
+			return
+		}
+
+		refs := 0
+		if component.ExternalReferences != nil {
+			refs = len(*component.ExternalReferences)
+		}
+		for _, u := range provenance.URLs {
+			utils.AddCDXExternalReference(component, cdx.ExternalReference{
+				URL:  u,
+				Type: cdx.ERTypeAttestation,
+			})
+		}
+		if component.ExternalReferences != nil && len(*component.ExternalReferences) > refs {
+			out.Added("attestation")
+		}
+		before, after := cdxEnrichProvenance(component, provenance)
+		out.RecordChange("provenance", before, after)
+	})
+}
+
+func cdxVCSURLs(comp *cdx.Component) []string {
+	if comp.ExternalReferences == nil {
+		return nil
+	}
+	var urls []string
+	for _, ref := range *comp.ExternalReferences {
+		if ref.Type == cdx.ERTypeVCS {
+			urls = append(urls, ref.URL)
+		}
+	}
+	return urls
+}
+
+// cdxEnrichProvenance replaces the provenance properties of a component,
+// returning those it had before and has after.
+func cdxEnrichProvenance(comp *cdx.Component, provenance *Provenance) (before, after []string) {
+	props := provenance.properties(cdxVCSURLs(comp))
+
+	var kept []cdx.Property
+	if comp.Properties != nil {
+		for _, p := range *comp.Properties {
+			if isProvenanceProperty(p.Name) {
+				before = append(before, p.Name+"="+p.Value)
+				continue
+			}
+			kept = append(kept, p)
+		}
+	}
+
+	for _, p := range props {
+		kept = append(kept, cdx.Property{Name: p.name, Value: p.value})
+		after = append(after, p.name+"="+p.value)
+	}
+	comp.Properties = &kept
+	return before, after
+}
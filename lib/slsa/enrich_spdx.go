@@ -0,0 +1,106 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package slsa
+
+import (
+	"context"
+	"time"
+
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/spdx/v2/common"
+	spdx_2_3 "github.com/spdx/tools-golang/spdx/v2/v2_3"
+
+	"github.com/snyk/parlay/internal/pool"
+	"github.com/snyk/parlay/internal/utils"
+)
+
+func enrichSPDX(ctx context.Context, cfg *Config, bom *spdx.Document) {
+	pool.Run(cfg.Concurrency, bom.Packages, func(pkg *spdx_2_3.Package) {
+		out := cfg.Report.Outcome(reportProvider, string(pkg.PackageSPDXIdentifier), "")
+		if err := ctx.Err(); err != nil {
+			out.Skip("enrichment interrupted: " + err.Error())
+			return
+		}
+
+		purl, err := utils.GetPurlFromSPDXPackage(pkg)
+		if err != nil {
+			out.Skip("no usable PackageURL")
+			return
+		}
+		out = cfg.Report.Outcome(reportProvider, string(pkg.PackageSPDXIdentifier), purl.String())
+		provenance, ok := lookupProvenance(ctx, cfg, *purl, out)
+		if !ok {
+			return
+		}
+
+		refs := len(pkg.PackageExternalReferences)
+		for _, u := range provenance.URLs {
+			utils.AddSPDXExternalReference(pkg, &spdx_2_3.PackageExternalReference{
+				Category: spdx.CategoryOther,
+				RefType:  "attestation",
+				Locator:  u,
+			})
+		}
+		if len(pkg.PackageExternalReferences) > refs {
+			out.Added("attestation")
+		}
+		before, after := spdxEnrichProvenance(pkg, provenance)
+		out.RecordChange("provenance", before, after)
+	})
+}
+
+func spdxVCSURLs(pkg *spdx_2_3.Package) []string {
+	var urls []string
+	for _, ref := range pkg.PackageExternalReferences {
+		if ref.RefType == "vcs" {
+			urls = append(urls, ref.Locator)
+		}
+	}
+	return urls
+}
+
+// spdxEnrichProvenance replaces the provenance annotations of a package,
+// returning those it had before and has after.
+func spdxEnrichProvenance(pkg *spdx_2_3.Package, provenance *Provenance) (before, after []string) {
+	props := provenance.properties(spdxVCSURLs(pkg))
+
+	var kept []spdx_2_3.Annotation
+	for _, a := range pkg.Annotations {
+		if isProvenanceProperty(a.AnnotationComment) {
+			before = append(before, a.AnnotationComment)
+			continue
+		}
+		kept = append(kept, a)
+	}
+
+	date := time.Now().UTC().Format(time.RFC3339)
+	for _, p := range props {
+		comment := p.name + "=" + p.value
+		kept = append(kept, spdx_2_3.Annotation{
+			Annotator: common.Annotator{
+				Annotator:     "parlay",
+				AnnotatorType: "Tool",
+			},
+			AnnotationDate:           date,
+			AnnotationType:           "OTHER",
+			AnnotationSPDXIdentifier: common.MakeDocElementID("", string(pkg.PackageSPDXIdentifier)),
+			AnnotationComment:        comment,
+		})
+		after = append(after, comment)
+	}
+	pkg.Annotations = kept
+	return before, after
+}
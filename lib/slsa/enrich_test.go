@@ -0,0 +1,311 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package slsa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/jarcoal/httpmock"
+	"github.com/spdx/tools-golang/spdx"
+	spdx_2_3 "github.com/spdx/tools-golang/spdx/v2/v2_3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/snyk/parlay/lib/report"
+	"github.com/snyk/parlay/lib/sbom"
+)
+
+const (
+	npmVersionURL      = "https://api.deps.dev/v3/systems/NPM/packages/example/versions/1.0.0"
+	npmAttestationsURL = "https://registry.npmjs.org/-/npm/v1/attestations/example@1.0.0"
+	pypiVersionURL     = "https://api.deps.dev/v3/systems/PYPI/packages/example/versions/1.0.0"
+	pypiReleaseURL     = "https://pypi.org/pypi/example/1.0.0/json"
+	pypiProvenanceURL  = "https://pypi.org/integrity/example/1.0.0/example-1.0.0.tar.gz/provenance"
+)
+
+const npmVersionResponse = `{
+  "versionKey": {"system": "NPM", "name": "example", "version": "1.0.0"},
+  "slsaProvenances": [
+    {
+      "sourceRepository": "https://github.com/example/repository",
+      "commit": "4b2c1a",
+      "url": "https://registry.npmjs.org/-/npm/v1/attestations/example@1.0.0",
+      "verified": true
+    }
+  ]
+}`
+
+// npmAttestationsResponse holds a SLSA v1 provenance statement for a build
+// of example on GitHub Actions.
+const npmAttestationsResponse = `{
+  "attestations": [
+    {
+      "predicateType": "https://slsa.dev/provenance/v1",
+      "bundle": {
+        "dsseEnvelope": {
+          "payloadType": "application/vnd.in-toto+json",
+          "payload": "eyJfdHlwZSI6Imh0dHBzOi8vaW4tdG90by5pby9TdGF0ZW1lbnQvdjEiLCJwcmVkaWNhdGVUeXBlIjoiaHR0cHM6Ly9zbHNhLmRldi9wcm92ZW5hbmNlL3YxIiwicHJlZGljYXRlIjp7ImJ1aWxkRGVmaW5pdGlvbiI6eyJleHRlcm5hbFBhcmFtZXRlcnMiOnsid29ya2Zsb3ciOnsicmVwb3NpdG9yeSI6Imh0dHBzOi8vZ2l0aHViLmNvbS9leGFtcGxlL3JlcG9zaXRvcnkiLCJwYXRoIjoiLmdpdGh1Yi93b3JrZmxvd3MvcmVsZWFzZS55bWwiLCJyZWYiOiJyZWZzL3RhZ3MvdjEuMC4wIn19LCJyZXNvbHZlZERlcGVuZGVuY2llcyI6W3sidXJpIjoiZ2l0K2h0dHBzOi8vZ2l0aHViLmNvbS9leGFtcGxlL3JlcG9zaXRvcnlAcmVmcy90YWdzL3YxLjAuMCIsImRpZ2VzdCI6eyJnaXRDb21taXQiOiI0YjJjMWEifX1dfSwicnVuRGV0YWlscyI6eyJidWlsZGVyIjp7ImlkIjoiaHR0cHM6Ly9naXRodWIuY29tL2FjdGlvbnMvcnVubmVyL2dpdGh1Yi1ob3N0ZWQifX19fQ=="
+        }
+      }
+    }
+  ]
+}`
+
+const pypiReleaseResponse = `{
+  "info": {"name": "example", "version": "1.0.0"},
+  "urls": [{"filename": "example-1.0.0.tar.gz"}]
+}`
+
+const pypiProvenanceResponse = `{
+  "version": 1,
+  "attestation_bundles": [
+    {
+      "publisher": {
+        "kind": "GitHub",
+        "repository": "example/repository",
+        "workflow": "release.yml",
+        "environment": null
+      },
+      "attestations": []
+    }
+  ]
+}`
+
+func setupProvenanceMock(t *testing.T) func() {
+	t.Helper()
+
+	httpmock.Activate()
+	httpmock.RegisterResponder("GET", npmVersionURL, httpmock.NewStringResponder(http.StatusOK, npmVersionResponse))
+	httpmock.RegisterResponder("GET", npmAttestationsURL, httpmock.NewStringResponder(http.StatusOK, npmAttestationsResponse))
+	httpmock.RegisterResponder("GET", pypiVersionURL, httpmock.NewStringResponder(http.StatusNotFound, `{"error": "not found"}`))
+	httpmock.RegisterResponder("GET", pypiReleaseURL, httpmock.NewStringResponder(http.StatusOK, pypiReleaseResponse))
+	httpmock.RegisterResponder("GET", pypiProvenanceURL, httpmock.NewStringResponder(http.StatusOK, pypiProvenanceResponse))
+	httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("unexpected HTTP request: " + req.URL.String())
+	})
+
+	return httpmock.DeactivateAndReset
+}
+
+func TestEnrichSBOM_CycloneDX(t *testing.T) {
+	teardown := setupProvenanceMock(t)
+	defer teardown()
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{
+				BOMRef:     "example",
+				PackageURL: "pkg:npm/example@1.0.0",
+				ExternalReferences: &[]cdx.ExternalReference{
+					{URL: "https://github.com/example/repository", Type: cdx.ERTypeVCS},
+				},
+				Properties: &[]cdx.Property{
+					{Name: PropertyProvenance, Value: "none"},
+				},
+			},
+		},
+	}
+
+	cfg := DefaultConfig()
+	cfg.Report = report.New()
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	comp := (*bom.Components)[0]
+	assert.Equal(t, []cdx.Property{
+		{Name: PropertyProvenance, Value: "verified"},
+		{Name: PropertyBuilder, Value: "https://github.com/actions/runner/github-hosted"},
+		{Name: PropertySourceRepository, Value: "https://github.com/example/repository"},
+		{Name: PropertySourceCommit, Value: "4b2c1a"},
+	}, *comp.Properties)
+	require.NotNil(t, comp.ExternalReferences)
+	assert.Equal(t, []cdx.ExternalReference{
+		{URL: "https://github.com/example/repository", Type: cdx.ERTypeVCS},
+		{URL: npmAttestationsURL, Type: cdx.ERTypeAttestation},
+	}, *comp.ExternalReferences)
+
+	outcome := cfg.Report.Outcome(reportProvider, "example", "")
+	assert.Equal(t, report.StatusEnriched, outcome.Status)
+	assert.Equal(t, []string{"attestation"}, outcome.FieldsAdded)
+	assert.Equal(t, []string{"provenance"}, outcome.FieldsChanged)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestEnrichSBOM_CycloneDX_SourceMismatch(t *testing.T) {
+	teardown := setupProvenanceMock(t)
+	defer teardown()
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{
+				PackageURL: "pkg:npm/example@1.0.0",
+				ExternalReferences: &[]cdx.ExternalReference{
+					{URL: "git+https://github.com/fork/repository.git", Type: cdx.ERTypeVCS},
+				},
+			},
+		},
+	}
+
+	EnrichSBOM(context.Background(), DefaultConfig(), &sbom.SBOMDocument{BOM: bom})
+
+	props := *(*bom.Components)[0].Properties
+	require.Len(t, props, 5)
+	assert.Equal(t, cdx.Property{
+		Name:  PropertySourceMismatch,
+		Value: "git+https://github.com/fork/repository.git",
+	}, props[4])
+}
+
+func TestEnrichSBOM_SPDX(t *testing.T) {
+	teardown := setupProvenanceMock(t)
+	defer teardown()
+
+	doc := &spdx.Document{
+		Packages: []*spdx_2_3.Package{
+			{
+				PackageSPDXIdentifier: "pkg-example",
+				PackageName:           "example",
+				PackageExternalReferences: []*spdx_2_3.PackageExternalReference{
+					{
+						Category: spdx.CategoryPackageManager,
+						RefType:  "purl",
+						Locator:  "pkg:pypi/example@1.0.0",
+					},
+				},
+			},
+		},
+	}
+
+	cfg := DefaultConfig()
+	cfg.Report = report.New()
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: doc})
+
+	pkg := doc.Packages[0]
+	var comments []string
+	for _, a := range pkg.Annotations {
+		comments = append(comments, a.AnnotationComment)
+	}
+	assert.Equal(t, []string{
+		"slsa:provenance=unverified",
+		"slsa:builder=https://github.com/example/repository/.github/workflows/release.yml",
+		"slsa:source_repository=https://github.com/example/repository",
+	}, comments)
+	require.Len(t, pkg.PackageExternalReferences, 2)
+	assert.Equal(t, "attestation", pkg.PackageExternalReferences[1].RefType)
+	assert.Equal(t, pypiProvenanceURL, pkg.PackageExternalReferences[1].Locator)
+
+	outcome := cfg.Report.Outcome(reportProvider, "pkg-example", "")
+	assert.Equal(t, report.StatusEnriched, outcome.Status)
+	assert.Equal(t, []string{"attestation", "provenance"}, outcome.FieldsAdded)
+}
+
+func TestEnrichSBOM_NoProvenance(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", npmVersionURL, httpmock.NewStringResponder(http.StatusOK, `{"versionKey": {"system": "NPM", "name": "example", "version": "1.0.0"}}`))
+	httpmock.RegisterResponder("GET", npmAttestationsURL, httpmock.NewStringResponder(http.StatusNotFound, `{"error": "Not Found"}`))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{PackageURL: "pkg:npm/example@1.0.0"},
+		},
+	}
+
+	EnrichSBOM(context.Background(), DefaultConfig(), &sbom.SBOMDocument{BOM: bom})
+
+	comp := (*bom.Components)[0]
+	assert.Equal(t, []cdx.Property{{Name: PropertyProvenance, Value: "none"}}, *comp.Properties)
+	assert.Nil(t, comp.ExternalReferences)
+}
+
+func TestEnrichSBOM_ErrorFetchingProvenance(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", npmVersionURL, httpmock.NewStringResponder(http.StatusBadRequest, `{}`))
+	httpmock.RegisterResponder("GET", npmAttestationsURL, httpmock.NewStringResponder(http.StatusNotFound, `{"error": "Not Found"}`))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "example", PackageURL: "pkg:npm/example@1.0.0"},
+		},
+	}
+
+	cfg := DefaultConfig()
+	cfg.Report = report.New()
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	assert.Nil(t, (*bom.Components)[0].Properties)
+	outcome := cfg.Report.Outcome(reportProvider, "example", "")
+	assert.Equal(t, report.StatusFailed, outcome.Status)
+	assert.Equal(t, "failed to get provenance: deps.dev: unexpected response from deps.dev: 400", outcome.Reason)
+}
+
+func TestEnrichSBOM_Skipped(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "no-purl", Name: "example"},
+			{BOMRef: "no-version", PackageURL: "pkg:npm/example"},
+			{BOMRef: "unsupported", PackageURL: "pkg:generic/example@1.0.0"},
+		},
+	}
+
+	cfg := DefaultConfig()
+	cfg.Report = report.New()
+	EnrichSBOM(context.Background(), cfg, &sbom.SBOMDocument{BOM: bom})
+
+	for id, reason := range map[string]string{
+		"no-purl":     "no usable PackageURL",
+		"no-version":  "no version on PackageURL",
+		"unsupported": `unsupported purl type "generic"`,
+	} {
+		outcome := cfg.Report.Outcome(reportProvider, id, "")
+		assert.Equal(t, report.StatusSkipped, outcome.Status, id)
+		assert.Equal(t, reason, outcome.Reason, id)
+	}
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+}
+
+func TestMeasureCoverage(t *testing.T) {
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{Properties: &[]cdx.Property{{Name: PropertyProvenance, Value: "verified"}}},
+			{
+				Properties: &[]cdx.Property{
+					{Name: PropertyProvenance, Value: "unverified"},
+					{Name: PropertySourceMismatch, Value: "https://github.com/fork/repository"},
+				},
+				Components: &[]cdx.Component{
+					{Properties: &[]cdx.Property{{Name: PropertyProvenance, Value: "none"}}},
+				},
+			},
+			{Name: "not enriched"},
+		},
+	}
+
+	assert.Equal(t, Coverage{
+		Components: 4,
+		Verified:   1,
+		Unverified: 1,
+		None:       1,
+		Mismatched: 1,
+	}, MeasureCoverage(&sbom.SBOMDocument{BOM: bom}))
+}
@@ -0,0 +1,179 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package slsa
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/snyk/parlay/internal/repourl"
+)
+
+// PropertyPrefix starts the names of the properties, or SPDX annotations,
+// which provenance is recorded as.
+const PropertyPrefix = "slsa:"
+
+const (
+	// PropertyProvenance is one of the Status values.
+	PropertyProvenance = PropertyPrefix + "provenance"
+
+	// PropertyBuilder identifies the build platform which produced the
+	// package, e.g. https://github.com/actions/runner/github-hosted.
+	PropertyBuilder = PropertyPrefix + "builder"
+
+	// PropertySourceRepository is the repository the package was built from
+	// according to its provenance.
+	PropertySourceRepository = PropertyPrefix + "source_repository"
+
+	// PropertySourceCommit is the commit the package was built from.
+	PropertySourceCommit = PropertyPrefix + "source_commit"
+
+	// PropertySourceMismatch lists the VCS references of the package which
+	// name a different repository than its provenance does.
+	PropertySourceMismatch = PropertyPrefix + "source_mismatch"
+)
+
+// Status describes the provenance found for a package version.
+type Status string
+
+const (
+	// StatusVerified means a signed provenance statement was found, and
+	// deps.dev verified its signature.
+	StatusVerified Status = "verified"
+
+	// StatusUnverified means a signed provenance statement or attestation
+	// was found, but its signature wasn't verified. parlay doesn't verify
+	// signatures itself.
+	StatusUnverified Status = "unverified"
+
+	// StatusNone means none of the sources has provenance for the version.
+	StatusNone Status = "none"
+)
+
+// attestation is what a single source knows about the provenance of a
+// package version.
+type attestation struct {
+	verified         bool
+	builder          string
+	sourceRepository string
+	commit           string
+	url              string
+}
+
+// Provenance is the provenance of a package version, combined from all the
+// sources which have any.
+type Provenance struct {
+	Status           Status
+	Builder          string
+	SourceRepository string
+	Commit           string
+
+	// URLs link to the provenance statements and attestations found.
+	URLs []string
+}
+
+// combine merges what the sources know about the provenance of a version.
+// The source repository and commit of verified statements are preferred.
+func combine(attestations []attestation) *Provenance {
+	p := &Provenance{Status: StatusNone}
+	if len(attestations) > 0 {
+		p.Status = StatusUnverified
+	}
+
+	// Verified statements come first, so that their details win.
+	ordered := make([]attestation, 0, len(attestations))
+	for _, a := range attestations {
+		if a.verified {
+			ordered = append(ordered, a)
+			p.Status = StatusVerified
+		}
+	}
+	for _, a := range attestations {
+		if !a.verified {
+			ordered = append(ordered, a)
+		}
+	}
+
+	for _, a := range ordered {
+		if p.Builder == "" {
+			p.Builder = a.builder
+		}
+		if p.SourceRepository == "" && a.sourceRepository != "" {
+			p.SourceRepository = a.sourceRepository
+			if repo, ok := repourl.Parse(a.sourceRepository); ok {
+				p.SourceRepository = repo.URL()
+			}
+		}
+		if p.Commit == "" {
+			p.Commit = a.commit
+		}
+		if a.url != "" && !slices.Contains(p.URLs, a.url) {
+			p.URLs = append(p.URLs, a.url)
+		}
+	}
+	return p
+}
+
+// mismatches returns the VCS references which name a different repository
+// than the provenance does. References which don't identify a repository
+// can't be compared, and are left out.
+func (p *Provenance) mismatches(vcsURLs []string) []string {
+	source, ok := repourl.Parse(p.SourceRepository)
+	if !ok {
+		return nil
+	}
+	var mismatched []string
+	for _, u := range vcsURLs {
+		repo, ok := repourl.Parse(u)
+		if !ok {
+			continue
+		}
+		if !strings.EqualFold(repo.String(), source.String()) {
+			mismatched = append(mismatched, u)
+		}
+	}
+	return mismatched
+}
+
+type property struct {
+	name  string
+	value string
+}
+
+// properties returns the provenance as namespaced name/value pairs, noting
+// the VCS references which don't match its source repository.
+func (p *Provenance) properties(vcsURLs []string) []property {
+	props := []property{{PropertyProvenance, string(p.Status)}}
+	if p.Builder != "" {
+		props = append(props, property{PropertyBuilder, p.Builder})
+	}
+	if p.SourceRepository != "" {
+		props = append(props, property{PropertySourceRepository, p.SourceRepository})
+	}
+	if p.Commit != "" {
+		props = append(props, property{PropertySourceCommit, p.Commit})
+	}
+	if mismatched := p.mismatches(vcsURLs); len(mismatched) > 0 {
+		props = append(props, property{PropertySourceMismatch, strings.Join(mismatched, ",")})
+	}
+	return props
+}
+
+// isProvenanceProperty reports whether a property, or SPDX annotation,
+// records provenance.
+func isProvenanceProperty(name string) bool {
+	return strings.HasPrefix(name, PropertyPrefix)
+}
@@ -0,0 +1,109 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package slsa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombine(t *testing.T) {
+	tc := []struct {
+		name         string
+		attestations []attestation
+		expected     *Provenance
+	}{
+		{
+			name:     "none",
+			expected: &Provenance{Status: StatusNone},
+		},
+		{
+			name: "verified details win",
+			attestations: []attestation{
+				{
+					builder:          "https://example.com/builder",
+					sourceRepository: "https://github.com/fork/repository",
+					url:              "https://example.com/unverified",
+				},
+				{
+					verified:         true,
+					sourceRepository: "git+https://github.com/example/repository.git",
+					commit:           "4b2c1a",
+					url:              "https://example.com/verified",
+				},
+			},
+			expected: &Provenance{
+				Status:           StatusVerified,
+				Builder:          "https://example.com/builder",
+				SourceRepository: "https://github.com/example/repository",
+				Commit:           "4b2c1a",
+				URLs:             []string{"https://example.com/verified", "https://example.com/unverified"},
+			},
+		},
+		{
+			name: "duplicate URLs",
+			attestations: []attestation{
+				{url: "https://example.com/provenance"},
+				{url: "https://example.com/provenance"},
+			},
+			expected: &Provenance{
+				Status: StatusUnverified,
+				URLs:   []string{"https://example.com/provenance"},
+			},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, combine(tt.attestations))
+		})
+	}
+}
+
+func TestProvenance_Mismatches(t *testing.T) {
+	p := &Provenance{SourceRepository: "https://github.com/example/repository"}
+
+	assert.Equal(t, []string{"https://github.com/fork/repository"}, p.mismatches([]string{
+		"git+https://github.com/Example/Repository.git",
+		"https://github.com/fork/repository",
+		"not a repository",
+	}))
+	assert.Nil(t, (&Provenance{}).mismatches([]string{"https://github.com/fork/repository"}))
+}
+
+func TestParseStatement_V02(t *testing.T) {
+	a, err := parseStatement([]byte(`{
+		"predicateType": "https://slsa.dev/provenance/v0.2",
+		"predicate": {
+			"builder": {"id": "https://github.com/actions/runner"},
+			"invocation": {
+				"configSource": {
+					"uri": "git+https://github.com/example/repository@refs/heads/main",
+					"digest": {"sha1": "4b2c1a"}
+				}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, attestation{
+		builder:          "https://github.com/actions/runner",
+		sourceRepository: "git+https://github.com/example/repository@refs/heads/main",
+		commit:           "4b2c1a",
+	}, a)
+}
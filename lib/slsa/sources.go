@@ -0,0 +1,193 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package slsa
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/package-url/packageurl-go"
+
+	"github.com/snyk/parlay/internal/httpclient"
+	"github.com/snyk/parlay/internal/utils"
+	"github.com/snyk/parlay/lib/deps"
+	"github.com/snyk/parlay/lib/report"
+)
+
+// depsAttestations returns the provenance statements and attestations
+// deps.dev has for a package version.
+func depsAttestations(ctx context.Context, cfg *Config, purl packageurl.PackageURL, out *report.Outcome) ([]attestation, error) {
+	if !deps.SupportsPurl(purl) {
+		return nil, nil
+	}
+	resp, err := deps.GetVersionData(ctx, cfg.Deps, purl)
+	if resp != nil {
+		out.Response(resp.HTTPResponse)
+		if resp.HTTPResponse.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var attestations []attestation
+	for _, p := range resp.Data.SLSAProvenances {
+		attestations = append(attestations, attestation{
+			verified:         p.Verified,
+			sourceRepository: p.SourceRepository,
+			commit:           p.Commit,
+			url:              p.URL,
+		})
+	}
+	for _, a := range resp.Data.Attestations {
+		attestations = append(attestations, attestation{
+			verified:         a.Verified,
+			sourceRepository: a.SourceRepository,
+			commit:           a.Commit,
+			url:              a.URL,
+		})
+	}
+	return attestations, nil
+}
+
+// npmAttestations returns the provenance statements the npm registry serves
+// for a package version.
+func npmAttestations(ctx context.Context, cfg *Config, purl packageurl.PackageURL, out *report.Outcome) ([]attestation, error) {
+	name := purl.Name
+	if purl.Namespace != "" {
+		name = purl.Namespace + "/" + purl.Name
+	}
+	endpoint := joinURL(cfg.NPMRegistryURL, "/-/npm/v1/attestations/"+url.PathEscape(name)+"@"+url.PathEscape(purl.Version))
+
+	var body struct {
+		Attestations []struct {
+			PredicateType string `json:"predicateType"`
+			Bundle        struct {
+				DSSEEnvelope struct {
+					Payload string `json:"payload"`
+				} `json:"dsseEnvelope"`
+			} `json:"bundle"`
+		} `json:"attestations"`
+	}
+	found, err := fetchJSON(ctx, cfg, endpoint, &body, out)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	var attestations []attestation
+	for _, a := range body.Attestations {
+		if !isSLSAProvenance(a.PredicateType) {
+			continue
+		}
+		payload, err := base64.StdEncoding.DecodeString(a.Bundle.DSSEEnvelope.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode npm attestation: %w", err)
+		}
+		parsed, err := parseStatement(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode npm attestation: %w", err)
+		}
+		parsed.url = endpoint
+		attestations = append(attestations, parsed)
+	}
+	return attestations, nil
+}
+
+// pypiAttestations returns the attestations PyPI serves for a package
+// version uploaded by a trusted publisher. All files of a release are
+// uploaded by the same publisher, so only the first file is looked at.
+func pypiAttestations(ctx context.Context, cfg *Config, purl packageurl.PackageURL, out *report.Outcome) ([]attestation, error) {
+	name, version := url.PathEscape(purl.Name), url.PathEscape(purl.Version)
+
+	var release struct {
+		URLs []struct {
+			Filename string `json:"filename"`
+		} `json:"urls"`
+	}
+	found, err := fetchJSON(ctx, cfg, joinURL(cfg.PyPIURL, "/pypi/"+name+"/"+version+"/json"), &release, out)
+	if err != nil || !found || len(release.URLs) == 0 {
+		return nil, err
+	}
+
+	endpoint := joinURL(cfg.PyPIURL, "/integrity/"+name+"/"+version+"/"+url.PathEscape(release.URLs[0].Filename)+"/provenance")
+	var provenance struct {
+		AttestationBundles []struct {
+			Publisher struct {
+				Kind       string `json:"kind"`
+				Repository string `json:"repository"`
+				Workflow   string `json:"workflow"`
+			} `json:"publisher"`
+		} `json:"attestation_bundles"`
+	}
+	found, err = fetchJSON(ctx, cfg, endpoint, &provenance, out)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	var attestations []attestation
+	for _, bundle := range provenance.AttestationBundles {
+		a := attestation{url: endpoint}
+		publisher := bundle.Publisher
+		switch publisher.Kind {
+		case "GitHub":
+			a.sourceRepository = "https://github.com/" + publisher.Repository
+			a.builder = a.sourceRepository + "/.github/workflows/" + publisher.Workflow
+		case "GitLab":
+			a.sourceRepository = "https://gitlab.com/" + publisher.Repository
+			a.builder = a.sourceRepository + "/" + publisher.Workflow
+		default:
+			a.builder = publisher.Kind
+		}
+		attestations = append(attestations, a)
+	}
+	return attestations, nil
+}
+
+// fetchJSON requests endpoint and decodes the JSON response into v. It
+// returns false if there is nothing at endpoint.
+func fetchJSON(ctx context.Context, cfg *Config, endpoint string, v any, out *report.Outcome) (bool, error) {
+	ctx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpclient.Default().Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	out.Response(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected response from %s: %s", req.URL.Host, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("failed to decode response from %s: %w", req.URL.Host, err)
+	}
+	return true, nil
+}
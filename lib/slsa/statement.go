@@ -0,0 +1,94 @@
+/*
+ * © 2024 Snyk Limited All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package slsa
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// isSLSAProvenance reports whether an in-toto predicate type is a version of
+// SLSA provenance.
+func isSLSAProvenance(predicateType string) bool {
+	return strings.HasPrefix(predicateType, "https://slsa.dev/provenance/")
+}
+
+// statement is an in-toto statement holding SLSA provenance, with the fields
+// of both v0.2 and v1 of the predicate parlay uses.
+type statement struct {
+	PredicateType string `json:"predicateType"`
+	Predicate     struct {
+		// SLSA v1
+		BuildDefinition struct {
+			ExternalParameters struct {
+				Workflow struct {
+					Repository string `json:"repository"`
+				} `json:"workflow"`
+			} `json:"externalParameters"`
+			ResolvedDependencies []struct {
+				URI    string            `json:"uri"`
+				Digest map[string]string `json:"digest"`
+			} `json:"resolvedDependencies"`
+		} `json:"buildDefinition"`
+		RunDetails struct {
+			Builder struct {
+				ID string `json:"id"`
+			} `json:"builder"`
+		} `json:"runDetails"`
+
+		// SLSA v0.2
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		Invocation struct {
+			ConfigSource struct {
+				URI    string            `json:"uri"`
+				Digest map[string]string `json:"digest"`
+			} `json:"configSource"`
+		} `json:"invocation"`
+	} `json:"predicate"`
+}
+
+// parseStatement reads the builder, source repository and commit from an
+// in-toto statement holding SLSA provenance.
+func parseStatement(b []byte) (attestation, error) {
+	var s statement
+	if err := json.Unmarshal(b, &s); err != nil {
+		return attestation{}, err
+	}
+
+	p := s.Predicate
+	if p.RunDetails.Builder.ID != "" {
+		a := attestation{
+			builder:          p.RunDetails.Builder.ID,
+			sourceRepository: p.BuildDefinition.ExternalParameters.Workflow.Repository,
+		}
+		if deps := p.BuildDefinition.ResolvedDependencies; len(deps) > 0 {
+			if a.sourceRepository == "" {
+				a.sourceRepository = deps[0].URI
+			}
+			a.commit = deps[0].Digest["gitCommit"]
+		}
+		return a, nil
+	}
+
+	source := p.Invocation.ConfigSource
+	return attestation{
+		builder:          p.Builder.ID,
+		sourceRepository: source.URI,
+		commit:           source.Digest["sha1"],
+	}, nil
+}
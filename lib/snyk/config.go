@@ -16,13 +16,54 @@
 
 package snyk
 
+import (
+	"time"
+
+	"github.com/snyk/parlay/internal/fields"
+	"github.com/snyk/parlay/internal/pool"
+	"github.com/snyk/parlay/lib/report"
+)
+
 type Config struct {
-	SnykAPIURL string
-	APIToken   string
+	SnykAPIURL  string
+	APIToken    string
+	Concurrency int
+
+	// RequestTimeout bounds each request to Snyk, including its retries. A
+	// zero value leaves requests bound only by the context enrichment runs
+	// under.
+	RequestTimeout time.Duration
+
+	// Fields selects the fields to enrich. All fields are enriched if nil.
+	Fields *fields.Selection
+
+	// Report, if set, collects the outcome of enriching each package.
+	Report *report.Report
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		SnykAPIURL: "https://api.snyk.io",
+		SnykAPIURL:  "https://api.snyk.io",
+		Concurrency: pool.DefaultConcurrency,
+	}
+}
+
+// reportProvider names Snyk in enrichment reports.
+const reportProvider = "snyk"
+
+var knownFields = []string{"advisor", "vulnerability_db", "vulnerabilities"}
+
+var fieldAliases = map[string][]string{
+	"externalRefs": {"advisor", "vulnerability_db"},
+}
+
+// SelectFields restricts enrichment to the included fields, or to all fields
+// if none are included, minus the excluded fields.
+func (cfg *Config) SelectFields(include, exclude []string) error {
+	selection, err := fields.NewSelection(knownFields, fieldAliases, include, exclude)
+	if err != nil {
+		return err
 	}
+	cfg.Fields = selection
+	return nil
 }
@@ -17,6 +17,8 @@
 package snyk
 
 import (
+	"context"
+
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/rs/zerolog"
 	"github.com/spdx/tools-golang/spdx"
@@ -29,12 +31,20 @@ const (
 	snykVulnerabilityDBWebURL = "https://security.snyk.io"
 )
 
-func EnrichSBOM(cfg *Config, doc *sbom.SBOMDocument, logger *zerolog.Logger) *sbom.SBOMDocument {
+// EnrichSBOM enriches the packages of the SBOM with Snyk data. When ctx is
+// done, packages which weren't enriched yet are left as they are. If no
+// fields are selected, Snyk isn't contacted at all.
+func EnrichSBOM(ctx context.Context, cfg *Config, doc *sbom.SBOMDocument, logger *zerolog.Logger) *sbom.SBOMDocument {
+	if !cfg.Fields.Any(knownFields...) {
+		logger.Warn().Msg("No Snyk fields selected, leaving the SBOM as it is")
+		return doc
+	}
+
 	switch bom := doc.BOM.(type) {
 	case *cdx.BOM:
-		enrichCycloneDX(cfg, bom, logger)
+		enrichCycloneDX(ctx, cfg, bom, logger)
 	case *spdx.Document:
-		enrichSPDX(cfg, bom, logger)
+		enrichSPDX(ctx, cfg, bom, logger)
 	}
 
 	return doc
@@ -17,6 +17,7 @@
 package snyk
 
 import (
+	"context"
 	"encoding/json"
 	"strconv"
 	"sync"
@@ -24,18 +25,21 @@ import (
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/package-url/packageurl-go"
-	"github.com/remeh/sizedwaitgroup"
 	"github.com/rs/zerolog"
 
+	"github.com/snyk/parlay/internal/pool"
 	"github.com/snyk/parlay/internal/utils"
 	"github.com/snyk/parlay/snyk/issues"
 )
 
 type cdxEnricher = func(*Config, *cdx.Component, *packageurl.PackageURL)
 
-var cdxEnrichers = []cdxEnricher{
-	enrichCDXSnykAdvisorData,
-	enrichCDXSnykVulnerabilityDBData,
+var cdxEnrichers = []struct {
+	field  string
+	enrich cdxEnricher
+}{
+	{"advisor", enrichCDXSnykAdvisorData},
+	{"vulnerability_db", enrichCDXSnykVulnerabilityDBData},
 }
 
 func enrichCDXSnykVulnerabilityDBData(cfg *Config, component *cdx.Component, purl *packageurl.PackageURL) {
@@ -46,11 +50,7 @@ func enrichCDXSnykVulnerabilityDBData(cfg *Config, component *cdx.Component, pur
 			Comment: "Snyk Vulnerability DB",
 			Type:    "Other",
 		}
-		if component.ExternalReferences == nil {
-			component.ExternalReferences = &[]cdx.ExternalReference{ext}
-		} else {
-			*component.ExternalReferences = append(*component.ExternalReferences, ext)
-		}
+		utils.AddCDXExternalReference(component, ext)
 	}
 }
 
@@ -62,22 +62,27 @@ func enrichCDXSnykAdvisorData(cfg *Config, component *cdx.Component, purl *packa
 			Comment: "Snyk Advisor",
 			Type:    "Other",
 		}
-		if component.ExternalReferences == nil {
-			component.ExternalReferences = &[]cdx.ExternalReference{ext}
-		} else {
-			*component.ExternalReferences = append(*component.ExternalReferences, ext)
-		}
+		utils.AddCDXExternalReference(component, ext)
+	}
+}
+
+func cdxExternalReferenceCount(component *cdx.Component) int {
+	if component.ExternalReferences == nil {
+		return 0
 	}
+	return len(*component.ExternalReferences)
 }
 
-func enrichCycloneDX(cfg *Config, bom *cdx.BOM, logger *zerolog.Logger) *cdx.BOM {
+func enrichCycloneDX(ctx context.Context, cfg *Config, bom *cdx.BOM, logger *zerolog.Logger) *cdx.BOM {
 	auth, err := AuthFromToken(cfg.APIToken)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Failed to authenticate")
 		return nil
 	}
 
-	orgID, err := SnykOrgID(cfg, auth)
+	orgCtx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+	orgID, err := SnykOrgID(orgCtx, cfg, auth)
+	cancel()
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to infer preferred Snyk organization")
 		return nil
@@ -86,52 +91,69 @@ func enrichCycloneDX(cfg *Config, bom *cdx.BOM, logger *zerolog.Logger) *cdx.BOM
 
 	var mutex = &sync.Mutex{}
 	vulnerabilities := make(map[cdx.Component][]issues.CommonIssueModelVThree)
-	wg := sizedwaitgroup.New(20)
 
 	comps := utils.DiscoverCDXComponents(bom)
 	logger.Debug().Msgf("Detected %d packages", len(comps))
 
-	for i := range comps {
-		wg.Add()
-		go func(component *cdx.Component) {
-			defer wg.Done()
-			l := logger.With().Str("bom-ref", component.BOMRef).Logger()
+	pool.Run(cfg.Concurrency, comps, func(component *cdx.Component) {
+		l := logger.With().Str("bom-ref", component.BOMRef).Logger()
+		out := cfg.Report.Outcome(reportProvider, utils.CDXComponentID(component), component.PackageURL)
+		if err := ctx.Err(); err != nil {
+			out.Skip("enrichment interrupted: " + err.Error())
+			return
+		}
 
-			purl, err := packageurl.FromString(component.PackageURL)
-			if err != nil {
-				l.Debug().
-					Err(err).
-					Msg("Could not identify package")
-				return
-			}
-			for _, enrichFunc := range cdxEnrichers {
-				enrichFunc(cfg, component, &purl)
-			}
-			resp, err := GetPackageVulnerabilities(cfg, &purl, auth, orgID, logger)
-			if err != nil {
-				l.Err(err).
-					Str("purl", purl.ToString()).
-					Msg("Failed to fetch vulnerabilities for package")
-				return
+		purl, err := packageurl.FromString(component.PackageURL)
+		if err != nil {
+			l.Debug().
+				Err(err).
+				Msg("Could not identify package")
+			out.Skip("no usable PackageURL")
+			return
+		}
+		for _, enricher := range cdxEnrichers {
+			if cfg.Fields.Selected(enricher.field) {
+				refs := cdxExternalReferenceCount(component)
+				enricher.enrich(cfg, component, &purl)
+				if cdxExternalReferenceCount(component) > refs {
+					out.Added(enricher.field)
+				}
 			}
+		}
+		if !cfg.Fields.Selected("vulnerabilities") {
+			return
+		}
+		reqCtx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+		defer cancel()
+		resp, err := GetPackageVulnerabilities(reqCtx, cfg, &purl, auth, orgID, logger)
+		if err != nil {
+			l.Err(err).
+				Str("purl", purl.ToString()).
+				Msg("Failed to fetch vulnerabilities for package")
+			out.Fail("failed to fetch vulnerabilities: " + err.Error())
+			return
+		}
+		out.Response(resp.HTTPResponse)
 
-			packageData := resp.Body
-			var packageDoc issues.IssuesWithPurlsResponse
-			if err := json.Unmarshal(packageData, &packageDoc); err != nil {
-				l.Err(err).
-					Str("status", resp.Status()).
-					Msg("Failed to decode Snyk vulnerability response")
-				return
-			}
+		packageData := resp.Body
+		var packageDoc issues.IssuesWithPurlsResponse
+		if err := json.Unmarshal(packageData, &packageDoc); err != nil {
+			l.Err(err).
+				Str("status", resp.Status()).
+				Msg("Failed to decode Snyk vulnerability response")
+			out.Fail("failed to decode vulnerability response: " + err.Error())
+			return
+		}
 
-			if packageDoc.Data != nil {
-				mutex.Lock()
-				vulnerabilities[*component] = *packageDoc.Data
-				mutex.Unlock()
+		if packageDoc.Data != nil {
+			mutex.Lock()
+			vulnerabilities[*component] = *packageDoc.Data
+			mutex.Unlock()
+			if len(*packageDoc.Data) > 0 {
+				out.Added("vulnerabilities")
 			}
-		}(comps[i])
-	}
-	wg.Wait()
+		}
+	})
 
 	var vulns []cdx.Vulnerability
 	for k, v := range vulnerabilities {
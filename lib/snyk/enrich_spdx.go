@@ -17,26 +17,30 @@
 package snyk
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"sync"
 
 	"github.com/package-url/packageurl-go"
-	"github.com/remeh/sizedwaitgroup"
 	"github.com/rs/zerolog"
 	"github.com/spdx/tools-golang/spdx"
 	spdx_2_3 "github.com/spdx/tools-golang/spdx/v2/v2_3"
 
+	"github.com/snyk/parlay/internal/pool"
 	"github.com/snyk/parlay/internal/utils"
 	"github.com/snyk/parlay/snyk/issues"
 )
 
 type spdxEnricher = func(*Config, *spdx_2_3.Package, *packageurl.PackageURL)
 
-var spdxEnrichers = []spdxEnricher{
-	enrichSPDXSnykAdvisorData,
-	enrichSPDXSnykVulnerabilityDBData,
+var spdxEnrichers = []struct {
+	field  string
+	enrich spdxEnricher
+}{
+	{"advisor", enrichSPDXSnykAdvisorData},
+	{"vulnerability_db", enrichSPDXSnykVulnerabilityDBData},
 }
 
 func enrichSPDXSnykAdvisorData(cfg *Config, component *spdx_2_3.Package, purl *packageurl.PackageURL) {
@@ -48,11 +52,7 @@ func enrichSPDXSnykAdvisorData(cfg *Config, component *spdx_2_3.Package, purl *p
 			Category:           spdx.CategoryOther,
 			ExternalRefComment: "Snyk Advisor",
 		}
-		if component.PackageExternalReferences == nil {
-			component.PackageExternalReferences = []*spdx_2_3.PackageExternalReference{ext}
-		} else {
-			component.PackageExternalReferences = append(component.PackageExternalReferences, ext)
-		}
+		utils.AddSPDXExternalReference(component, ext)
 	}
 }
 
@@ -65,15 +65,11 @@ func enrichSPDXSnykVulnerabilityDBData(cfg *Config, component *spdx_2_3.Package,
 			Category:           spdx.CategoryOther,
 			ExternalRefComment: "Snyk Vulnerability DB",
 		}
-		if component.PackageExternalReferences == nil {
-			component.PackageExternalReferences = []*spdx_2_3.PackageExternalReference{ext}
-		} else {
-			component.PackageExternalReferences = append(component.PackageExternalReferences, ext)
-		}
+		utils.AddSPDXExternalReference(component, ext)
 	}
 }
 
-func enrichSPDX(cfg *Config, bom *spdx.Document, logger *zerolog.Logger) *spdx.Document {
+func enrichSPDX(ctx context.Context, cfg *Config, bom *spdx.Document, logger *zerolog.Logger) *spdx.Document {
 	auth, err := AuthFromToken(cfg.APIToken)
 	if err != nil {
 		logger.Fatal().
@@ -82,7 +78,9 @@ func enrichSPDX(cfg *Config, bom *spdx.Document, logger *zerolog.Logger) *spdx.D
 		return nil
 	}
 
-	orgID, err := SnykOrgID(cfg, auth)
+	orgCtx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+	orgID, err := SnykOrgID(orgCtx, cfg, auth)
+	cancel()
 	if err != nil {
 		logger.Fatal().
 			Err(err).
@@ -91,53 +89,69 @@ func enrichSPDX(cfg *Config, bom *spdx.Document, logger *zerolog.Logger) *spdx.D
 	}
 
 	mutex := &sync.Mutex{}
-	wg := sizedwaitgroup.New(20)
 	vulnerabilities := make(map[*spdx_2_3.Package][]issues.CommonIssueModelVThree)
 
 	packages := bom.Packages
 	logger.Debug().Msgf("Detected %d packages", len(packages))
 
-	for i, pkg := range packages {
-		wg.Add()
-
-		go func(pkg *spdx_2_3.Package, i int) {
-			defer wg.Done()
-			l := logger.With().Str("SPDXID", string(pkg.PackageSPDXIdentifier)).Logger()
-
-			purl, err := utils.GetPurlFromSPDXPackage(pkg)
-			if err != nil || purl == nil {
-				l.Debug().Msg("Could not identify package")
-				return
-			}
-			for _, enrichFn := range spdxEnrichers {
-				enrichFn(cfg, pkg, purl)
-			}
-			resp, err := GetPackageVulnerabilities(cfg, purl, auth, orgID, logger)
-			if err != nil {
-				l.Err(err).
-					Str("purl", purl.ToString()).
-					Msg("Failed to fetch vulnerabilities for package")
-				return
-			}
+	pool.Run(cfg.Concurrency, packages, func(pkg *spdx_2_3.Package) {
+		l := logger.With().Str("SPDXID", string(pkg.PackageSPDXIdentifier)).Logger()
+		out := cfg.Report.Outcome(reportProvider, string(pkg.PackageSPDXIdentifier), "")
+		if err := ctx.Err(); err != nil {
+			out.Skip("enrichment interrupted: " + err.Error())
+			return
+		}
 
-			packageData := resp.Body
-			var packageDoc issues.IssuesWithPurlsResponse
-			if err := json.Unmarshal(packageData, &packageDoc); err != nil {
-				l.Err(err).
-					Str("status", resp.Status()).
-					Msg("Failed to decode Snyk vulnerability response")
-				return
+		purl, err := utils.GetPurlFromSPDXPackage(pkg)
+		if err != nil || purl == nil {
+			l.Debug().Msg("Could not identify package")
+			out.Skip("no usable PackageURL")
+			return
+		}
+		out = cfg.Report.Outcome(reportProvider, string(pkg.PackageSPDXIdentifier), purl.String())
+		for _, enricher := range spdxEnrichers {
+			if cfg.Fields.Selected(enricher.field) {
+				refs := len(pkg.PackageExternalReferences)
+				enricher.enrich(cfg, pkg, purl)
+				if len(pkg.PackageExternalReferences) > refs {
+					out.Added(enricher.field)
+				}
 			}
+		}
+		if !cfg.Fields.Selected("vulnerabilities") {
+			return
+		}
+		reqCtx, cancel := utils.WithRequestTimeout(ctx, cfg.RequestTimeout)
+		defer cancel()
+		resp, err := GetPackageVulnerabilities(reqCtx, cfg, purl, auth, orgID, logger)
+		if err != nil {
+			l.Err(err).
+				Str("purl", purl.ToString()).
+				Msg("Failed to fetch vulnerabilities for package")
+			out.Fail("failed to fetch vulnerabilities: " + err.Error())
+			return
+		}
+		out.Response(resp.HTTPResponse)
+
+		packageData := resp.Body
+		var packageDoc issues.IssuesWithPurlsResponse
+		if err := json.Unmarshal(packageData, &packageDoc); err != nil {
+			l.Err(err).
+				Str("status", resp.Status()).
+				Msg("Failed to decode Snyk vulnerability response")
+			out.Fail("failed to decode vulnerability response: " + err.Error())
+			return
+		}
 
-			if packageDoc.Data != nil {
-				mutex.Lock()
-				vulnerabilities[pkg] = *packageDoc.Data
-				mutex.Unlock()
+		if packageDoc.Data != nil {
+			mutex.Lock()
+			vulnerabilities[pkg] = *packageDoc.Data
+			mutex.Unlock()
+			if len(*packageDoc.Data) > 0 {
+				out.Added("vulnerabilities")
 			}
-		}(pkg, i)
-	}
-
-	wg.Wait()
+		}
+	})
 
 	for pkg, vulns := range vulnerabilities {
 		for _, issue := range vulns {
@@ -158,7 +172,7 @@ func enrichSPDX(cfg *Config, bom *spdx.Document, logger *zerolog.Logger) *spdx.D
 				ref.ExternalRefComment = *issue.Attributes.Title
 			}
 
-			pkg.PackageExternalReferences = append(pkg.PackageExternalReferences, ref)
+			utils.AddSPDXExternalReference(pkg, ref)
 		}
 	}
 
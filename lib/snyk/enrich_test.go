@@ -1,6 +1,7 @@
 package snyk
 
 import (
+	"context"
 	_ "embed"
 	"net/http"
 	"net/http/httptest"
@@ -40,7 +41,7 @@ func TestEnrichSBOM_CycloneDXWithVulnerabilities(t *testing.T) {
 	}
 	doc := &sbom.SBOMDocument{BOM: bom}
 
-	svc.EnrichSBOM(doc)
+	svc.EnrichSBOM(context.Background(), doc)
 
 	require.NotNil(t, bom.Vulnerabilities)
 	assert.Len(t, *bom.Vulnerabilities, 1)
@@ -71,7 +72,7 @@ func TestEnrichSBOM_CycloneDXExternalRefs(t *testing.T) {
 	}
 	doc := &sbom.SBOMDocument{BOM: bom}
 
-	svc.EnrichSBOM(doc)
+	svc.EnrichSBOM(context.Background(), doc)
 
 	require.NotNil(t, bom.Components)
 	refs := (*bom.Components)[0].ExternalReferences
@@ -103,7 +104,7 @@ func TestEnrichSBOM_CycloneDXExternalRefs_WithNamespace(t *testing.T) {
 	}
 	doc := &sbom.SBOMDocument{BOM: bom}
 
-	svc.EnrichSBOM(doc)
+	svc.EnrichSBOM(context.Background(), doc)
 
 	require.NotNil(t, bom.Components)
 	refs := (*bom.Components)[0].ExternalReferences
@@ -143,7 +144,7 @@ func TestEnrichSBOM_CycloneDXWithVulnerabilities_NestedComponents(t *testing.T)
 	}
 	doc := &sbom.SBOMDocument{BOM: bom}
 
-	svc.EnrichSBOM(doc)
+	svc.EnrichSBOM(context.Background(), doc)
 
 	require.NotNil(t, bom.Vulnerabilities)
 	assert.Len(t, *bom.Vulnerabilities, 2)
@@ -164,7 +165,7 @@ func TestEnrichSBOM_CycloneDXWithoutVulnerabilities(t *testing.T) {
 	}
 	doc := &sbom.SBOMDocument{BOM: bom}
 
-	svc.EnrichSBOM(doc)
+	svc.EnrichSBOM(context.Background(), doc)
 
 	assert.Nil(t, bom.Vulnerabilities, "should not extend vulnerabilities if there are none")
 }
@@ -190,7 +191,7 @@ func TestEnrichSBOM_SPDXWithVulnerabilities(t *testing.T) {
 	}
 	doc := &sbom.SBOMDocument{BOM: bom}
 
-	svc.EnrichSBOM(doc)
+	svc.EnrichSBOM(context.Background(), doc)
 
 	vulnRef := bom.Packages[0].PackageExternalReferences[3]
 	assert.Equal(t, "SECURITY", vulnRef.Category)
@@ -221,7 +222,7 @@ func TestEnrichSBOM_SPDXExternalRefs(t *testing.T) {
 
 	doc := &sbom.SBOMDocument{BOM: bom}
 
-	svc.EnrichSBOM(doc)
+	svc.EnrichSBOM(context.Background(), doc)
 
 	assert.NotNil(t, bom.Packages)
 	refs := (*bom.Packages[0]).PackageExternalReferences
@@ -240,9 +241,95 @@ func TestEnrichSBOM_SPDXExternalRefs(t *testing.T) {
 	assert.Equal(t, spdx.CategoryOther, ref2.Category)
 }
 
+func TestEnrichSBOM_CycloneDXSelectedFields(t *testing.T) {
+	cfg := setupTestConfig(t)
+	require.NoError(t, cfg.SelectFields([]string{"vulnerabilities"}, nil))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{
+				BOMRef:     "pkg:pypi/numpy@1.16.0",
+				Name:       "numpy",
+				Version:    "1.16.0",
+				PackageURL: "pkg:pypi/numpy@1.16.0",
+			},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	require.NotNil(t, bom.Vulnerabilities)
+	assert.Len(t, *bom.Vulnerabilities, 1)
+	assert.Nil(t, (*bom.Components)[0].ExternalReferences)
+}
+
+func TestEnrichSBOM_SPDXExcludedFields(t *testing.T) {
+	cfg := setupTestConfig(t)
+	require.NoError(t, cfg.SelectFields(nil, []string{"vulnerabilities", "advisor"}))
+
+	bom := &spdx_2_3.Document{
+		Packages: []*spdx_2_3.Package{
+			{
+				PackageSPDXIdentifier: "pkg:pypi/numpy@1.16.0",
+				PackageName:           "numpy",
+				PackageVersion:        "1.16.0",
+				PackageExternalReferences: []*spdx_2_3.PackageExternalReference{
+					{
+						Category: spdx.CategoryPackageManager,
+						RefType:  "purl",
+						Locator:  "pkg:pypi/numpy@1.16.0",
+					},
+				},
+			},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	refs := bom.Packages[0].PackageExternalReferences
+	require.Len(t, refs, 2)
+	assert.Equal(t, "https://security.snyk.io/package/pip/numpy", refs[1].Locator)
+}
+
+func TestEnrichSBOM_NoSelectedFields(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := DefaultConfig()
+	cfg.APIToken = "asdf"
+	cfg.SnykAPIURL = srv.URL
+	require.NoError(t, cfg.SelectFields(nil, []string{"externalRefs", "vulnerabilities"}))
+
+	bom := &cdx.BOM{
+		Components: &[]cdx.Component{
+			{BOMRef: "numpy", PackageURL: "pkg:pypi/numpy@1.16.0"},
+		},
+	}
+	doc := &sbom.SBOMDocument{BOM: bom}
+	logger := zerolog.Nop()
+
+	EnrichSBOM(context.Background(), cfg, doc, &logger)
+
+	assert.Nil(t, bom.Vulnerabilities)
+	assert.Nil(t, (*bom.Components)[0].ExternalReferences)
+}
+
 func setupTestEnv(t *testing.T) Service {
 	t.Helper()
 
+	logger := zerolog.Nop()
+	return NewService(setupTestConfig(t), &logger)
+}
+
+func setupTestConfig(t *testing.T) *Config {
+	t.Helper()
+
 	mux := http.NewServeMux()
 
 	mux.HandleFunc(
@@ -276,10 +363,7 @@ func setupTestEnv(t *testing.T) Service {
 	cfg.APIToken = "asdf"
 	cfg.SnykAPIURL = srv.URL
 
-	logger := zerolog.Nop()
-	svc := NewService(cfg, &logger)
-
-	return svc
+	return cfg
 }
 
 func respond(w http.ResponseWriter, data []byte) {
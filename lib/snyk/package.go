@@ -20,15 +20,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"strconv"
-	"time"
 
 	"github.com/deepmap/oapi-codegen/pkg/securityprovider"
 	"github.com/google/uuid"
-	"github.com/hashicorp/go-retryablehttp"
 	"github.com/package-url/packageurl-go"
 	"github.com/rs/zerolog"
 
+	"github.com/snyk/parlay/internal/httpclient"
 	"github.com/snyk/parlay/snyk/issues"
 )
 
@@ -86,61 +84,25 @@ func SnykVulnURL(cfg *Config, purl *packageurl.PackageURL) string {
 	return url
 }
 
-func GetPackageVulnerabilities(cfg *Config, purl *packageurl.PackageURL, auth *securityprovider.SecurityProviderApiKey, orgID *uuid.UUID, logger *zerolog.Logger) (*issues.FetchIssuesPerPurlResponse, error) {
+func GetPackageVulnerabilities(ctx context.Context, cfg *Config, purl *packageurl.PackageURL, auth *securityprovider.SecurityProviderApiKey, orgID *uuid.UUID, logger *zerolog.Logger) (*issues.FetchIssuesPerPurlResponse, error) {
 	client, err := issues.NewClientWithResponses(
 		cfg.SnykAPIURL+"/rest",
 		issues.WithRequestEditorFn(auth.Intercept),
-		issues.WithHTTPClient(getRetryClient(logger)))
+		issues.WithHTTPClient(httpclient.Default()))
 	if err != nil {
 		return nil, err
 	}
 
 	params := issues.FetchIssuesPerPurlParams{Version: version}
-	resp, err := client.FetchIssuesPerPurlWithResponse(context.Background(), *orgID, purl.ToString(), &params)
+	resp, err := client.FetchIssuesPerPurlWithResponse(ctx, *orgID, purl.ToString(), &params)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode() != http.StatusOK {
+		logger.Warn().Msgf("Unexpected status code (%s) for %s", resp.Status(), purl.ToString())
 		return resp, fmt.Errorf("unsuccessful request (%s)", resp.Status())
 	}
 
 	return resp, nil
 }
-
-func getRetryClient(logger *zerolog.Logger) *http.Client {
-	rc := retryablehttp.NewClient()
-	rc.RetryMax = 20
-	rc.Logger = nil
-	rc.ErrorHandler = retryablehttp.PassthroughErrorHandler
-	rc.ResponseLogHook = func(_ retryablehttp.Logger, r *http.Response) {
-		if r != nil && r.StatusCode >= 400 {
-			logger.Warn().Msgf("Unexpected status code (%s) for %s %s", r.Status, r.Request.Method, r.Request.URL.String())
-		}
-	}
-	rc.Backoff = func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
-		if resp != nil {
-			if sleep, ok := parseRateLimitHeader(resp.Header.Get("X-RateLimit-Reset")); ok {
-				logger.Warn().
-					Dur("Retry-After", sleep).
-					Msg("Getting rate-limited, waiting...")
-				return sleep
-			}
-		}
-		return retryablehttp.DefaultBackoff(min, max, attemptNum, resp)
-	}
-
-	return rc.StandardClient()
-}
-
-func parseRateLimitHeader(v string) (time.Duration, bool) {
-	if v == "" {
-		return 0, false
-	}
-
-	if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
-		return time.Duration(sec) * time.Second, true
-	}
-
-	return 0, false
-}
@@ -17,6 +17,7 @@
 package snyk
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -52,7 +53,7 @@ func TestGetPackageVulnerabilities_RetryRateLimited(t *testing.T) {
 	require.NoError(t, err)
 
 	orgID := uuid.New()
-	issues, err := GetPackageVulnerabilities(cfg, &purl, auth, &orgID, &logger)
+	issues, err := GetPackageVulnerabilities(context.Background(), cfg, &purl, auth, &orgID, &logger)
 	require.NoError(t, err)
 
 	assert.Equal(t, 2, numRequests, "retries failed requests")
@@ -84,7 +85,7 @@ func TestGetPackageVulnerabilities_HandlesNilResponses(t *testing.T) {
 	require.NoError(t, err)
 
 	orgID := uuid.New()
-	issues, err := GetPackageVulnerabilities(cfg, &purl, auth, &orgID, &logger)
+	issues, err := GetPackageVulnerabilities(context.Background(), cfg, &purl, auth, &orgID, &logger)
 
 	require.Error(t, err)
 	assert.Nil(t, issues)
@@ -25,21 +25,23 @@ import (
 	"github.com/deepmap/oapi-codegen/pkg/securityprovider"
 	"github.com/google/uuid"
 
+	"github.com/snyk/parlay/internal/httpclient"
 	"github.com/snyk/parlay/snyk/users"
 )
 
 const experimentalVersion = "2023-04-28~experimental"
 
-func SnykOrgID(cfg *Config, auth *securityprovider.SecurityProviderApiKey) (*uuid.UUID, error) {
+func SnykOrgID(ctx context.Context, cfg *Config, auth *securityprovider.SecurityProviderApiKey) (*uuid.UUID, error) {
 	experimental, err := users.NewClientWithResponses(
 		cfg.SnykAPIURL+"/rest",
-		users.WithRequestEditorFn(auth.Intercept))
+		users.WithRequestEditorFn(auth.Intercept),
+		users.WithHTTPClient(httpclient.Default()))
 	if err != nil {
 		return nil, err
 	}
 
 	userParams := users.GetSelfParams{Version: experimentalVersion}
-	self, err := experimental.GetSelfWithResponse(context.Background(), &userParams)
+	self, err := experimental.GetSelfWithResponse(ctx, &userParams)
 	if err != nil {
 		return nil, err
 	}
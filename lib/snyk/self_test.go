@@ -17,6 +17,7 @@
 package snyk
 
 import (
+	"context"
 	_ "embed"
 	"net/http"
 	"net/http/httptest"
@@ -42,7 +43,7 @@ func TestSnykOrgID_Success(t *testing.T) {
 	auth, err := securityprovider.NewSecurityProviderApiKey("header", "authorization", "asdf")
 	require.NoError(t, err)
 
-	actualOrg, err := SnykOrgID(cfg, auth)
+	actualOrg, err := SnykOrgID(context.Background(), cfg, auth)
 
 	assert.NoError(t, err)
 	assert.Equal(t, uuid.MustParse("00000000-0000-0000-0000-000000000000"), *actualOrg)
@@ -60,7 +61,7 @@ func TestSnykOrgID_Unauthorized(t *testing.T) {
 	auth, err := securityprovider.NewSecurityProviderApiKey("header", "authorization", "asdf")
 	require.NoError(t, err)
 
-	actualOrg, err := SnykOrgID(cfg, auth)
+	actualOrg, err := SnykOrgID(context.Background(), cfg, auth)
 
 	assert.ErrorContains(t, err, "Failed to get user info (401 Unauthorized)")
 	assert.Nil(t, actualOrg)
@@ -1,18 +1,21 @@
 package snyk
 
 import (
+	"context"
+
 	"github.com/deepmap/oapi-codegen/pkg/securityprovider"
 	"github.com/google/uuid"
 	"github.com/package-url/packageurl-go"
 	"github.com/rs/zerolog"
 
+	"github.com/snyk/parlay/internal/utils"
 	"github.com/snyk/parlay/lib/sbom"
 	"github.com/snyk/parlay/snyk/issues"
 )
 
 type Service interface {
-	EnrichSBOM(*sbom.SBOMDocument) *sbom.SBOMDocument
-	GetPackageVulnerabilities(*packageurl.PackageURL) (*issues.FetchIssuesPerPurlResponse, error)
+	EnrichSBOM(context.Context, *sbom.SBOMDocument) *sbom.SBOMDocument
+	GetPackageVulnerabilities(context.Context, *packageurl.PackageURL) (*issues.FetchIssuesPerPurlResponse, error)
 }
 
 type serviceImpl struct {
@@ -26,28 +29,34 @@ func NewService(cfg *Config, logger *zerolog.Logger) Service {
 	return &serviceImpl{cfg, logger}
 }
 
-func (svc *serviceImpl) EnrichSBOM(doc *sbom.SBOMDocument) *sbom.SBOMDocument {
-	return EnrichSBOM(svc.cfg, doc, svc.logger)
+func (svc *serviceImpl) EnrichSBOM(ctx context.Context, doc *sbom.SBOMDocument) *sbom.SBOMDocument {
+	return EnrichSBOM(ctx, svc.cfg, doc, svc.logger)
 }
 
-func (svc *serviceImpl) GetPackageVulnerabilities(purl *packageurl.PackageURL) (*issues.FetchIssuesPerPurlResponse, error) {
+func (svc *serviceImpl) GetPackageVulnerabilities(ctx context.Context, purl *packageurl.PackageURL) (*issues.FetchIssuesPerPurlResponse, error) {
 	auth, err := svc.getAuth()
 	if err != nil {
 		return nil, err
 	}
 
-	orgID, err := svc.getOrgID(auth)
+	orgID, err := svc.getOrgID(ctx, auth)
 	if err != nil {
 		return nil, err
 	}
 
-	return GetPackageVulnerabilities(svc.cfg, purl, auth, orgID, svc.logger)
+	ctx, cancel := utils.WithRequestTimeout(ctx, svc.cfg.RequestTimeout)
+	defer cancel()
+
+	return GetPackageVulnerabilities(ctx, svc.cfg, purl, auth, orgID, svc.logger)
 }
 
 func (svc *serviceImpl) getAuth() (*securityprovider.SecurityProviderApiKey, error) {
 	return AuthFromToken(svc.cfg.APIToken)
 }
 
-func (svc *serviceImpl) getOrgID(auth *securityprovider.SecurityProviderApiKey) (*uuid.UUID, error) {
-	return SnykOrgID(svc.cfg, auth)
+func (svc *serviceImpl) getOrgID(ctx context.Context, auth *securityprovider.SecurityProviderApiKey) (*uuid.UUID, error) {
+	ctx, cancel := utils.WithRequestTimeout(ctx, svc.cfg.RequestTimeout)
+	defer cancel()
+
+	return SnykOrgID(ctx, svc.cfg, auth)
 }
@@ -17,13 +17,26 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/snyk/parlay/internal/commands"
 )
 
 func main() {
-	if err := commands.NewDefaultCommand().Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		// Commands get to write out partial results after the first
+		// interrupt, a second one exits straight away.
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := commands.NewDefaultCommand().ExecuteContext(ctx)
+	stop()
+	if err != nil {
 		os.Exit(1)
 	}
 }